@@ -0,0 +1,52 @@
+package hmux
+
+import (
+	"net/http"
+	"time"
+)
+
+// timeNow is replaced in tests.
+var timeNow = time.Now
+
+// Active returns a RuleOption which restricts a rule to the time window
+// beginning at notBefore and ending at notAfter. A zero time leaves the
+// corresponding side of the window unbounded.
+//
+// Before the window opens, the Mux ignores the rule and continues looking for
+// a matching rule, as if it had not been registered. After the window closes,
+// the Mux responds to requests matching the rule with a 410 ("Gone") unless
+// another rule for the same pattern and method matches. For example, to
+// replace one handler with another at a scheduled time:
+//
+//	b.Get("/report", oldReport, hmux.Active(time.Time{}, launch))
+//	b.Get("/report", newReport, hmux.Active(launch, time.Time{}))
+//
+// Active panics if notAfter precedes notBefore.
+func Active(notBefore, notAfter time.Time) RuleOption {
+	if !notBefore.IsZero() && !notAfter.IsZero() && notAfter.Before(notBefore) {
+		panic("hmux: Active called with notAfter before notBefore")
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, activeCond{notBefore, notAfter})
+	}
+}
+
+type activeCond struct {
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+func (c activeCond) check(*http.Request, *rule, *Params) int {
+	now := timeNow()
+	if !c.notBefore.IsZero() && now.Before(c.notBefore) {
+		return condSkip
+	}
+	if !c.notAfter.IsZero() && !now.Before(c.notAfter) {
+		return http.StatusGone
+	}
+	return condOK
+}
+
+func (c activeCond) key() string {
+	return "active:" + c.notBefore.Format(time.RFC3339Nano) + "/" + c.notAfter.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,49 @@
+package hmux
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActive(t *testing.T) {
+	launch := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBuilder()
+	b.Get("/report", testHandler("old"), Active(time.Time{}, launch))
+	b.Get("/report", testHandler("new"), Active(launch, time.Time{}))
+	b.Get("/promo", testHandler("promo"), Active(launch, sunset))
+	b.Get("/:page", testHandler("page %s", "page"))
+	mux := b.Build()
+
+	defer func() { timeNow = time.Now }()
+	for _, tt := range []struct {
+		now   time.Time
+		tests []reqTest
+	}{
+		{
+			launch.Add(-time.Second),
+			[]reqTest{
+				{"GET", "/report", "old"},
+				{"GET", "/promo", "page promo"},
+			},
+		},
+		{
+			launch,
+			[]reqTest{
+				{"GET", "/report", "new"},
+				{"GET", "/promo", "promo"},
+			},
+		},
+		{
+			sunset,
+			[]reqTest{
+				{"GET", "/report", "new"},
+				{"GET", "/promo", "410"},
+			},
+		},
+	} {
+		now := tt.now
+		timeNow = func() time.Time { return now }
+		testRequests(t, mux, tt.tests)
+	}
+}
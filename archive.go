@@ -0,0 +1,270 @@
+package hmux
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// An ArchiveFormat is a file format in which ServeArchive streams files.
+type ArchiveFormat int
+
+// These are the supported archive formats.
+const (
+	ArchiveZip     ArchiveFormat = iota // .zip
+	ArchiveTar                          // .tar
+	ArchiveTarGzip                      // .tar.gz
+)
+
+// ArchiveOptions configures ServeArchive.
+type ArchiveOptions struct {
+	// Format is the format of the archive.
+	Format ArchiveFormat
+	// Include, if non-empty, restricts the archive to files whose paths
+	// (relative to the archived directory) match at least one of the
+	// given globs.
+	Include []string
+	// Exclude lists globs matching the paths of files to leave out of the
+	// archive.
+	Exclude []string
+}
+
+// ServeArchive registers a GET handler for the given pattern that streams an
+// archive of the files in a directory tree of fsys. If pat is a wildcard
+// pattern, the archived directory is the path remainder matched by the
+// wildcard; otherwise, it is the root of fsys. For example,
+//
+//	b.ServeArchive("/export/*", files, hmux.ArchiveOptions{
+//		Format:  hmux.ArchiveZip,
+//		Exclude: []string{"**/*.tmp"},
+//	})
+//
+// responds to a request for /export/reports/2024 with a zip file, named
+// 2024.zip, holding the regular files beneath reports/2024 in fsys other
+// than those ending in .tmp.
+//
+// The globs in ao use the syntax of pattern globs (see Patterns), without
+// URL escaping: a segment ** matches any number of path segments, and, in
+// other segments, * matches any sequence of characters. Files are matched
+// using their slash-separated paths relative to the archived directory.
+//
+// If the directory does not exist, the handler responds with a 404. Since
+// the archive is streamed as the files are read, an error reading a file
+// after the response has begun aborts the response (see
+// http.ErrAbortHandler). The rule is marked as Streaming.
+//
+// ServeArchive panics if ao has an invalid format or glob.
+func (b *Builder) ServeArchive(pat string, fsys fs.FS, ao ArchiveOptions, opts ...RuleOption) {
+	h, err := newArchiveHandler(fsys, ao)
+	if err != nil {
+		panic("hmux: " + err.Error())
+	}
+	pat, p, err := b.parsePattern(pat)
+	if err != nil {
+		panic("hmux: " + err.Error())
+	}
+	opts = append([]RuleOption{Streaming()}, opts...)
+	if err := b.addHandler(http.MethodGet, pat, p, h, opts); err != nil {
+		panic("hmux: " + err.Error())
+	}
+}
+
+type archiveHandler struct {
+	fsys    fs.FS
+	format  ArchiveFormat
+	include [][]string
+	exclude [][]string
+}
+
+func newArchiveHandler(fsys fs.FS, ao ArchiveOptions) (*archiveHandler, error) {
+	switch ao.Format {
+	case ArchiveZip, ArchiveTar, ArchiveTarGzip:
+	default:
+		return nil, fmt.Errorf("invalid archive format %d", ao.Format)
+	}
+	h := &archiveHandler{fsys: fsys, format: ao.Format}
+	for _, s := range ao.Include {
+		glob, err := parseFileGlob(s)
+		if err != nil {
+			return nil, err
+		}
+		h.include = append(h.include, glob)
+	}
+	for _, s := range ao.Exclude {
+		glob, err := parseFileGlob(s)
+		if err != nil {
+			return nil, err
+		}
+		h.exclude = append(h.exclude, glob)
+	}
+	return h, nil
+}
+
+// parseFileGlob parses a glob matching slash-separated file paths.
+func parseFileGlob(s string) ([]string, error) {
+	glob := strings.Split(s, "/")
+	for _, g := range glob {
+		if g == "" {
+			return nil, fmt.Errorf("archive glob %q contains an empty segment", s)
+		}
+		if strings.Contains(g, "**") && g != "**" {
+			return nil, fmt.Errorf("archive glob %q contains ** within a segment", s)
+		}
+	}
+	return glob, nil
+}
+
+func (h *archiveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dir := "."
+	if p := RequestParams(r); p != nil && p.hasWildcard {
+		dir = strings.TrimPrefix(path.Clean(p.wildcard), "/")
+		if dir == "" {
+			dir = "."
+		}
+	}
+	if fi, err := fs.Stat(h.fsys, dir); err != nil || !fi.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := "archive"
+	if dir != "." {
+		name = path.Base(dir)
+	}
+	var contentType string
+	switch h.format {
+	case ArchiveZip:
+		contentType, name = "application/zip", name+".zip"
+	case ArchiveTar:
+		contentType, name = "application/x-tar", name+".tar"
+	case ArchiveTarGzip:
+		contentType, name = "application/gzip", name+".tar.gz"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+
+	if err := h.writeArchive(w, dir); err != nil {
+		// The response has begun, so the best we can do is abort it.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func (h *archiveHandler) writeArchive(w io.Writer, dir string) error {
+	var aw interface {
+		add(name string, fi fs.FileInfo, r io.Reader) error
+		Close() error
+	}
+	switch h.format {
+	case ArchiveZip:
+		aw = zipWriter{zip.NewWriter(w)}
+	case ArchiveTar:
+		aw = tarWriter{Writer: tar.NewWriter(w)}
+	case ArchiveTarGzip:
+		gw := gzip.NewWriter(w)
+		aw = tarWriter{tar.NewWriter(gw), gw}
+	}
+	err := fs.WalkDir(h.fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel := name
+		if dir != "." {
+			rel = strings.TrimPrefix(name, dir+"/")
+		}
+		if !h.includes(rel) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := h.fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return aw.add(rel, fi, f)
+	})
+	if err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+// includes reports whether the file with the relative path rel belongs in
+// the archive.
+func (h *archiveHandler) includes(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for _, glob := range h.exclude {
+		if matchGlob(glob, parts, 0) {
+			return false
+		}
+	}
+	if len(h.include) == 0 {
+		return true
+	}
+	for _, glob := range h.include {
+		if matchGlob(glob, parts, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+type zipWriter struct {
+	*zip.Writer
+}
+
+func (zw zipWriter) add(name string, fi fs.FileInfo, r io.Reader) error {
+	hdr, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	hdr.Method = zip.Deflate
+	fw, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+type tarWriter struct {
+	*tar.Writer
+	gw *gzip.Writer // if compressed
+}
+
+func (tw tarWriter) add(name string, fi fs.FileInfo, r io.Reader) error {
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, r)
+	return err
+}
+
+func (tw tarWriter) Close() error {
+	if err := tw.Writer.Close(); err != nil {
+		return err
+	}
+	if tw.gw != nil {
+		return tw.gw.Close()
+	}
+	return nil
+}
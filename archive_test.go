@@ -0,0 +1,123 @@
+package hmux
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServeArchive(t *testing.T) {
+	fsys := fstest.MapFS{
+		"top.txt":                  &fstest.MapFile{Data: []byte("top")},
+		"reports/2024/q1.csv":      &fstest.MapFile{Data: []byte("q1")},
+		"reports/2024/q2.csv":      &fstest.MapFile{Data: []byte("q2")},
+		"reports/2024/scratch.tmp": &fstest.MapFile{Data: []byte("tmp")},
+		"reports/2024/sub/a.csv":   &fstest.MapFile{Data: []byte("a")},
+	}
+	b := NewBuilder()
+	b.ServeArchive("/zip/*", fsys, ArchiveOptions{
+		Format:  ArchiveZip,
+		Exclude: []string{"**/*.tmp"},
+	})
+	b.ServeArchive("/tar/*", fsys, ArchiveOptions{
+		Format:  ArchiveTar,
+		Include: []string{"*.csv"},
+	})
+	b.ServeArchive("/all.tar.gz", fsys, ArchiveOptions{Format: ArchiveTarGzip})
+	mux := b.Build()
+
+	get := func(pth string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", pth, nil))
+		return w
+	}
+
+	w := get("/zip/reports/2024")
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename=2024.zip`; got != want {
+		t.Errorf("got Content-Disposition %q; want %q", got, want)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Name] = string(data)
+	}
+	want := map[string]string{"q1.csv": "q1", "q2.csv": "q2", "sub/a.csv": "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("zip: got files %v; want %v", got, want)
+	}
+
+	if got, want := tarNames(t, get("/tar/reports/2024").Body), []string{"q1.csv", "q2.csv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tar: got files %q; want %q", got, want)
+	}
+
+	w = get("/all.tar.gz")
+	if got, want := w.Header().Get("Content-Type"), "application/gzip"; got != want {
+		t.Errorf("got Content-Type %q; want %q", got, want)
+	}
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantAll := []string{
+		"reports/2024/q1.csv",
+		"reports/2024/q2.csv",
+		"reports/2024/scratch.tmp",
+		"reports/2024/sub/a.csv",
+		"top.txt",
+	}
+	if got := tarNames(t, gr); !reflect.DeepEqual(got, wantAll) {
+		t.Errorf("tar.gz: got files %q; want %q", got, wantAll)
+	}
+
+	for _, pth := range []string{"/zip/nope", "/zip/top.txt"} {
+		if code := get(pth).Code; code != 404 {
+			t.Errorf("GET %s: got code %d; want 404", pth, code)
+		}
+	}
+}
+
+func tarNames(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestServeArchiveBadGlob(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("ServeArchive with an invalid glob did not panic")
+		}
+	}()
+	NewBuilder().ServeArchive("/x", fstest.MapFS{}, ArchiveOptions{Include: []string{"a/**b"}})
+}
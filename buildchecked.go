@@ -0,0 +1,198 @@
+package hmux
+
+import (
+	"strings"
+)
+
+// A RouteError describes a problem with the rules of a Builder found by
+// BuildChecked.
+type RouteError struct {
+	// Route is the rule with the problem, or nil if the problem concerns
+	// the rules as a whole.
+	Route *Route
+	// Cause is the rule responsible for the problem, if any. For an
+	// unreachable rule, it is the rule which serves the requests that
+	// Route matches.
+	Cause *Route
+	// Reason describes the problem.
+	Reason string
+}
+
+func (e *RouteError) Error() string {
+	s := "hmux: "
+	if e.Route != nil {
+		s += describeRoute(*e.Route) + ": "
+	}
+	s += e.Reason
+	if e.Cause != nil {
+		s += " (shadowed by " + describeRoute(*e.Cause) + ")"
+	}
+	return s
+}
+
+// RouteErrors is the error returned by BuildChecked. It lists the problems
+// found in the order of the rules concerned (see Mux.Routes).
+type RouteErrors []*RouteError
+
+func (e RouteErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// BuildChecked is like Build but first checks the rule table as a whole,
+// returning a RouteErrors listing any problems instead of a Mux. Unlike the
+// checks made as each rule is registered, these concern combinations of
+// rules. BuildChecked reports:
+//
+//   - an empty rule table;
+//   - unreachable rules, which only match requests that the Mux always
+//     routes to another rule (without conditions) that takes precedence.
+//
+// Rules are usually shadowed in this way by rules registered with Priority,
+// by wildcard rules which take precedence over other wildcard rules (as
+// "/files/*" with a priority does over "/files/img/*"), or by rules whose
+// patterns become identical with CaseInsensitive or NormalizeSegments.
+//
+// The check is conservative: rules which are reachable only for requests
+// that parameter types such as int32 reject, for example, are not reported.
+func (b *Builder) BuildChecked() (*Mux, error) {
+	b.checkNotGroup("BuildChecked")
+	m := b.Build()
+	if errs := m.check(); len(errs) > 0 {
+		return nil, errs
+	}
+	return m, nil
+}
+
+func (m *Mux) check() RouteErrors {
+	if len(m.matchers) == 0 {
+		return RouteErrors{{Reason: "no rules registered"}}
+	}
+	var errs RouteErrors
+	for i, ma := range m.matchers {
+		ma.forEachRule(func(rl *rule) {
+			if cause := m.shadow(m.matchers[:i], rl); cause != nil {
+				rt, crt := rl.route(), cause.route()
+				errs = append(errs, &RouteError{Route: &rt, Cause: &crt, Reason: "unreachable"})
+			}
+		})
+	}
+	return errs
+}
+
+// shadow returns a rule of one of the matchers (which precede the matcher of
+// rl) that serves every request matched by rl, or nil if there is none.
+func (m *Mux) shadow(matchers []*matcher, rl *rule) *rule {
+	for _, ma := range matchers {
+		if !patternCovers(ma.pat, rl.p, m.foldCase) {
+			continue
+		}
+		var methodRules []*rule
+		if rl.method != "" {
+			methodRules = ma.rules(rl.method)
+		}
+		for _, rules := range [2][]*rule{methodRules, ma.allMethods} {
+			for _, rl1 := range rules {
+				if len(rl1.conds) == 0 && len(rl1.paramMaps) == 0 && rl1.produces == "" {
+					return rl1
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// patternCovers reports whether every path matched by p1 is matched by p.
+// It may return false negatives.
+func patternCovers(p, p1 pattern, fold bool) bool {
+	switch p.opt {
+	case patEmpty:
+		return true
+	case patStar:
+		return p1.opt == patStar
+	}
+	if p1.opt == patEmpty || p1.opt == patStar || p.glob != nil {
+		return false
+	}
+	n := len(p.segs)
+	switch p.opt {
+	case patOther:
+		if p1.opt != patOther || len(p1.segs) != n || p1.anySlash && !p.anySlash {
+			return false
+		}
+	case patTrailingSlash:
+		if p1.opt != patTrailingSlash || len(p1.segs) != n {
+			return false
+		}
+	case patWildcard:
+		switch {
+		case len(p1.segs) > n:
+		case len(p1.segs) == n && (p1.opt == patTrailingSlash || p1.opt == patWildcard):
+		default:
+			return false
+		}
+	}
+	for i, seg := range p.segs {
+		if !segmentCovers(seg, p1.segs[i], fold) {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentCovers reports whether every path segment matched by seg1 is matched
+// by seg. It may return false negatives.
+func segmentCovers(seg, seg1 segment, fold bool) bool {
+	if !seg.isParam {
+		if seg1.isParam {
+			return false
+		}
+		return seg.s == seg1.s || fold && strings.EqualFold(seg.s, seg1.s)
+	}
+	if seg.suffix != "" {
+		if !seg1.isParam || seg1.suffix != seg.suffix {
+			return false
+		}
+	}
+	if seg.ptyp == paramString {
+		return true
+	}
+	if !seg1.isParam {
+		if seg.ptyp == paramEnum {
+			for _, v := range seg.enum {
+				if v == seg1.s {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if seg1.ptyp != seg.ptyp || seg1.custom != seg.custom {
+		return false
+	}
+	switch seg.ptyp {
+	case paramEnum:
+		return isSubset(seg1.enum, seg.enum)
+	case paramStringLen:
+		return seg.minLen <= seg1.minLen && seg1.maxLen <= seg.maxLen
+	}
+	return true
+}
+
+// isSubset reports whether the sorted list a is a subset of the sorted list
+// b.
+func isSubset(a, b []string) bool {
+	i := 0
+	for _, s := range a {
+		for i < len(b) && b[i] < s {
+			i++
+		}
+		if i == len(b) || b[i] != s {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package hmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildChecked(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		setup func(b *Builder)
+		want  []string
+	}{
+		{
+			"ok",
+			func(b *Builder) {
+				b.Get("/x/y", testHandler(""))
+				b.Get("/x/:p", testHandler(""))
+				b.Get("/x/*", testHandler(""))
+				b.Get("/x/y", testHandler(""), Host("example.com"))
+				b.Any("", testHandler(""))
+			},
+			nil,
+		},
+		{
+			"empty",
+			func(b *Builder) {},
+			[]string{"hmux: no rules registered"},
+		},
+		{
+			"priority",
+			func(b *Builder) {
+				b.Get("/users/new", testHandler(""))
+				b.Post("/users/new", testHandler(""))
+				b.Get("/users/:id", testHandler(""), Priority(1))
+			},
+			[]string{"hmux: GET /users/new: unreachable (shadowed by GET /users/:id)"},
+		},
+		{
+			"all methods",
+			func(b *Builder) {
+				b.Get("/a/b", testHandler(""))
+				b.Any("/a/:p", testHandler(""), Priority(1))
+			},
+			[]string{"hmux: GET /a/b: unreachable (shadowed by * /a/:p)"},
+		},
+		{
+			"conditions",
+			func(b *Builder) {
+				b.Get("/a/b", testHandler(""))
+				b.Get("/a/:p", testHandler(""), Priority(1), Query("debug", "1"))
+			},
+			nil,
+		},
+		{
+			"wildcards",
+			func(b *Builder) {
+				b.Get("/files/img/*", testHandler(""))
+				b.Get("/files/img/", testHandler(""))
+				b.Get("/files/*", testHandler(""), Priority(1))
+				b.Get("/files", testHandler(""))
+			},
+			[]string{
+				"hmux: GET /files/img/: unreachable (shadowed by GET /files/*)",
+				"hmux: GET /files/img/*: unreachable (shadowed by GET /files/*)",
+			},
+		},
+		{
+			"case insensitive",
+			func(b *Builder) {
+				b.CaseInsensitive(true)
+				b.Get("/About", testHandler(""))
+				b.Get("/about", testHandler(""))
+			},
+			[]string{"hmux: GET /About: unreachable (shadowed by GET /about)"},
+		},
+		{
+			"param types",
+			func(b *Builder) {
+				b.Get("/e/:v:enum(a|b)", testHandler(""))
+				b.Get("/e/b", testHandler(""), Priority(-1))
+				b.Get("/s/:v:string(2,4)", testHandler(""))
+				b.Get("/s/:v:string(1,8)", testHandler(""), Priority(1))
+				b.Get("/n/:v:int32", testHandler(""))
+				b.Get("/n/:v:int64", testHandler(""), Priority(1))
+			},
+			[]string{
+				"hmux: GET /s/:v:string(2,4): unreachable (shadowed by GET /s/:v:string(1,8))",
+				"hmux: GET /e/b: unreachable (shadowed by GET /e/:v:enum(a|b))",
+			},
+		},
+	} {
+		b := NewBuilder()
+		tt.setup(b)
+		m, err := b.BuildChecked()
+		var got []string
+		if err != nil {
+			if m != nil {
+				t.Errorf("%s: got non-nil Mux with error", tt.name)
+			}
+			for _, e := range err.(RouteErrors) {
+				got = append(got, e.Error())
+			}
+		} else if m == nil {
+			t.Errorf("%s: got nil Mux without error", tt.name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got errors\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package hmux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A CachePolicy describes how HTTP caches may store the responses of a rule.
+// See Cache.
+type CachePolicy struct {
+	// Private indicates that responses are specific to the user, so
+	// shared caches (such as CDNs) must not store them. Otherwise,
+	// responses are public.
+	Private bool
+	// NoStore indicates that no cache may store responses. If it is set,
+	// the other fields must be zero.
+	NoStore bool
+	// MaxAge is how long a response remains fresh. It is truncated to a
+	// whole number of seconds.
+	MaxAge time.Duration
+	// StaleWhileRevalidate is how long after it becomes stale a response
+	// may still be used while a cache revalidates it in the background.
+	StaleWhileRevalidate time.Duration
+}
+
+// String returns the Cache-Control header value for c, such as
+// "public, max-age=300, stale-while-revalidate=60".
+func (c CachePolicy) String() string {
+	if c.NoStore {
+		return "no-store"
+	}
+	parts := []string{"public"}
+	if c.Private {
+		parts[0] = "private"
+	}
+	parts = append(parts, "max-age="+strconv.FormatInt(int64(c.MaxAge/time.Second), 10))
+	if c.StaleWhileRevalidate > 0 {
+		parts = append(parts, "stale-while-revalidate="+strconv.FormatInt(int64(c.StaleWhileRevalidate/time.Second), 10))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cacheControlKey is the metadata key holding a rule's Cache-Control value.
+const cacheControlKey = "cache-control"
+
+// Cache returns a RuleOption which declares the cacheability of a rule's
+// responses. It records c as the "cache-control" metadata of the rule (see
+// Meta), where tools which list routes can review it alongside the rest of
+// the route table:
+//
+//	b.Get("/catalog", serveCatalog, hmux.Cache(hmux.CachePolicy{
+//		MaxAge:               5 * time.Minute,
+//		StaleWhileRevalidate: time.Minute,
+//	}))
+//	b.Get("/account", serveAccount, hmux.Cache(hmux.CachePolicy{NoStore: true}))
+//
+// For each successful (2xx) response of a rule with "cache-control" metadata,
+// whether given by Cache or directly using Meta, the Mux sets the
+// Cache-Control header to the metadata value unless the handler (or a rule
+// middleware) has set the header itself.
+//
+// Cache panics if a duration is negative or if NoStore is combined with other
+// fields.
+func Cache(c CachePolicy) RuleOption {
+	if c.MaxAge < 0 || c.StaleWhileRevalidate < 0 {
+		panic("hmux: Cache called with negative duration")
+	}
+	if c.NoStore && c != (CachePolicy{NoStore: true}) {
+		panic("hmux: Cache called with NoStore and other fields")
+	}
+	return Meta(cacheControlKey, c.String())
+}
+
+// A cacheWriter is an http.ResponseWriter which sets the Cache-Control header
+// of successful responses if the handler has not set it.
+type cacheWriter struct {
+	http.ResponseWriter
+	cc      string
+	written bool
+}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	if !w.written && code >= 200 {
+		// Informational (1xx) responses precede the final response.
+		w.written = true
+		h := w.ResponseWriter.Header()
+		if _, ok := h["Cache-Control"]; !ok && code >= 200 && code < 300 {
+			h.Set("Cache-Control", w.cc)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) Flush() {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *cacheWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type cacheHandler struct {
+	h  http.Handler
+	cc string
+}
+
+func (h cacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cw := &cacheWriter{ResponseWriter: w, cc: h.cc}
+	h.h.ServeHTTP(cw, r)
+	if !cw.written {
+		// The handler wrote nothing, so the response is an empty 200.
+		if _, ok := w.Header()["Cache-Control"]; !ok {
+			w.Header().Set("Cache-Control", h.cc)
+		}
+	}
+}
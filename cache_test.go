@@ -0,0 +1,105 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCachePolicyString(t *testing.T) {
+	for _, tt := range []struct {
+		c    CachePolicy
+		want string
+	}{
+		{CachePolicy{}, "public, max-age=0"},
+		{CachePolicy{MaxAge: 90 * time.Second}, "public, max-age=90"},
+		{CachePolicy{Private: true, MaxAge: time.Minute, StaleWhileRevalidate: 1500 * time.Millisecond}, "private, max-age=60, stale-while-revalidate=1"},
+		{CachePolicy{NoStore: true}, "no-store"},
+	} {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("%+v: got %q; want %q", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	b := NewBuilder()
+	policy := CachePolicy{MaxAge: 5 * time.Minute, StaleWhileRevalidate: time.Minute}
+	b.Get("/catalog", testHandler("catalog"), Cache(policy))
+	b.Get("/override", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+	}, Cache(policy))
+	b.Get("/missing", http.NotFound, Cache(policy))
+	b.Get("/empty", func(w http.ResponseWriter, r *http.Request) {}, Meta("cache-control", "private, max-age=10"))
+	b.Get("/plain", testHandler("plain"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		{"/catalog", "public, max-age=300, stale-while-revalidate=60"},
+		{"/override", "no-cache"},
+		{"/missing", ""},
+		{"/empty", "private, max-age=10"},
+		{"/plain", ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("GET %s: got Cache-Control %q; want %q", tt.path, got, tt.want)
+		}
+	}
+
+	for _, rt := range mux.Routes() {
+		if rt.Pattern != "/catalog" {
+			continue
+		}
+		if got, want := rt.Meta["cache-control"], policy.String(); got != want {
+			t.Errorf("got cache-control metadata %q; want %q", got, want)
+		}
+	}
+}
+
+func TestCacheInvalid(t *testing.T) {
+	for _, c := range []CachePolicy{
+		{MaxAge: -time.Second},
+		{StaleWhileRevalidate: -time.Second},
+		{NoStore: true, MaxAge: time.Second},
+		{NoStore: true, Private: true},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Cache(%+v) did not panic", c)
+				}
+			}()
+			Cache(c)
+		}()
+	}
+}
+
+func TestCacheEarlyHints(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Write([]byte("page"))
+	}, Cache(CachePolicy{MaxAge: time.Minute}))
+	mux := b.Build()
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d; want 200", resp.StatusCode)
+	}
+	if got, want := resp.Header.Get("Cache-Control"), "public, max-age=60"; got != want {
+		t.Errorf("got Cache-Control %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package hmux
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CanonicalBase sets the scheme, host, and base path used by CanonicalURL for
+// requests served by Muxes built from b. The base must be an absolute URL such
+// as "https://www.example.com" or "https://example.com/app".
+func (b *Builder) CanonicalBase(base string) {
+	b.checkNotGroup("CanonicalBase")
+	u, err := url.Parse(base)
+	if err != nil {
+		panic("hmux: bad canonical base: " + err.Error())
+	}
+	if u.Scheme == "" || u.Host == "" {
+		panic("hmux: canonical base must be an absolute URL")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		panic("hmux: canonical base must not have a query or fragment")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	b.canonical = u
+}
+
+// CanonicalURL returns the canonical absolute URL for a request being served
+// by a Mux. The path is constructed from the pattern of the matched rule and
+// the matched parameters, so that equivalent request paths (for instance,
+// /items/007 and /items/7 for the pattern /items/:id:int64) produce the same
+// URL. The query string is not included.
+//
+// If the Mux was built from a Builder with a canonical base (see
+// Builder.CanonicalBase), the URL uses that scheme and host and the path is
+// prefixed by the base path. Otherwise, the URL uses the host of r and the
+// scheme implied by r.TLS.
+//
+// When Muxes are nested, the rule and canonical base of the innermost Mux are
+// used, so the base of an inner Mux should include the prefix at which it is
+// mounted.
+func CanonicalURL(r *http.Request) string {
+	p := requestParams(r)
+	var u url.URL
+	if p != nil && p.mux != nil && p.mux.canonical != nil {
+		u = *p.mux.canonical
+	} else {
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+		u.Host = r.Host
+	}
+	pth := r.URL.EscapedPath()
+	if p != nil && p.rule != nil {
+		switch p.rule.p.opt {
+		case patEmpty, patStar:
+		default:
+			pth = p.rule.p.canonicalPath(p)
+		}
+	}
+	u.RawPath = u.EscapedPath() + pth
+	u.Path = mustPathUnescape(u.RawPath)
+	return u.String()
+}
+
+// canonicalPath constructs the escaped path which would match p (which is not
+// "" or "*") using the parameters in ps.
+func (p pattern) canonicalPath(ps *Params) string {
+	// Param values are already escaped if the Mux matches raw paths.
+	escape := url.PathEscape
+	if ps.mux != nil && ps.mux.rawPath {
+		escape = func(s string) string { return s }
+	}
+	var sb strings.Builder
+	for _, seg := range p.segs {
+		sb.WriteByte('/')
+		if !seg.isParam {
+			sb.WriteString(url.PathEscape(seg.s))
+			continue
+		}
+		pp := ps.get(seg.s)
+		switch pp.typ {
+		case paramInt32, paramInt64:
+			sb.WriteString(strconv.FormatInt(pp.n, 10))
+		default:
+			sb.WriteString(escape(pp.val))
+		}
+		sb.WriteString(url.PathEscape(seg.suffix))
+	}
+	switch p.opt {
+	case patTrailingSlash:
+		sb.WriteByte('/')
+	case patWildcard:
+		for _, seg := range strings.Split(ps.wildcard, "/")[1:] {
+			sb.WriteByte('/')
+			sb.WriteString(escape(seg))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+package hmux
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	canonicalHandler := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, CanonicalURL(r))
+	}
+	b := NewBuilder()
+	b.CanonicalBase("https://www.example.com/app/")
+	b.Get("/", canonicalHandler)
+	b.Get("/about/", canonicalHandler)
+	b.Get("/items/:id:int64", canonicalHandler)
+	b.Get("/invoices/:id:int64.pdf", canonicalHandler)
+	b.Get("/users/:name/x", canonicalHandler)
+	b.Get("/static/*", canonicalHandler)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/", "https://www.example.com/app/"},
+		{"GET", "/about/?x=y", "https://www.example.com/app/about/"},
+		{"GET", "/items/007", "https://www.example.com/app/items/7"},
+		{"GET", "/invoices/007.pdf", "https://www.example.com/app/invoices/7.pdf"},
+		{"GET", "/users/a%2fb/x", "https://www.example.com/app/users/a%2Fb/x"},
+		{"GET", "/static/a%20b/c", "https://www.example.com/app/static/a%20b/c"},
+	})
+
+	b = NewBuilder()
+	b.Get("/a", canonicalHandler)
+	b.Get("/b/:n:int32", canonicalHandler)
+	b.Get("", canonicalHandler)
+	mux := b.Build()
+	testRequests(t, mux, []reqTest{
+		{"GET", "/a", "http://example.com/a"},
+		{"GET", "/b/+3", "http://example.com/b/3"},
+		{"GET", "/c/d", "http://example.com/c/d"},
+	})
+
+	r := httptest.NewRequest("GET", "https://secure.example.com/a", nil)
+	r.TLS = new(tls.ConnectionState)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if got, want := w.Body.String(), "https://secure.example.com/a"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestCanonicalBaseErrors(t *testing.T) {
+	for _, base := range []string{
+		"/app",
+		"example.com",
+		"https://example.com/?x=y",
+		"https://example.com/#foo",
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("CanonicalBase(%q): got no panic", base)
+				}
+			}()
+			NewBuilder().CanonicalBase(base)
+		}()
+	}
+}
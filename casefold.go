@@ -0,0 +1,82 @@
+package hmux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedirectCase sets whether Muxes built from b redirect requests whose paths
+// match no rule pattern, but would match if literal pattern segments were
+// compared regardless of ASCII case, to the path with those segments written
+// as registered. For example, if the pattern "/about/:page" is registered, a
+// request for /About/Team receives a 308 redirect to /about/Team. Parameter
+// values and wildcard remainders are unchanged, and the redirect preserves
+// the query.
+//
+// This helps sites migrating from case-insensitive servers keep a single
+// canonical URL for each page. Compare CaseInsensitive, which serves such
+// requests without redirecting.
+//
+// When disabled (the default), such requests result in a 404 response.
+func (b *Builder) RedirectCase(enable bool) {
+	b.checkNotGroup("RedirectCase")
+	b.redirectCase = enable
+}
+
+// caseRedirect returns the URL to which a request for pth, which doesn't
+// match any rule pattern, should be redirected if pth matches a pattern
+// ignoring case.
+func (m *Mux) caseRedirect(r *http.Request, pth string, opts matchOpts) (string, bool) {
+	if opts&optFoldCase != 0 || pth == "*" {
+		return "", false
+	}
+	parts, foldOpts := m.splitPath(pth, opts|optFoldCase)
+	prio, lits, rest := m.candidates(parts, foldOpts)
+	for _, matchers := range [3][]*matcher{prio, lits, rest} {
+		for _, ma := range matchers {
+			if !ma.match(r, parts, foldOpts).matchesPath() {
+				continue
+			}
+			pth1 := ma.pat.recase(pth, opts&(optReencode|optRaw) != 0)
+			if pth1 == pth || !m.handler(r, pth1, opts).matchesPath() {
+				return "", false
+			}
+			u := *r.URL
+			if opts&(optReencode|optRaw) != 0 {
+				u.RawPath = pth1
+				u.Path = mustPathUnescape(pth1)
+			} else {
+				u.Path = pth1
+				u.RawPath = ""
+			}
+			return u.String(), true
+		}
+	}
+	return "", false
+}
+
+// recase returns pth, which matches p ignoring case, with the text matched by
+// p's literal segments and parameter suffixes replaced by that of p. If
+// escaped is set, pth is escaped.
+func (p pattern) recase(pth string, escaped bool) string {
+	esc := func(s string) string { return s }
+	if escaped {
+		esc = url.PathEscape
+	}
+	parts := strings.Split(strings.TrimPrefix(pth, "/"), "/")
+	for i, seg := range p.segs {
+		if i == len(parts) {
+			break
+		}
+		if !seg.isParam {
+			parts[i] = esc(seg.s)
+		} else if seg.suffix != "" {
+			suffix := esc(seg.suffix)
+			if n := len(parts[i]) - len(suffix); n > 0 {
+				parts[i] = parts[i][:n] + suffix
+			}
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
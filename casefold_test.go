@@ -0,0 +1,33 @@
+package hmux
+
+import "testing"
+
+func TestRedirectCase(t *testing.T) {
+	b := NewBuilder()
+	b.RedirectCase(true)
+	b.Get("/about", testHandler("about"))
+	b.Get("/about/:page", testHandler("page %s", "page"))
+	b.Get("/docs/:name.html", testHandler("doc %s", "name"))
+	b.Get("/static/*", testHandler("static %s", "*"))
+	b.Get("/a%20b/", testHandler("a b"))
+	b.Get("/Mixed", testHandler("mixed"))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/about", "about"},
+		{"GET", "/About", "308 /about"},
+		{"GET", "/ABOUT?x=Y", "308 /about?x=Y"},
+		{"GET", "/About/Team", "308 /about/Team"},
+		{"GET", "/about/Team", "page Team"},
+		{"GET", "/Docs/Intro.HTML", "308 /docs/Intro.html"},
+		{"GET", "/STATIC/A/B", "308 /static/A/B"},
+		{"GET", "/A%20B/", "308 /a%20b/"},
+		{"GET", "/mixed", "308 /Mixed"},
+		{"GET", "/Other", "404"},
+	})
+
+	b.RedirectCase(false)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/About", "404"},
+	})
+}
@@ -0,0 +1,117 @@
+package hmux
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// A ClientCertPolicy describes the TLS client certificate which a rule
+// requires. See ClientCert.
+//
+// Each non-empty field lists acceptable values, at least one of which the
+// certificate must have; a certificate must satisfy every non-empty field.
+// Values are compared exactly.
+type ClientCertPolicy struct {
+	// DNSNames lists DNS names from the subject alternative name extension.
+	DNSNames []string
+	// EmailAddresses lists email addresses from the subject alternative
+	// name extension.
+	EmailAddresses []string
+	// URIs lists URIs from the subject alternative name extension, such as
+	// SPIFFE IDs ("spiffe://example.com/ns/prod/sa/deployer").
+	URIs []string
+	// OrganizationalUnits lists organizational units from the subject.
+	OrganizationalUnits []string
+}
+
+// ClientCert returns a RuleOption which restricts a rule to requests
+// received over TLS with a verified client certificate satisfying policy
+// (the zero ClientCertPolicy accepts any verified certificate). This allows
+// mutual TLS to protect particular endpoints, or groups of endpoints, of a
+// server without a separate listener:
+//
+//	b.Group("/admin", func(g *hmux.Builder) {
+//		g.Get("/status", serveStatus)
+//		g.Post("/reload", reload)
+//	}, hmux.ClientCert(hmux.ClientCertPolicy{
+//		OrganizationalUnits: []string{"ops"},
+//	}))
+//
+// A certificate is verified if it appears as the leaf of one of the request's
+// verified chains (r.TLS.VerifiedChains), so the server's tls.Config must
+// set ClientAuth to tls.VerifyClientCertIfGiven (to make certificates
+// optional for other rules) or tls.RequireAndVerifyClientCert, along with
+// ClientCAs.
+//
+// If a request matching the rule has no verified certificate or the
+// certificate does not satisfy policy, and no other rule for the same
+// pattern and method matches, the Mux responds with a 403 ("Forbidden").
+func ClientCert(policy ClientCertPolicy) RuleOption {
+	c := clientCertCond{
+		dnsNames: append([]string(nil), policy.DNSNames...),
+		emails:   append([]string(nil), policy.EmailAddresses...),
+		uris:     append([]string(nil), policy.URIs...),
+		ous:      append([]string(nil), policy.OrganizationalUnits...),
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, c)
+	}
+}
+
+type clientCertCond struct {
+	dnsNames []string
+	emails   []string
+	uris     []string
+	ous      []string
+}
+
+func (c clientCertCond) check(r *http.Request, _ *rule, _ *Params) int {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return http.StatusForbidden
+	}
+	if !c.allows(r.TLS.VerifiedChains[0][0]) {
+		return http.StatusForbidden
+	}
+	return condOK
+}
+
+// allows reports whether cert satisfies c.
+func (c clientCertCond) allows(cert *x509.Certificate) bool {
+	uris := make([]string, len(cert.URIs))
+	for i, u := range cert.URIs {
+		uris[i] = u.String()
+	}
+	return anyIn(c.dnsNames, cert.DNSNames) &&
+		anyIn(c.emails, cert.EmailAddresses) &&
+		anyIn(c.uris, uris) &&
+		anyIn(c.ous, cert.Subject.OrganizationalUnit)
+}
+
+// anyIn reports whether want is empty or one of its elements is in have.
+func anyIn(want, have []string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	for _, w := range want {
+		for _, h := range have {
+			if w == h {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c clientCertCond) key() string {
+	parts := []string{"client-cert"}
+	for _, vals := range [][]string{c.dnsNames, c.emails, c.uris, c.ous} {
+		escaped := make([]string, len(vals))
+		for i, v := range vals {
+			escaped[i] = url.QueryEscape(v)
+		}
+		parts = append(parts, strings.Join(escaped, ","))
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,89 @@
+package hmux
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClientCert(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/admin", testHandler("admin"), ClientCert(ClientCertPolicy{}))
+	b.Get("/deploy", testHandler("deploy"), ClientCert(ClientCertPolicy{
+		URIs:                []string{"spiffe://example.com/deployer"},
+		OrganizationalUnits: []string{"ops", "sre"},
+	}))
+	b.Get("/status", testHandler("full status"), ClientCert(ClientCertPolicy{DNSNames: []string{"monitor.example.com"}}))
+	b.Get("/status", testHandler("status"))
+	b.Group("/internal", func(g *Builder) {
+		g.Get("/metrics", testHandler("metrics"))
+	}, ClientCert(ClientCertPolicy{EmailAddresses: []string{"ops@example.com"}}))
+	mux := b.Build()
+
+	deployer, _ := url.Parse("spiffe://example.com/deployer")
+	certs := map[string]*x509.Certificate{
+		"any": {},
+		"ops": {
+			Subject:        pkix.Name{OrganizationalUnit: []string{"sre"}},
+			URIs:           []*url.URL{deployer},
+			EmailAddresses: []string{"ops@example.com"},
+		},
+		"dev": {
+			Subject: pkix.Name{OrganizationalUnit: []string{"dev"}},
+			URIs:    []*url.URL{deployer},
+		},
+		"monitor": {DNSNames: []string{"monitor.example.com"}},
+	}
+	for _, tt := range []struct {
+		path     string
+		cert     string // "" for none; "unverified" for an unverified one
+		wantCode int
+		want     string
+	}{
+		{"/admin", "any", 200, "admin"},
+		{"/admin", "", 403, "Forbidden\n"},
+		{"/admin", "unverified", 403, "Forbidden\n"},
+		{"/deploy", "ops", 200, "deploy"},
+		{"/deploy", "dev", 403, "Forbidden\n"},
+		{"/deploy", "monitor", 403, "Forbidden\n"},
+		{"/status", "monitor", 200, "full status"},
+		{"/status", "ops", 200, "status"},
+		{"/status", "", 200, "status"},
+		{"/internal/metrics", "ops", 200, "metrics"},
+		{"/internal/metrics", "monitor", 403, "Forbidden\n"},
+	} {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		switch tt.cert {
+		case "":
+		case "unverified":
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certs["ops"]}}
+		default:
+			cert := certs[tt.cert]
+			r.TLS = &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{cert},
+				VerifiedChains:   [][]*x509.Certificate{{cert}},
+			}
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tt.wantCode || w.Body.String() != tt.want {
+			t.Errorf("GET %s with cert %q: got %d %q; want %d %q",
+				tt.path, tt.cert, w.Code, w.Body.String(), tt.wantCode, tt.want)
+		}
+	}
+}
+
+func TestClientCertConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler("a"), ClientCert(ClientCertPolicy{DNSNames: []string{"a"}}))
+	b.Get("/x", testHandler("b"), ClientCert(ClientCertPolicy{OrganizationalUnits: []string{"a"}}))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule with the same client certificate policy did not panic")
+		}
+	}()
+	b.Get("/x", testHandler("c"), ClientCert(ClientCertPolicy{DNSNames: []string{"a"}}))
+}
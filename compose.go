@@ -0,0 +1,87 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Compose returns a handler which routes each request to the first of muxes
+// that would serve it. This allows separately built Muxes (for example, those
+// of independent modules of an application) to share a server without sharing
+// a Builder.
+//
+// The handler asks each Mux in turn whether it would handle the request,
+// without serving it: a Mux handles a request if a rule matches its path and
+// method, or if the Mux would respond in some other way than with a 404 or 405
+// (for instance, by redirecting to a canonical path or by rejecting a request
+// whose conditions fail with a 406). The first Mux that would handle the
+// request serves it.
+//
+// If no Mux would handle the request but some Muxes have rules matching its
+// path for other methods, the handler responds with a 405 ("Method Not
+// Allowed") whose Allow header lists the methods of all those Muxes.
+// Otherwise, it responds with a 404.
+//
+// The functions registered with Builder.Normalize are called by each Mux
+// consulted, so they should not have side effects.
+func Compose(muxes ...*Mux) http.Handler {
+	for _, m := range muxes {
+		if m == nil {
+			panic("hmux: Compose called with nil Mux")
+		}
+	}
+	return composed(append([]*Mux(nil), muxes...))
+}
+
+type composed []*Mux
+
+func (c composed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var allow []string
+	seen := make(map[string]bool)
+	for _, m := range c {
+		r1, mr, ok := m.lookup(discardWriter{}, r)
+		if !ok {
+			// The Mux rejects or redirects r.
+			m.ServeHTTP(w, r)
+			return
+		}
+		if mr.handles() {
+			m.serve(w, r1, mr)
+			return
+		}
+		if mr.allow == "" {
+			continue
+		}
+		for _, method := range strings.Split(mr.allow, ", ") {
+			if !seen[method] {
+				seen[method] = true
+				allow = append(allow, method)
+			}
+		}
+	}
+	if len(allow) > 0 {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// handles reports whether a Mux would respond to a request with the result
+// mr in some way other than with a 404 or 405.
+func (mr matchResult) handles() bool {
+	if mr.rule != nil {
+		return true
+	}
+	if _, ok := mr.cond.(condResponder); ok {
+		return true
+	}
+	return mr.status != 0 && mr.status != http.StatusNotFound
+}
+
+// discardWriter is an http.ResponseWriter which discards the response.
+type discardWriter struct{}
+
+func (discardWriter) Header() http.Header         { return make(http.Header) }
+func (discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardWriter) WriteHeader(int)             {}
@@ -0,0 +1,53 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	b1 := NewBuilder()
+	b1.Get("/users/:id", testHandler("users get %s", "id"))
+	b1.Get("/shared", testHandler("shared 1"))
+	b1.Get("/docs/", testHandler("docs"))
+	b1.Get("/html", testHandler("html"), Produces("text/html"))
+	b2 := NewBuilder()
+	b2.Post("/users/:id", testHandler("users post %s", "id"))
+	b2.Put("/users/:id", testHandler("users put %s", "id"))
+	b2.Get("/shared", testHandler("shared 2"))
+	b2.Get("/billing/*", testHandler("billing %s", "*"))
+	b2.Get("/html", testHandler("any"))
+	h := Compose(b1.Build(), b2.Build())
+
+	testCases := []reqTest{
+		{"GET", "/users/3", "users get 3"},
+		{"POST", "/users/3", "users post 3"},
+		{"DELETE", "/users/3", "405 GET, POST, PUT"},
+		{"GET", "/shared", "shared 1"},
+		{"GET", "/billing/a/b", "billing /a/b"},
+		{"POST", "/billing/a", "405 GET"},
+		{"GET", "//docs/", "308 /docs/"},
+		{"GET", "/nope", "404"},
+	}
+	testRequests(t, h, testCases)
+
+	// The first Mux rejects the Accept header with a 406 rather than
+	// deferring to the second.
+	r, _ := http.NewRequest("GET", "/html", nil)
+	r.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("GET /html (Accept: application/json): got status %d; want 406", w.Code)
+	}
+}
+
+func TestComposeNil(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Compose(nil) did not panic")
+		}
+	}()
+	Compose(NewBuilder().Build(), nil)
+}
@@ -0,0 +1,64 @@
+package hmux
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// isAuthorityPattern reports whether pat, given to Builder.Connect, is an
+// authority pattern rather than a path pattern.
+func isAuthorityPattern(pat string) bool {
+	return pat != "" && pat != "*" && !strings.HasPrefix(pat, "/")
+}
+
+// authorityPattern returns a RuleOption restricting a rule to authority-form
+// requests whose targets match pat (see Builder.Connect).
+func authorityPattern(pat string) RuleOption {
+	i := strings.LastIndexByte(pat, ':')
+	if i < 0 {
+		panic(fmt.Sprintf("hmux: authority pattern %q has no port", pat))
+	}
+	host, port := pat[:i], pat[i+1:]
+	if port != "*" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n <= 0 || n > 65535 || port[0] == '0' {
+			panic(fmt.Sprintf("hmux: invalid port in authority pattern %q", pat))
+		}
+	}
+	var hostOpt RuleOption
+	if host != "*" {
+		if _, err := parseHostPattern(host); err != nil {
+			panic(fmt.Sprintf("hmux: invalid host in authority pattern %q", pat))
+		}
+		hostOpt = Host(host)
+	}
+	return func(rl *rule) {
+		if hostOpt != nil {
+			hostOpt(rl)
+		}
+		rl.conds = append(rl.conds, authorityCond(port))
+	}
+}
+
+// An authorityCond matches authority-form requests whose targets have the
+// given port (or any port, if it is *). The host is matched by a hostCond,
+// since the Host of such a request is its target.
+type authorityCond string
+
+func (c authorityCond) check(r *http.Request, _ *rule, _ *Params) int {
+	if r.Method != http.MethodConnect || r.URL.Path != "" {
+		return condSkip
+	}
+	if c == "*" {
+		return condOK
+	}
+	if _, port, err := net.SplitHostPort(r.Host); err != nil || port != string(c) {
+		return condSkip
+	}
+	return condOK
+}
+
+func (c authorityCond) key() string { return "authority:" + string(c) }
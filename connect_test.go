@@ -0,0 +1,64 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnect(t *testing.T) {
+	b := NewBuilder()
+	b.Connect("*.internal:5432", testHandler("db"))
+	b.Connect(":region.cache.example.com:*", testHandler("cache %s", "region"))
+	b.Connect("*:443", testHandler("tls"))
+	b.Connect("", testHandler("fallback"))
+	b.Connect("/chat", testHandler("extended"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		target string
+		want   string
+	}{
+		{"pg.internal:5432", "db"},
+		{"pg.internal:5433", "fallback"},
+		{"eu.cache.example.com:6379", "cache eu"},
+		{"example.com:443", "tls"},
+		{"example.com:80", "fallback"},
+		{"/chat", "extended"},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodConnect, tt.target, nil)
+		mux.ServeHTTP(w, r)
+		if got := w.Body.String(); w.Code != 200 || got != tt.want {
+			t.Errorf("CONNECT %s: got %d %q; want %q", tt.target, w.Code, got, tt.want)
+		}
+	}
+
+	// Authority patterns don't match requests with paths.
+	b = NewBuilder()
+	b.Connect("*:*", testHandler("tunnel"))
+	testRequests(t, b.Build(), []reqTest{
+		{"CONNECT", "/x", "404"},
+	})
+}
+
+func TestConnectInvalid(t *testing.T) {
+	for _, pat := range []string{
+		"example.com",
+		"example.com:",
+		"example.com:0",
+		"example.com:080",
+		"example.com:70000",
+		"exa/mple.com:443",
+		":443",
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Connect(%q) did not panic", pat)
+				}
+			}()
+			NewBuilder().Connect(pat, testHandler(""))
+		}()
+	}
+}
@@ -0,0 +1,65 @@
+package hmux
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ContentType returns a RuleOption which restricts a rule to requests whose
+// Content-Type header has one of the given media types. Parameters of the
+// header (such as charset) are ignored. A media type may end with /* to match
+// all of its subtypes, as in "image/*".
+//
+//	b.Post("/upload", handleMultipartUpload, hmux.ContentType("multipart/form-data"))
+//	b.Post("/upload", handleJSONUpload, hmux.ContentType("application/json"))
+//
+// If the request's content type does not match, the Mux responds with a 415
+// ("Unsupported Media Type") unless another rule for the same pattern and
+// method matches. (A rule without a ContentType option therefore serves as a
+// fallback for other content types.)
+//
+// ContentType panics if no media types are given or if any is malformed.
+func ContentType(mediaTypes ...string) RuleOption {
+	if len(mediaTypes) == 0 {
+		panic("hmux: ContentType called with no media types")
+	}
+	c := make(contentTypeCond, len(mediaTypes))
+	for i, mt := range mediaTypes {
+		mt = strings.ToLower(mt)
+		slash := strings.IndexByte(mt, '/')
+		if slash <= 0 || slash == len(mt)-1 || strings.ContainsAny(mt, " ;") ||
+			strings.Contains(mt[:slash], "*") {
+			panic("hmux: invalid media type " + mediaTypes[i])
+		}
+		c[i] = mt
+	}
+	sort.Strings(c)
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, c)
+	}
+}
+
+type contentTypeCond []string // lowercase; sorted
+
+func (c contentTypeCond) check(r *http.Request, _ *rule, _ *Params) int {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return http.StatusUnsupportedMediaType
+	}
+	for _, want := range c {
+		if prefix, ok := trimSuffix(want, "*"); ok {
+			if strings.HasPrefix(mt, prefix) {
+				return condOK
+			}
+		} else if mt == want {
+			return condOK
+		}
+	}
+	return http.StatusUnsupportedMediaType
+}
+
+func (c contentTypeCond) key() string {
+	return "content-type:" + strings.Join(c, ",")
+}
@@ -0,0 +1,67 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContentType(t *testing.T) {
+	b := NewBuilder()
+	b.Post("/upload", testHandler("multipart"), ContentType("multipart/form-data"))
+	b.Post("/upload", testHandler("json"), ContentType("application/json", "text/json"))
+	b.Put("/upload", testHandler("image"), ContentType("image/*"))
+	b.Put("/upload", testHandler("other"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		method      string
+		contentType string
+		wantCode    int
+		want        string
+	}{
+		{"POST", "multipart/form-data; boundary=xyz", 200, "multipart"},
+		{"POST", "application/json", 200, "json"},
+		{"POST", "Application/JSON; charset=utf-8", 200, "json"},
+		{"POST", "text/json", 200, "json"},
+		{"POST", "text/plain", 415, ""},
+		{"POST", "", 415, ""},
+		{"POST", "bogus", 415, ""},
+		{"PUT", "image/png", 200, "image"},
+		{"PUT", "text/plain", 200, "other"},
+	} {
+		r := httptest.NewRequest(tt.method, "/upload", nil)
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tt.wantCode {
+			t.Errorf("%s with Content-Type %q: got code %d; want %d", tt.method, tt.contentType, w.Code, tt.wantCode)
+			continue
+		}
+		if tt.wantCode == 200 {
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("%s with Content-Type %q: got %q; want %q", tt.method, tt.contentType, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestContentTypeInvalid(t *testing.T) {
+	for _, mts := range [][]string{
+		nil,
+		{"json"},
+		{"*/*"},
+		{"text/"},
+		{"text/plain; charset=utf-8"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ContentType(%q) did not panic", mts)
+				}
+			}()
+			ContentType(mts...)
+		}()
+	}
+}
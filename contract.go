@@ -0,0 +1,128 @@
+package hmux
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+type responseSpec struct {
+	status      int
+	contentType string // media type without parameters; "" matches any
+}
+
+// Responds returns a RuleOption which declares that the rule's handler may
+// respond with the given status code and content type. The content type is
+// compared by media type, ignoring parameters such as charset; an empty
+// content type allows any content type for the status.
+//
+// Declared responses are only checked by Muxes built from a Builder on which
+// CheckResponses has been called.
+func Responds(status int, contentType string) RuleOption {
+	if contentType != "" {
+		mt, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			panic(fmt.Sprintf("hmux: bad content type %q: %s", contentType, err))
+		}
+		contentType = mt
+	}
+	return func(rl *rule) {
+		rl.responses = append(rl.responses, responseSpec{status, contentType})
+	}
+}
+
+// CheckResponses enables checking of responses against the responses declared
+// using Responds. This is intended for use during development and testing to
+// catch drift between the documented and actual behavior of handlers.
+//
+// When a handler for a rule with declared responses writes a response whose
+// status and content type do not match any declaration, the Mux calls report
+// with a descriptive error. The report function may log the error, or it may
+// panic to fail loudly. Rules without declared responses are not checked.
+func (b *Builder) CheckResponses(report func(r *http.Request, rt Route, err error)) {
+	b.checkNotGroup("CheckResponses")
+	if report == nil {
+		panic("hmux: CheckResponses called with nil function")
+	}
+	b.checkResponses = report
+}
+
+type contractHandler struct {
+	h      http.Handler
+	rule   *rule
+	report func(*http.Request, Route, error)
+}
+
+func (h contractHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cw := &contractWriter{ResponseWriter: w}
+	h.h.ServeHTTP(cw, r)
+	if !cw.wroteHeader {
+		// The server will send a 200 response.
+		cw.status = http.StatusOK
+		cw.contentType = w.Header().Get("Content-Type")
+	}
+	if err := h.check(cw.status, cw.contentType); err != nil {
+		h.report(r, h.rule.route(), err)
+	}
+}
+
+func (h contractHandler) check(status int, contentType string) error {
+	mt := ""
+	if contentType != "" {
+		var err error
+		mt, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			mt = contentType
+		}
+	}
+	for _, spec := range h.rule.responses {
+		if spec.status != status {
+			continue
+		}
+		if spec.contentType == "" || spec.contentType == mt {
+			return nil
+		}
+	}
+	return fmt.Errorf("hmux: response with status %d and content type %q does not match any declared response", status, contentType)
+}
+
+// A contractWriter records the status and content type of a response.
+type contractWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+	contentType string
+}
+
+func (w *contractWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= 200 {
+		w.wroteHeader = true
+		w.status = code
+		w.contentType = w.Header().Get("Content-Type")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *contractWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = http.StatusOK
+		// Mirror the content sniffing done by net/http.
+		w.contentType = w.Header().Get("Content-Type")
+		if _, ok := w.Header()["Content-Type"]; !ok && len(b) > 0 {
+			w.contentType = http.DetectContentType(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *contractWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *contractWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
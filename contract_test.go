@@ -0,0 +1,45 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCheckResponses(t *testing.T) {
+	var reports []string
+	b := NewBuilder()
+	b.CheckResponses(func(r *http.Request, rt Route, err error) {
+		reports = append(reports, r.URL.Path+" "+rt.Pattern+": "+err.Error())
+	})
+	jsonHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if r.URL.Query().Get("missing") != "" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintln(w, "{}")
+	}
+	b.Get("/json", jsonHandler,
+		Responds(200, "application/json"),
+		Responds(404, ""),
+	)
+	b.Get("/html", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "<html><body>hi</body></html>")
+	}, Responds(200, "application/json"))
+	b.Get("/empty", func(w http.ResponseWriter, r *http.Request) {}, Responds(204, ""))
+	b.Get("/unchecked", jsonHandler)
+	mux := b.Build()
+
+	for _, path := range []string{"/json", "/json?missing=1", "/html", "/empty", "/unchecked"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	want := []string{
+		`/html /html: hmux: response with status 200 and content type "text/html; charset=utf-8" does not match any declared response`,
+		`/empty /empty: hmux: response with status 200 and content type "" does not match any declared response`,
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("got reports\n%q\nwant\n%q", reports, want)
+	}
+}
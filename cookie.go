@@ -0,0 +1,47 @@
+package hmux
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Cookie returns a RuleOption which restricts a rule to requests carrying the
+// cookie name with the given value (among any other cookies of the same
+// name). If value is empty, the cookie need only be present, with any value.
+// For example, to serve the application to signed-in users and a landing
+// page to everyone else:
+//
+//	b.Get("/", serveApp, hmux.Cookie("session", ""))
+//	b.Get("/", serveLanding)
+//
+// The cookie name and value are compared exactly. Cookie only routes on the
+// presence of a cookie; the handler must still validate a session cookie.
+//
+// The cookie restriction is a condition (see Conditions): if the request's
+// cookies do not match, the Mux continues looking for a matching rule.
+func Cookie(name, value string) RuleOption {
+	if name == "" {
+		panic("hmux: Cookie called with empty name")
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, cookieCond{name, value})
+	}
+}
+
+type cookieCond struct {
+	name  string
+	value string
+}
+
+func (c cookieCond) check(r *http.Request, _ *rule, _ *Params) int {
+	for _, ck := range r.Cookies() {
+		if ck.Name == c.name && (c.value == "" || ck.Value == c.value) {
+			return condOK
+		}
+	}
+	return condSkip
+}
+
+func (c cookieCond) key() string {
+	return "cookie:" + url.QueryEscape(c.name) + "=" + url.QueryEscape(c.value)
+}
@@ -0,0 +1,51 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCookie(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/", testHandler("app"), Cookie("session", ""))
+	b.Get("/", testHandler("landing"))
+	b.Get("/", testHandler("beta app"), Cookie("session", ""), Cookie("beta", "1"))
+	b.Get("/admin", testHandler("admin"), Cookie("role", "admin"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		path   string
+		cookie string
+		want   string
+	}{
+		{"/", "", "landing"},
+		{"/", "session=abc", "app"},
+		{"/", "Session=abc", "landing"},
+		{"/", "theme=dark; session=abc", "app"},
+		{"/", "session=abc; beta=1", "beta app"},
+		{"/", "session=abc; beta=0", "app"},
+		{"/admin", "role=user; role=admin", "admin"},
+		{"/admin", "role=user", "404 page not found\n"},
+	} {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		if tt.cookie != "" {
+			r.Header.Set("Cookie", tt.cookie)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("GET %s with cookie %q: got %q; want %q", tt.path, tt.cookie, got, tt.want)
+		}
+	}
+}
+
+func TestCookieConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler("a"), Cookie("session", ""))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule with the same cookie restriction did not panic")
+		}
+	}()
+	b.Get("/x", testHandler("b"), Cookie("session", ""))
+}
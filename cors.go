@@ -0,0 +1,117 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CheckCORS enables checking of CORS preflight responses against the rules
+// of the Mux. This is intended for use during development and testing to
+// catch CORS configurations which advertise methods that the Mux does not
+// actually serve, so that browsers would send requests which fail after a
+// successful preflight.
+//
+// When a handler (or rule middleware) responds to a preflight request (an
+// OPTIONS request with Origin and Access-Control-Request-Method headers), the
+// Mux compares the methods listed in the Access-Control-Allow-Methods header
+// of the response with the methods of the rules whose patterns match the
+// request path. For each advertised method that no rule would serve, the Mux
+// calls report with a descriptive error. (If the header is *, the requested
+// method is checked instead.) The report function may log the error, or it
+// may panic to fail loudly.
+//
+// Only responses written by handlers that the Mux calls are checked, so CORS
+// handling must be done by a rule (such as one for OPTIONS requests with the
+// pattern "") or by rule middleware rather than by wrapping the Mux.
+func (b *Builder) CheckCORS(report func(r *http.Request, err error)) {
+	b.checkNotGroup("CheckCORS")
+	if report == nil {
+		panic("hmux: CheckCORS called with nil function")
+	}
+	b.checkCORS = report
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+type corsCheckHandler struct {
+	h http.Handler
+	m *Mux
+}
+
+func (h corsCheckHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.h.ServeHTTP(w, r)
+	allowed := w.Header().Values("Access-Control-Allow-Methods")
+	if len(allowed) == 0 {
+		return
+	}
+	var methods []string
+	for _, v := range allowed {
+		for _, method := range strings.Split(v, ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				methods = append(methods, method)
+			}
+		}
+	}
+	if len(methods) == 1 && methods[0] == "*" {
+		methods = []string{r.Header.Get("Access-Control-Request-Method")}
+	}
+	served, all := h.m.pathMethods(r)
+	if all {
+		return
+	}
+	var missing []string
+	for _, method := range methods {
+		if method == http.MethodOptions {
+			continue
+		}
+		i := sort.SearchStrings(served, method)
+		if i == len(served) || served[i] != method {
+			missing = append(missing, method)
+		}
+	}
+	if len(missing) > 0 {
+		h.m.checkCORS(r, fmt.Errorf("hmux: CORS preflight for %s allows methods not served by any rule: %s",
+			r.URL.Path, strings.Join(missing, ", ")))
+	}
+}
+
+// pathMethods returns the sorted methods of the rules of m whose patterns
+// match the path of r. If a rule for all methods matches, all is true.
+func (m *Mux) pathMethods(r *http.Request) (methods []string, all bool) {
+	pth, opts, _ := m.matchPath(r)
+	if m.serveMux {
+		opts |= optSubtree
+	}
+	parts, opts := m.splitPath(pth, opts)
+	// Match with a method which no rule has, so that each matcher reports
+	// the methods it has (or matches a rule for all methods).
+	r1 := r.Clone(r.Context())
+	r1.Method = "-"
+	seen := make(map[string]bool)
+	prio, lits, rest := m.candidates(parts, opts)
+	for _, matchers := range [3][]*matcher{prio, lits, rest} {
+		for _, ma := range matchers {
+			mr := ma.match(r1, parts, opts)
+			if mr.rule != nil {
+				return nil, true
+			}
+			if !mr.matchesPath() {
+				continue
+			}
+			for _, method := range ma.methodNames {
+				if !seen[method] {
+					seen[method] = true
+					methods = append(methods, method)
+				}
+			}
+		}
+	}
+	sort.Strings(methods)
+	return methods, false
+}
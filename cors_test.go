@@ -0,0 +1,67 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCheckCORS(t *testing.T) {
+	var errs []string
+	b := NewBuilder()
+	b.CheckCORS(func(r *http.Request, err error) {
+		errs = append(errs, err.Error())
+	})
+	b.Get("/items", testHandler("list"))
+	b.Post("/items", testHandler("create"))
+	b.Get("/items/:id", testHandler("get"))
+	b.Delete("/items/:id:int64", testHandler("delete"))
+	b.Handle("", "/any", testHandler("any"))
+	b.Handle(http.MethodOptions, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods := r.URL.Query().Get("allow")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		target string
+		method string // Access-Control-Request-Method
+		want   []string
+	}{
+		{"/items?allow=GET,POST", "POST", nil},
+		{"/items?allow=GET,%20POST,%20PUT", "PUT", []string{
+			"hmux: CORS preflight for /items allows methods not served by any rule: PUT",
+		}},
+		{"/items/3?allow=GET,DELETE,OPTIONS", "DELETE", nil},
+		{"/items/x?allow=GET,DELETE", "DELETE", []string{
+			"hmux: CORS preflight for /items/x allows methods not served by any rule: DELETE",
+		}},
+		{"/items?allow=*", "PATCH", []string{
+			"hmux: CORS preflight for /items allows methods not served by any rule: PATCH",
+		}},
+		{"/any?allow=PUT,PATCH", "PUT", nil},
+		{"/nothing?allow=GET", "GET", []string{
+			"hmux: CORS preflight for /nothing allows methods not served by any rule: GET",
+		}},
+		{"/items?allow=", "GET", nil},
+	} {
+		errs = nil
+		r := httptest.NewRequest("OPTIONS", tt.target, nil)
+		r.Header.Set("Origin", "https://example.com")
+		r.Header.Set("Access-Control-Request-Method", tt.method)
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		if !reflect.DeepEqual(errs, tt.want) {
+			t.Errorf("OPTIONS %s: got errors %q; want %q", tt.target, errs, tt.want)
+		}
+	}
+
+	// Ordinary OPTIONS requests are not checked.
+	errs = nil
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("OPTIONS", "/items?allow=PUT", nil))
+	if errs != nil {
+		t.Errorf("non-preflight request was checked: %q", errs)
+	}
+}
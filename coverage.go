@@ -0,0 +1,164 @@
+package hmux
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// A Coverage records which rules serve requests, so that tests can find
+// routes which they do not exercise. To use it, register its Observe method
+// with Builder.OnMatch, run the tests against the built Mux, and then
+// examine the results:
+//
+//	cov := hmux.NewCoverage()
+//	b.OnMatch(cov.Observe)
+//	mux := b.Build()
+//	// ... test requests using mux ...
+//	cov.Check(t, mux)
+//
+// Rules are identified by method, pattern, and conditions (such as those
+// added by Host or Query), so one Coverage may observe several Muxes built
+// from the same Builder. It is safe to use concurrently.
+//
+// A production server may also use a Coverage to find routes which are never
+// used. Such questions are best answered over weeks rather than a single
+// process lifetime, so the hit counts may be persisted across restarts using
+// Save and Load.
+type Coverage struct {
+	mu   sync.Mutex
+	hits map[string]int // by coverageKey
+}
+
+// NewCoverage creates an empty Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{hits: make(map[string]int)}
+}
+
+// Observe records the match m. It has the signature required by
+// Builder.OnMatch.
+func (c *Coverage) Observe(r *http.Request, m *Match) {
+	k := coverageKey(m.rule)
+	c.mu.Lock()
+	c.hits[k]++
+	c.mu.Unlock()
+}
+
+// Uncovered returns the routes of m, in the order given by Mux.Routes, whose
+// rules have not served any observed requests.
+func (c *Coverage) Uncovered(m *Mux) []Route {
+	return c.UnusedSince(m, nil)
+}
+
+// UnusedSince returns the routes of m, in the order given by Mux.Routes, whose
+// rules have not served any observed requests since baseline was taken using
+// Snapshot. Comparing against a snapshot saved at the start of a window (say,
+// 30 days ago) gives the routes without traffic during the window. A nil
+// baseline is equivalent to a snapshot with no hits.
+func (c *Coverage) UnusedSince(m *Mux, baseline map[string]int) []Route {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var routes []Route
+	for _, ma := range m.matchers {
+		ma.forEachRule(func(rl *rule) {
+			k := coverageKey(rl)
+			if c.hits[k]-baseline[k] <= 0 {
+				routes = append(routes, rl.route())
+			}
+		})
+	}
+	return routes
+}
+
+// WriteUnusedReport writes a table listing the routes of m returned by
+// UnusedSince along with their owners, given by the metadata key ownerKey
+// (see Meta), to drive periodic cleanup of unused routes. The routes are
+// grouped by owner, and routes without an owner are listed last with the
+// owner "-". For example:
+//
+//	cov.WriteUnusedReport(os.Stdout, mux, monthAgo, "owner")
+//
+// might write
+//
+//	billing  GET /invoices/:id/legacy
+//	search   GET /search/v1
+//	-        POST /debug/reindex
+func (c *Coverage) WriteUnusedReport(w io.Writer, m *Mux, baseline map[string]int, ownerKey string) error {
+	routes := c.UnusedSince(m, baseline)
+	owner := func(rt Route) string {
+		if o := rt.Meta[ownerKey]; o != "" {
+			return o
+		}
+		return "-"
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		oi, oj := owner(routes[i]), owner(routes[j])
+		if (oi == "-") != (oj == "-") {
+			return oj == "-"
+		}
+		return oi < oj
+	})
+	width := 0
+	for _, rt := range routes {
+		if n := len(owner(rt)); n > width {
+			width = n
+		}
+	}
+	for _, rt := range routes {
+		if _, err := fmt.Fprintf(w, "%-*s  %s\n", width, owner(rt), describeRoute(rt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports an error to t for each route of m that has not served any
+// observed requests. The t argument is typically a *testing.T.
+func (c *Coverage) Check(t interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}, m *Mux) {
+	t.Helper()
+	for _, rt := range c.Uncovered(m) {
+		t.Errorf("hmux: route not covered: %s", describeRoute(rt))
+	}
+}
+
+// WriteReport writes a table listing each route of m with the number of
+// observed requests it served.
+func (c *Coverage) WriteReport(w io.Writer, m *Mux) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var rules []*rule
+	width := 1
+	for _, ma := range m.matchers {
+		ma.forEachRule(func(rl *rule) {
+			rules = append(rules, rl)
+			if n := len(strconv.Itoa(c.hits[coverageKey(rl)])); n > width {
+				width = n
+			}
+		})
+	}
+	for _, rl := range rules {
+		_, err := fmt.Fprintf(w, "%*d  %s\n", width, c.hits[coverageKey(rl)], describeRoute(rl.route()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func coverageKey(rl *rule) string {
+	return rl.method + " " + rl.pat + " " + rl.condKey()
+}
+
+func describeRoute(rt Route) string {
+	method := rt.Method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + rt.Pattern
+}
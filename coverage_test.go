@@ -0,0 +1,71 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	cov := NewCoverage()
+	b := NewBuilder()
+	b.OnMatch(cov.Observe)
+	b.Get("/a", testHandler("a"))
+	b.Post("/a", testHandler("post a"))
+	b.Get("/b/:id", testHandler("b"))
+	b.Get("/c", testHandler("c rss"), Query("format", "rss"))
+	b.Get("/c", testHandler("c"))
+	b.Handle("", "/d", testHandler("d"))
+	mux := b.Build()
+
+	for _, pth := range []string{"/a", "/b/1", "/b/2", "/c?format=rss", "/nope"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", pth, nil))
+	}
+
+	var got []string
+	for _, rt := range cov.Uncovered(mux) {
+		got = append(got, rt.Method+" "+rt.Pattern)
+	}
+	want := []string{" /d", "GET /c", "POST /a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got uncovered routes %q; want %q", got, want)
+	}
+
+	ft := new(fakeT)
+	cov.Check(ft, mux)
+	wantErrs := []string{
+		"hmux: route not covered: * /d",
+		"hmux: route not covered: GET /c",
+		"hmux: route not covered: POST /a",
+	}
+	if !reflect.DeepEqual(ft.errs, wantErrs) {
+		t.Errorf("Check reported %q; want %q", ft.errs, wantErrs)
+	}
+
+	var sb strings.Builder
+	if err := cov.WriteReport(&sb, mux); err != nil {
+		t.Fatal(err)
+	}
+	wantReport := `0  * /d
+1  GET /c
+0  GET /c
+2  GET /b/:id
+1  GET /a
+0  POST /a
+`
+	if got := sb.String(); got != wantReport {
+		t.Errorf("got report:\n%s\nwant:\n%s", got, wantReport)
+	}
+}
+
+type fakeT struct {
+	errs []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
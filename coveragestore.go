@@ -0,0 +1,105 @@
+package hmux
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Snapshot returns a copy of the hit counts recorded by c, keyed by opaque
+// rule identifiers. The snapshot can be persisted and later passed to
+// Restore.
+func (c *Coverage) Snapshot() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snap := make(map[string]int, len(c.hits))
+	for k, n := range c.hits {
+		snap[k] = n
+	}
+	return snap
+}
+
+// Restore adds the hit counts in snap, as returned by Snapshot (possibly of
+// another Coverage), to those recorded by c. Counts for rules which no longer
+// exist are retained but otherwise ignored.
+func (c *Coverage) Restore(snap map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, n := range snap {
+		c.hits[k] += n
+	}
+}
+
+// A CoverageStore persists the hit counts of a Coverage. See Coverage.Save
+// and Coverage.Load.
+type CoverageStore interface {
+	// SaveCoverage stores snap, replacing any previously stored counts.
+	SaveCoverage(snap map[string]int) error
+	// LoadCoverage returns the stored counts. If none have been stored,
+	// it returns an empty map and a nil error.
+	LoadCoverage() (map[string]int, error)
+}
+
+// Save stores a snapshot of c's hit counts in s. A server would typically
+// call Save periodically and before exiting.
+func (c *Coverage) Save(s CoverageStore) error {
+	return s.SaveCoverage(c.Snapshot())
+}
+
+// Load adds the hit counts stored in s to those recorded by c. A server
+// would typically call Load once, on startup, before serving requests.
+func (c *Coverage) Load(s CoverageStore) error {
+	snap, err := s.LoadCoverage()
+	if err != nil {
+		return err
+	}
+	c.Restore(snap)
+	return nil
+}
+
+// FileCoverageStore returns a CoverageStore which stores hit counts in the
+// named file as JSON. Saving replaces the file atomically (on systems where
+// renaming a file does so).
+func FileCoverageStore(name string) CoverageStore {
+	return fileCoverageStore(name)
+}
+
+type fileCoverageStore string
+
+func (name fileCoverageStore) SaveCoverage(snap map[string]int) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(string(name)), filepath.Base(string(name))+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), string(name))
+}
+
+func (name fileCoverageStore) LoadCoverage() (map[string]int, error) {
+	b, err := os.ReadFile(string(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]int), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap map[string]int
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
@@ -0,0 +1,106 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoverageStore(t *testing.T) {
+	store := FileCoverageStore(filepath.Join(t.TempDir(), "coverage.json"))
+	build := func(cov *Coverage) *Mux {
+		b := NewBuilder()
+		b.OnMatch(cov.Observe)
+		b.Get("/a", testHandler("a"))
+		b.Get("/b", testHandler("b"))
+		b.Get("/c", testHandler("c"))
+		return b.Build()
+	}
+	serve := func(mux *Mux, paths ...string) {
+		for _, pth := range paths {
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", pth, nil))
+		}
+	}
+	uncovered := func(cov *Coverage, mux *Mux) []string {
+		var pats []string
+		for _, rt := range cov.Uncovered(mux) {
+			pats = append(pats, rt.Pattern)
+		}
+		return pats
+	}
+
+	// First process lifetime.
+	cov := NewCoverage()
+	if err := cov.Load(store); err != nil {
+		t.Fatal(err)
+	}
+	mux := build(cov)
+	serve(mux, "/a", "/a")
+	if err := cov.Save(store); err != nil {
+		t.Fatal(err)
+	}
+
+	// Second process lifetime.
+	cov = NewCoverage()
+	if err := cov.Load(store); err != nil {
+		t.Fatal(err)
+	}
+	mux = build(cov)
+	serve(mux, "/b")
+	if got, want := uncovered(cov, mux), []string{"/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got uncovered %q; want %q", got, want)
+	}
+	if err := cov.Save(store); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := store.LoadCoverage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap, cov.Snapshot()) {
+		t.Errorf("stored %v; want %v", snap, cov.Snapshot())
+	}
+	total := 0
+	for _, n := range snap {
+		total += n
+	}
+	if total != 3 {
+		t.Errorf("got %d total hits; want 3", total)
+	}
+}
+
+func TestCoverageUnusedReport(t *testing.T) {
+	cov := NewCoverage()
+	b := NewBuilder()
+	b.OnMatch(cov.Observe)
+	b.Get("/invoices/:id/legacy", testHandler(""), Meta("owner", "billing"))
+	b.Get("/invoices/:id", testHandler(""), Meta("owner", "billing"))
+	b.Get("/search/v1", testHandler(""), Meta("owner", "search"))
+	b.Post("/debug/reindex", testHandler(""))
+	b.Get("/old", testHandler(""), Meta("owner", "billing"))
+	mux := b.Build()
+
+	serve := func(paths ...string) {
+		for _, pth := range paths {
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", pth, nil))
+		}
+	}
+	serve("/old", "/invoices/1")
+	baseline := cov.Snapshot()
+	serve("/invoices/2")
+
+	var sb strings.Builder
+	if err := cov.WriteUnusedReport(&sb, mux, baseline, "owner"); err != nil {
+		t.Fatal(err)
+	}
+	want := `billing  GET /old
+billing  GET /invoices/:id/legacy
+search   GET /search/v1
+-        POST /debug/reindex
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got report:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+package hmux
+
+import (
+	"net/http"
+	"time"
+)
+
+// Deadlines returns a RuleOption which sets I/O deadlines for requests
+// handled by a rule, overriding the server-wide timeouts configured in the
+// http.Server for the rest of the request. Before calling the handler, the
+// Mux sets the read deadline to read after the current time and the write
+// deadline to write after the current time, using http.ResponseController.
+// A zero duration leaves the corresponding deadline unchanged.
+//
+// For example, a long-polling endpoint may need more time to write its
+// response than other endpoints:
+//
+//	b.Get("/poll", handlePoll, hmux.Deadlines(0, 2*time.Minute))
+//
+// If the ResponseWriter does not support setting deadlines, they are not set.
+// Deadlines panics if either duration is negative.
+func Deadlines(read, write time.Duration) RuleOption {
+	if read < 0 || write < 0 {
+		panic("hmux: Deadlines called with negative duration")
+	}
+	return func(rl *rule) {
+		rl.mws = append(rl.mws, func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rc := http.NewResponseController(w)
+				now := timeNow()
+				if read > 0 {
+					rc.SetReadDeadline(now.Add(read))
+				}
+				if write > 0 {
+					rc.SetWriteDeadline(now.Add(write))
+				}
+				h.ServeHTTP(w, r)
+			})
+		})
+	}
+}
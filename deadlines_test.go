@@ -0,0 +1,66 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	read, write time.Time
+}
+
+func (w *deadlineRecorder) SetReadDeadline(t time.Time) error {
+	w.read = t
+	return nil
+}
+
+func (w *deadlineRecorder) SetWriteDeadline(t time.Time) error {
+	w.write = t
+	return nil
+}
+
+func TestDeadlines(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { timeNow = time.Now }()
+	timeNow = func() time.Time { return now }
+
+	b := NewBuilder()
+	b.Get("/poll", testHandler("poll"), Deadlines(0, 2*time.Minute))
+	b.Post("/upload", testHandler("upload"), Deadlines(time.Hour, time.Minute))
+	b.Get("/plain", testHandler("plain"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		method    string
+		path      string
+		wantRead  time.Time
+		wantWrite time.Time
+	}{
+		{"GET", "/poll", time.Time{}, now.Add(2 * time.Minute)},
+		{"POST", "/upload", now.Add(time.Hour), now.Add(time.Minute)},
+		{"GET", "/plain", time.Time{}, time.Time{}},
+	} {
+		w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if !w.read.Equal(tt.wantRead) || !w.write.Equal(tt.wantWrite) {
+			t.Errorf("%s %s: got deadlines (%v, %v); want (%v, %v)",
+				tt.method, tt.path, w.read, w.write, tt.wantRead, tt.wantWrite)
+		}
+	}
+
+	// Writers without deadline support are served normally.
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/poll", nil))
+	if got := w.Body.String(); got != "poll" {
+		t.Errorf("got body %q; want poll", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Deadlines with a negative duration did not panic")
+		}
+	}()
+	Deadlines(-time.Second, 0)
+}
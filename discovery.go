@@ -0,0 +1,67 @@
+package hmux
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// DescribeMethods controls whether Muxes built from b describe the methods
+// they serve for a path in the bodies of 405 ("Method Not Allowed")
+// responses, so that API clients can discover them without a separate
+// documentation service. It also enables responding to OPTIONS requests for
+// paths that have no rule for OPTIONS with the same description (and a 200
+// status).
+//
+// The description is a JSON document listing the methods given in the Allow
+// header. If a rule for a method has the "doc" metadata key (see Meta), its
+// value is included as a link to the method's documentation:
+//
+//	b.Get("/users/:id", getUser, hmux.Meta("doc", "https://example.com/docs/users#get"))
+//	b.Put("/users/:id", putUser)
+//
+// Here a DELETE request for /users/3 receives a 405 response with this body:
+//
+//	{"methods":[{"method":"GET","doc":"https://example.com/docs/users#get"},{"method":"PUT"}]}
+//
+// If several rules for a method have the key (because they have different
+// conditions), the value of the first one that the Mux would consider is used.
+func (b *Builder) DescribeMethods(enable bool) {
+	b.checkNotGroup("DescribeMethods")
+	b.describeMethods = enable
+}
+
+type methodsDocument struct {
+	Methods []methodDescription `json:"methods"`
+}
+
+type methodDescription struct {
+	Method string `json:"method"`
+	Doc    string `json:"doc,omitempty"`
+}
+
+// writeMethodsDocument responds to r, which matched the pattern of ma but none
+// of its methods, with a description of the methods of ma.
+func writeMethodsDocument(w http.ResponseWriter, r *http.Request, ma *matcher) {
+	var doc methodsDocument
+	for _, method := range ma.methodNames {
+		d := methodDescription{Method: method}
+		for _, rl := range ma.rules(method) {
+			if v, ok := rl.meta["doc"]; ok {
+				d.Doc = v
+				break
+			}
+		}
+		doc.Methods = append(doc.Methods, d)
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	status := http.StatusMethodNotAllowed
+	if r.Method == http.MethodOptions {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
@@ -0,0 +1,42 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDescribeMethods(t *testing.T) {
+	b := NewBuilder()
+	b.DescribeMethods(true)
+	b.Get("/users/:id", testHandler("get"), Meta("doc", "https://example.com/docs/users#get"))
+	b.Put("/users/:id", testHandler("put"))
+	b.Options("/opts", testHandler("opts"))
+	b.Get("/opts", testHandler("get opts"))
+	mux := b.Build()
+
+	const usersDoc = `{"methods":[{"method":"GET","doc":"https://example.com/docs/users#get"},{"method":"PUT"}]}` + "\n"
+	for _, tt := range []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{"DELETE", "/users/3", 405, usersDoc, "GET, PUT"},
+		{"OPTIONS", "/users/3", 200, usersDoc, "GET, PUT"},
+		{"GET", "/users/3", 200, "get", ""},
+		{"OPTIONS", "/opts", 200, "opts", ""},
+		{"DELETE", "/opts", 405, `{"methods":[{"method":"GET"},{"method":"OPTIONS"}]}` + "\n", "GET, OPTIONS"},
+		{"GET", "/nope", 404, "404 page not found\n", ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.wantStatus || w.Body.String() != tt.wantBody {
+			t.Errorf("%s %s: got %d %q; want %d %q",
+				tt.method, tt.path, w.Code, w.Body, tt.wantStatus, tt.wantBody)
+		}
+		if got := w.Header().Get("Allow"); got != tt.wantAllow {
+			t.Errorf("%s %s: got Allow %q; want %q", tt.method, tt.path, got, tt.wantAllow)
+		}
+	}
+}
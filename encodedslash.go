@@ -0,0 +1,75 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// An EncodedSlashPolicy specifies how a Mux handles request paths containing
+// an escaped slash (%2F). See Builder.SetEncodedSlashPolicy.
+type EncodedSlashPolicy int
+
+const (
+	// EncodedSlashKeep treats %2F as data within a path segment, so
+	// /a%2Fb has the single segment "a/b" and doesn't match the pattern
+	// "/a/b". This is the default.
+	EncodedSlashKeep EncodedSlashPolicy = iota
+	// EncodedSlashDecode treats %2F as a path separator, so /a%2Fb is
+	// routed (and passed to the handler) as if it were /a/b.
+	EncodedSlashDecode
+	// EncodedSlashReject responds to requests whose paths contain %2F
+	// with a 400 ("Bad Request").
+	EncodedSlashReject
+)
+
+// SetEncodedSlashPolicy sets how Muxes built from b handle request paths
+// containing escaped slashes. Which policy is appropriate depends on the
+// deployment: some proxies decode %2F before forwarding requests and others
+// pass it through, and a server may prefer to reject such paths rather than
+// risk routing them differently from the proxy in front of it.
+//
+// The policy is applied after the functions registered with Normalize.
+// Under EncodedSlashDecode, the handler receives a request whose URL's
+// RawPath is cleared, so that its path is the decoded form.
+func (b *Builder) SetEncodedSlashPolicy(p EncodedSlashPolicy) {
+	b.checkNotGroup("SetEncodedSlashPolicy")
+	switch p {
+	case EncodedSlashKeep, EncodedSlashDecode, EncodedSlashReject:
+	default:
+		panic("hmux: SetEncodedSlashPolicy called with invalid policy")
+	}
+	b.encodedSlash = p
+}
+
+// applyEncodedSlashPolicy applies m's EncodedSlashPolicy to r. It returns the
+// request to route or, if r has been rejected, nil.
+func (m *Mux) applyEncodedSlashPolicy(w http.ResponseWriter, r *http.Request) *http.Request {
+	// Path never contains an escaped slash on its own; RawPath is set
+	// if the original path did.
+	if !hasEncodedSlash(r.URL.RawPath) {
+		return r
+	}
+	if m.encodedSlash == EncodedSlashReject {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil
+	}
+	r1 := new(http.Request)
+	*r1 = *r
+	u := *r.URL
+	u.RawPath = ""
+	r1.URL = &u
+	return r1
+}
+
+func hasEncodedSlash(s string) bool {
+	for {
+		i := strings.IndexByte(s, '%')
+		if i < 0 || i+2 >= len(s) {
+			return false
+		}
+		if s[i+1] == '2' && (s[i+2] == 'F' || s[i+2] == 'f') {
+			return true
+		}
+		s = s[i+1:]
+	}
+}
@@ -0,0 +1,35 @@
+package hmux
+
+import "testing"
+
+func TestEncodedSlashPolicy(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/a/b", testHandler("a/b"))
+	b.Get("/:name", testHandler("name %s", "name"))
+	b.Get("/files/*", testHandler("files %s", "*"))
+
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/a/b", "a/b"},
+		{"GET", "/a%2Fb", "name a/b"},
+		{"GET", "/files/x%2fy", "files /x/y"},
+	})
+
+	b.SetEncodedSlashPolicy(EncodedSlashDecode)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/a/b", "a/b"},
+		{"GET", "/a%2Fb", "a/b"},
+		{"GET", "/a%2fb", "a/b"},
+		{"GET", "/c%20d", "name c d"},
+		{"GET", "/files/x%2Fy", "files /x/y"},
+		{"GET", "/a%2F%2Fb", "308 /a/b"},
+	})
+
+	b.SetEncodedSlashPolicy(EncodedSlashReject)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/a/b", "a/b"},
+		{"GET", "/a%2Fb", "400"},
+		{"GET", "/files/x%2fy", "400"},
+		{"GET", "/c%20d", "name c d"},
+		{"GET", "/c%252F", "name c%2F"},
+	})
+}
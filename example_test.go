@@ -60,6 +60,19 @@ func Example_nestedMuxes() {
 	log.Fatal(http.ListenAndServe(":5555", mux))
 }
 
+func Example_routeMiddleware() {
+	// Rather than building a separate Mux for the admin pages as in the
+	// nestedMuxes example, the admin check may be applied to individual
+	// rules.
+	b := hmux.NewBuilder()
+	b.Get("/", staticHandler("Main page"))
+	b.Get("/profile", staticHandler("User profile"))
+	b.Get("/admin/users", staticHandler("List of all users"), hmux.Middleware(checkAdmin))
+	mux := checkUser(b.Build())
+
+	log.Fatal(http.ListenAndServe(":5555", mux))
+}
+
 func Example_fileServing() {
 	b := hmux.NewBuilder()
 	b.Get("/", staticHandler("Main page"))
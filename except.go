@@ -0,0 +1,62 @@
+package hmux
+
+import "net/http"
+
+// Except returns a RuleOption which excludes requests whose paths match the
+// given pattern from a rule. This keeps a catch-all rule from shadowing
+// routes registered elsewhere:
+//
+//	b.Prefix("/app", serveSPA, hmux.Except("/app/api/*"))
+//
+// If the path matches an excluded pattern, the Mux ignores the rule and
+// continues looking for a matching rule, so a request for /app/api/users is
+// routed to some other rule for that path (or results in a 404) rather than
+// being served by serveSPA.
+//
+// The excluded pattern is matched against the whole request path, with the
+// same options (such as case-insensitive matching) as the Mux's rules. It
+// may not use custom parameter types or pattern fragments. A rule may be
+// given several Except options; it is excluded if any of them match. As with
+// other conditions, rules with Except options take precedence over rules for
+// the same pattern and method with fewer restrictions.
+//
+// Except panics if pat is not a valid pattern.
+func Except(pat string) RuleOption {
+	p, err := parsePattern(pat, nil)
+	if err != nil {
+		panic("hmux: " + err.Error())
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, exceptCond{pat: pat, p: p})
+	}
+}
+
+type exceptCond struct {
+	pat string
+	p   pattern
+	mux *Mux
+}
+
+func (c exceptCond) check(r *http.Request, _ *rule, _ *Params) int {
+	pth, opts, _ := c.mux.matchPath(r)
+	if c.mux.serveMux {
+		opts |= optSubtree
+	}
+	parts, opts := c.mux.splitPath(pth, opts)
+	if _, ok := c.p.match(parts, opts); ok {
+		return condSkip
+	}
+	return condOK
+}
+
+func (c exceptCond) key() string {
+	return "except:" + c.pat
+}
+
+func (c exceptCond) bindCond(m *Mux, _ *rule) condition {
+	c.mux = m
+	if m.normSeg != nil {
+		c.p, _ = c.p.normalize(m.normSeg)
+	}
+	return c
+}
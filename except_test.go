@@ -0,0 +1,54 @@
+package hmux
+
+import "testing"
+
+func TestExcept(t *testing.T) {
+	b := NewBuilder()
+	b.Prefix("/app", testHandler("spa"), Except("/app/api/*"), Except("/app/static/*.map"))
+	b.Get("/app/api/users", testHandler("users"))
+	b.Get("/:id", testHandler("item %s", "id"), Except("/new"))
+	b.Get("/new", testHandler("new"), Query("form", ""))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/app/", "spa"},
+		{"GET", "/app/settings", "spa"},
+		{"POST", "/app/api", "spa"},
+		{"GET", "/app/api/users", "users"},
+		{"GET", "/app/api/other", "404"},
+		{"GET", "/app/static/x.js", "spa"},
+		{"GET", "/app/static/x.js.map", "404"},
+		{"GET", "/3", "item 3"},
+		{"GET", "/new", "404"},
+		{"GET", "/new?form", "new"},
+	})
+
+	b = NewBuilder()
+	b.CaseInsensitive(true)
+	b.Prefix("/app", testHandler("spa"), Except("/app/api/*"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/APP/x", "spa"},
+		{"GET", "/APP/API/x", "404"},
+	})
+}
+
+func TestExceptConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x/*", testHandler("a"), Except("/x/y"))
+	b.Get("/x/*", testHandler("b"))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule with the same exclusion did not panic")
+		}
+	}()
+	b.Get("/x/*", testHandler("c"), Except("/x/y"))
+}
+
+func TestExceptBadPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Except with an invalid pattern did not panic")
+		}
+	}()
+	Except("x//y")
+}
@@ -0,0 +1,82 @@
+package hmux
+
+import "net/http"
+
+// A FlagDecision is the result of evaluating a feature flag for a request.
+type FlagDecision int
+
+const (
+	// FlagServe indicates that the rule's handler should serve the
+	// request.
+	FlagServe FlagDecision = iota
+	// FlagFallThrough indicates that the Mux should ignore the rule and
+	// continue matching the request against other rules.
+	FlagFallThrough
+	// FlagNotFound indicates that the Mux should respond with a 404.
+	FlagNotFound
+)
+
+// A FlagProvider evaluates feature flags.
+//
+// Flag is called by a Mux with the name given to the Flag option, the
+// request, the Route of the rule that matched it, and the rule's path
+// parameters (which may be nil). Flag may be called for rules that are
+// ultimately not used to serve the request, and it must be safe to call
+// concurrently. The Route is shared by the calls for a rule, so Flag must
+// not modify its Meta map.
+type FlagProvider interface {
+	Flag(name string, r *http.Request, rt Route, p *Params) FlagDecision
+}
+
+// FlagProviderFunc is an adapter to allow the use of ordinary functions as
+// FlagProviders.
+type FlagProviderFunc func(name string, r *http.Request, rt Route, p *Params) FlagDecision
+
+// Flag calls f(name, r, rt, p).
+func (f FlagProviderFunc) Flag(name string, r *http.Request, rt Route, p *Params) FlagDecision {
+	return f(name, r, rt, p)
+}
+
+// Flag returns a RuleOption which gates a rule behind the feature flag name,
+// as evaluated by provider for each request that the rule matches.
+//
+// If the flag's decision is FlagFallThrough, the Mux continues looking for a
+// matching rule. Other rules for the same pattern and method are considered
+// first: rules with flags take precedence over rules without them, so an
+// unflagged rule registered for the same pattern and method serves as the
+// fallback when the flag is off. (Registering two rules for the same pattern
+// and method with the same set of flags causes a panic, as usual.)
+//
+// If the flag's decision is FlagNotFound and no other rule for the same
+// pattern and method matches, the Mux responds with a 404.
+func Flag(name string, provider FlagProvider) RuleOption {
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, flagCond{name: name, provider: provider})
+	}
+}
+
+type flagCond struct {
+	name     string
+	provider FlagProvider
+	rt       Route // set by bindCond
+}
+
+func (c flagCond) check(r *http.Request, _ *rule, p *Params) int {
+	switch c.provider.Flag(c.name, r, c.rt, p) {
+	case FlagServe:
+		return condOK
+	case FlagNotFound:
+		return http.StatusNotFound
+	default:
+		return condSkip
+	}
+}
+
+func (c flagCond) key() string { return "flag:" + c.name }
+
+// bindCond records the Route of rl so that it need not be built for each
+// request.
+func (c flagCond) bindCond(_ *Mux, rl *rule) condition {
+	c.rt = rl.route()
+	return c
+}
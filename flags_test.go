@@ -0,0 +1,82 @@
+package hmux
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFlag(t *testing.T) {
+	provider := FlagProviderFunc(func(name string, r *http.Request, rt Route, p *Params) FlagDecision {
+		switch name {
+		case "new-items":
+			if rt.Pattern != "/items/:id" {
+				panic("unexpected route")
+			}
+			if p.Get("id") == "1" {
+				return FlagServe
+			}
+			return FlagFallThrough
+		case "beta":
+			return FlagNotFound
+		default:
+			return FlagFallThrough
+		}
+	})
+	b := NewBuilder()
+	b.Get("/items/:id", testHandler("new %s", "id"), Flag("new-items", provider))
+	b.Get("/items/:id", testHandler("old %s", "id"))
+	b.Get("/beta", testHandler("beta"), Flag("beta", provider))
+	b.Get("/off/:x", testHandler("off"), Flag("off", provider))
+	b.Get("/off/*", testHandler("wildcard"))
+	b.Post("/only-flagged", testHandler("x"), Flag("off", provider))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/items/1", "new 1"},
+		{"GET", "/items/2", "old 2"},
+		{"GET", "/beta", "404"},
+		{"GET", "/off/a", "wildcard"},
+		{"POST", "/only-flagged", "404"},
+		{"GET", "/only-flagged", "405 POST"},
+	})
+}
+
+func TestFlagConflict(t *testing.T) {
+	provider := FlagProviderFunc(func(string, *http.Request, Route, *Params) FlagDecision {
+		return FlagServe
+	})
+	b := NewBuilder()
+	b.Get("/x", testHandler("a"), Flag("a", provider))
+	b.Get("/x", testHandler("b"), Flag("b", provider))
+	b.Get("/x", testHandler("c"))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule with the same flags did not panic")
+		}
+	}()
+	b.Get("/x", testHandler("d"), Flag("b", provider))
+}
+
+func TestFlagRoute(t *testing.T) {
+	var metas []map[string]string
+	provider := FlagProviderFunc(func(name string, r *http.Request, rt Route, p *Params) FlagDecision {
+		if rt.Name != "item" || rt.Method != "GET" || rt.Meta["team"] != "x" {
+			t.Errorf("Flag got route %+v", rt)
+		}
+		metas = append(metas, rt.Meta)
+		return FlagServe
+	})
+	b := NewBuilder()
+	// The Route reflects options given after Flag.
+	b.Get("/items/:id", testHandler("item %s", "id"), Flag("f", provider), Name("item"), Meta("team", "x"))
+	mux := b.Build()
+	testRequests(t, mux, []reqTest{
+		{"GET", "/items/1", "item 1"},
+		{"GET", "/items/2", "item 2"},
+	})
+	// The Route is built once rather than for each request.
+	if len(metas) != 2 || reflect.ValueOf(metas[0]).Pointer() != reflect.ValueOf(metas[1]).Pointer() {
+		t.Error("Flag got a different Route for each request")
+	}
+}
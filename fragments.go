@@ -0,0 +1,86 @@
+package hmux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefinePattern defines a named pattern fragment which later patterns given
+// to b may reference with a segment of the form {name}. This avoids
+// repeating long prefixes and parameter definitions in large route tables:
+//
+//	b.DefinePattern("user", "/orgs/:org/users/:user")
+//	b.Get("/{user}", serveUser)               // "/orgs/:org/users/:user"
+//	b.Get("/{user}/repos/:repo", serveRepo)   // "/orgs/:org/users/:user/repos/:repo"
+//	b.Get("/api/v2/{user}/settings", serveSettings)
+//
+// The fragment is a pattern beginning with a slash which does not end with a
+// slash or a wildcard. It may itself reference previously defined fragments.
+// It is substituted textually, so the resulting patterns are checked as usual
+// (for example, for duplicate parameter names), and the routes of a Mux
+// report the expanded patterns.
+//
+// A pattern segment of the form {name} is always a fragment reference;
+// patterns that reference undefined fragments are invalid. To match a
+// literal segment of that form, escape the braces ("%7Bname%7D").
+//
+// DefinePattern panics if name is empty or contains a slash or brace, if name
+// is already defined, or if the fragment is invalid.
+func (b *Builder) DefinePattern(name, fragment string) {
+	if b.group != nil {
+		b.group.parent.DefinePattern(name, fragment)
+		return
+	}
+	if name == "" || strings.ContainsAny(name, "/{}") {
+		panic(fmt.Sprintf("hmux: invalid pattern fragment name %q", name))
+	}
+	if _, ok := b.fragments[name]; ok {
+		panic(fmt.Sprintf("hmux: pattern fragment %q is already defined", name))
+	}
+	if !strings.HasPrefix(fragment, "/") || strings.HasSuffix(fragment, "/") ||
+		strings.Contains(fragment, "*") {
+		panic(fmt.Sprintf("hmux: invalid pattern fragment %q", fragment))
+	}
+	expanded, err := b.expandPattern(fragment)
+	if err != nil {
+		panic("hmux: " + err.Error())
+	}
+	if b.fragments == nil {
+		b.fragments = make(map[string]string)
+	}
+	b.fragments[name] = expanded
+}
+
+// expandPattern substitutes the fragments referenced by pat.
+func (b *Builder) expandPattern(pat string) (string, error) {
+	if !strings.Contains(pat, "{") {
+		return pat, nil
+	}
+	parts := strings.Split(pat, "/")
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			continue
+		}
+		name := part[1 : len(part)-1]
+		frag, ok := b.fragments[name]
+		if !ok {
+			return "", fmt.Errorf("pattern %q references undefined fragment %q", pat, name)
+		}
+		parts[i] = strings.TrimPrefix(frag, "/")
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// parsePattern expands the fragments referenced by pat and parses the
+// result, which it also returns.
+func (b *Builder) parsePattern(pat string) (string, pattern, error) {
+	if b.group != nil {
+		return b.group.parsePattern(pat)
+	}
+	pat, err := b.expandPattern(pat)
+	if err != nil {
+		return "", pattern{}, err
+	}
+	p, err := parsePattern(pat, b.paramTypes)
+	return pat, p, err
+}
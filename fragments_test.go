@@ -0,0 +1,69 @@
+package hmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefinePattern(t *testing.T) {
+	b := NewBuilder()
+	b.DefinePattern("org", "/orgs/:org")
+	b.DefinePattern("user", "/{org}/users/:user")
+	b.Get("/{user}", testHandler("user %s %s", "org", "user"))
+	b.Get("/{user}/repos/:repo", testHandler("repo %s", "repo"))
+	b.Get("/api/{org}/", testHandler("api org %s", "org"))
+	b.Prefix("/files/{org}", testHandler("files %s", "*"))
+	b.Get("/%7Borg%7D", testHandler("literal"))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/orgs/a/users/b", "user a b"},
+		{"GET", "/orgs/a/users/b/repos/c", "repo c"},
+		{"GET", "/api/orgs/a/", "api org a"},
+		{"GET", "/files/orgs/a/x/y", "files /x/y"},
+		{"GET", "/%7Borg%7D", "literal"},
+	})
+
+	var got []string
+	for _, rt := range mux.Routes() {
+		got = append(got, rt.Pattern)
+	}
+	want := []string{
+		"/%7Borg%7D",
+		"/orgs/:org/users/:user/repos/:repo",
+		"/orgs/:org/users/:user",
+		"/files/orgs/:org",
+		"/api/orgs/:org/",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got patterns %q; want %q", got, want)
+	}
+}
+
+func TestDefinePatternErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		f    func(b *Builder)
+	}{
+		{"empty name", func(b *Builder) { b.DefinePattern("", "/x") }},
+		{"slash in name", func(b *Builder) { b.DefinePattern("a/b", "/x") }},
+		{"redefinition", func(b *Builder) { b.DefinePattern("org", "/y") }},
+		{"no leading slash", func(b *Builder) { b.DefinePattern("x", "x") }},
+		{"trailing slash", func(b *Builder) { b.DefinePattern("x", "/x/") }},
+		{"wildcard", func(b *Builder) { b.DefinePattern("x", "/x/*") }},
+		{"undefined in fragment", func(b *Builder) { b.DefinePattern("x", "/{nope}/x") }},
+		{"undefined in pattern", func(b *Builder) { b.Get("/{nope}", testHandler("x")) }},
+		{"duplicate param", func(b *Builder) { b.Get("/{org}/:org", testHandler("x")) }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", tt.name)
+				}
+			}()
+			b := NewBuilder()
+			b.DefinePattern("org", "/orgs/:org")
+			tt.f(b)
+		}()
+	}
+}
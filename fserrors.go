@@ -0,0 +1,60 @@
+package hmux
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FSErrors returns a RuleOption which sets a function to respond to requests
+// for files which a rule registered by Builder.ServeFS cannot serve, in place
+// of http.FileServer's plain-text error responses. For example, an asset API
+// may report missing files as JSON:
+//
+//	b.ServeFS("/api/assets", assets, hmux.FSErrors(func(w http.ResponseWriter, r *http.Request, err error) {
+//		status := http.StatusInternalServerError
+//		switch {
+//		case errors.Is(err, fs.ErrNotExist):
+//			status = http.StatusNotFound
+//		case errors.Is(err, fs.ErrPermission):
+//			status = http.StatusForbidden
+//		}
+//		writeJSONError(w, status, err)
+//	}))
+//
+// The function is called with the error from looking up the requested file
+// in the file system (such as fs.ErrNotExist or fs.ErrPermission). As in the
+// handler given to Prefix, the request path has the pattern prefix removed.
+//
+// FSErrors has no effect on rules not registered by ServeFS.
+func FSErrors(f func(w http.ResponseWriter, r *http.Request, err error)) RuleOption {
+	if f == nil {
+		panic("hmux: FSErrors called with nil function")
+	}
+	return func(rl *rule) {
+		rl.fsErrors = f
+	}
+}
+
+// fsHandler is the handler used by ServeFS.
+type fsHandler struct {
+	fsys fs.FS
+	fs   http.Handler // http.FileServer for fsys
+}
+
+func (h fsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p := requestParams(r); p != nil && p.rule != nil && p.rule.fsErrors != nil {
+		// Look up the file as http.FileServer does, so that errors can
+		// be reported before it writes its own response.
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		if _, err := fs.Stat(h.fsys, name); err != nil {
+			p.rule.fsErrors(w, r, err)
+			return
+		}
+	}
+	h.fs.ServeHTTP(w, r)
+}
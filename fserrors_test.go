@@ -0,0 +1,66 @@
+package hmux
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type permFS struct {
+	fs.FS
+}
+
+func (fsys permFS) Open(name string) (fs.File, error) {
+	if strings.HasPrefix(name, "private") {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return fsys.FS.Open(name)
+}
+
+func TestFSErrors(t *testing.T) {
+	fsys := permFS{fstest.MapFS{
+		"a.txt":         &fstest.MapFile{Data: []byte("a")},
+		"dir/b.txt":     &fstest.MapFile{Data: []byte("b")},
+		"private/c.txt": &fstest.MapFile{Data: []byte("c")},
+	}}
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			status = http.StatusNotFound
+		case errors.Is(err, fs.ErrPermission):
+			status = http.StatusForbidden
+		}
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "custom %s", r.URL.Path)
+	}
+
+	b := NewBuilder()
+	b.ServeFS("/assets", fsys, FSErrors(onError))
+	b.ServeFS("/plain", fsys)
+	mux := b.Build()
+	for _, tt := range []struct {
+		path     string
+		wantCode int
+		want     string
+	}{
+		{"/assets/a.txt", 200, "a"},
+		{"/assets/dir/b.txt", 200, "b"},
+		{"/assets/nope.txt", 404, "custom /nope.txt"},
+		{"/assets/private/c.txt", 403, "custom /private/c.txt"},
+		{"/plain/nope.txt", 404, "404 page not found\n"},
+		{"/plain/private/c.txt", 403, "403 Forbidden\n"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.wantCode || w.Body.String() != tt.want {
+			t.Errorf("GET %s: got (%d, %q); want (%d, %q)",
+				tt.path, w.Code, w.Body.String(), tt.wantCode, tt.want)
+		}
+	}
+}
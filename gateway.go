@@ -0,0 +1,105 @@
+package hmux
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// WriteHTTPRoute writes a Kubernetes Gateway API HTTPRoute resource, in YAML,
+// which routes the paths and methods of m's rules to the Service backend on
+// the given port. The output is a starting point for generating edge routing
+// configuration from the application's own routes; typically, it is
+// combined with other configuration such as parentRefs and hostnames.
+//
+// Each rule of m becomes one HTTPRoute rule, in the order that m considers
+// them when matching a request. Patterns consisting only of
+// literal segments use Exact path matches; wildcard patterns without
+// parameters (and the empty pattern) use PathPrefix matches; other patterns
+// use RegularExpression matches in which each parameter matches any single
+// path segment. Rules for all methods match any method. The special pattern
+// "*" and the rule options that restrict matching in other ways (such as
+// Host and Flag) are not represented.
+func (m *Mux) WriteHTTPRoute(w io.Writer, name, backend string, port int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "apiVersion: gateway.networking.k8s.io/v1\n")
+	fmt.Fprintf(bw, "kind: HTTPRoute\n")
+	fmt.Fprintf(bw, "metadata:\n")
+	fmt.Fprintf(bw, "  name: %q\n", name)
+	fmt.Fprintf(bw, "spec:\n")
+	fmt.Fprintf(bw, "  rules:\n")
+	for _, ma := range m.matchers {
+		if ma.pat.opt == patStar {
+			continue
+		}
+		typ, value := gatewayPathMatch(ma.pat)
+		ma.forEachRule(func(rl *rule) {
+			fmt.Fprintf(bw, "  - matches:\n")
+			fmt.Fprintf(bw, "    - path:\n")
+			fmt.Fprintf(bw, "        type: %s\n", typ)
+			fmt.Fprintf(bw, "        value: %q\n", value)
+			if rl.method != "" {
+				fmt.Fprintf(bw, "      method: %s\n", rl.method)
+			}
+			fmt.Fprintf(bw, "    backendRefs:\n")
+			fmt.Fprintf(bw, "    - name: %q\n", backend)
+			fmt.Fprintf(bw, "      port: %d\n", port)
+		})
+	}
+	return bw.Flush()
+}
+
+// gatewayPathMatch returns the Gateway API path match type and value
+// corresponding to p (which is not "*").
+func gatewayPathMatch(p pattern) (typ, value string) {
+	if p.opt == patEmpty {
+		return "PathPrefix", "/"
+	}
+	if !p.hasParams() && p.glob == nil && !p.anySlash {
+		pth, _ := p.fill(nil)
+		if p.opt == patWildcard {
+			return "PathPrefix", strings.TrimSuffix(pth, "/")
+		}
+		return "Exact", pth
+	}
+	var sb strings.Builder
+	for _, seg := range p.segs {
+		sb.WriteByte('/')
+		if seg.isParam {
+			sb.WriteString("[^/]+")
+			sb.WriteString(regexp.QuoteMeta(url.PathEscape(seg.suffix)))
+		} else {
+			sb.WriteString(regexp.QuoteMeta(url.PathEscape(seg.s)))
+		}
+	}
+	switch p.opt {
+	case patOther:
+		if p.anySlash {
+			sb.WriteString("/?")
+		}
+	case patTrailingSlash:
+		sb.WriteByte('/')
+	case patWildcard:
+		if p.glob == nil {
+			sb.WriteString("/.*")
+			break
+		}
+		for _, g := range p.glob {
+			if g == "**" {
+				sb.WriteString("(/[^/]+)*")
+				continue
+			}
+			sb.WriteByte('/')
+			for i, lit := range strings.Split(g, "*") {
+				if i > 0 {
+					sb.WriteString("[^/]*")
+				}
+				sb.WriteString(regexp.QuoteMeta(url.PathEscape(lit)))
+			}
+		}
+	}
+	return "RegularExpression", sb.String()
+}
@@ -0,0 +1,97 @@
+package hmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteHTTPRoute(t *testing.T) {
+	b := NewBuilder()
+	h := testHandler("")
+	b.Get("/", h)
+	b.Get("/users/:id", h)
+	b.Put("/users/:id", h)
+	b.Get("/files/:name.json", h)
+	b.Prefix("/static", h)
+	b.Get("/t/:tenant/*", h)
+	b.Get("/js/**/*.min.js", h)
+	b.Get("/about", h, OptionalTrailingSlash())
+	b.Handle("OPTIONS", "*", h)
+	var sb strings.Builder
+	if err := b.Build().WriteHTTPRoute(&sb, "app", "app-svc", 8080); err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: "app"
+spec:
+  rules:
+  - matches:
+    - path:
+        type: RegularExpression
+        value: "/users/[^/]+"
+      method: GET
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+  - matches:
+    - path:
+        type: RegularExpression
+        value: "/users/[^/]+"
+      method: PUT
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+  - matches:
+    - path:
+        type: RegularExpression
+        value: "/t/[^/]+/.*"
+      method: GET
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+  - matches:
+    - path:
+        type: PathPrefix
+        value: "/static"
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+  - matches:
+    - path:
+        type: RegularExpression
+        value: "/js(/[^/]+)*/[^/]*\\.min\\.js"
+      method: GET
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+  - matches:
+    - path:
+        type: RegularExpression
+        value: "/files/[^/]+\\.json"
+      method: GET
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+  - matches:
+    - path:
+        type: RegularExpression
+        value: "/about/?"
+      method: GET
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+  - matches:
+    - path:
+        type: Exact
+        value: "/"
+      method: GET
+    backendRefs:
+    - name: "app-svc"
+      port: 8080
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package hmux
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var errGlobSegment = errors.New(`pattern glob contains ** within a segment`)
+
+// parseGlob parses the glob portion of a pattern: the segments following the
+// first segment containing *.
+func parseGlob(s string) ([]string, error) {
+	glob := strings.Split(s, "/")
+	for i, g := range glob {
+		if g == "" {
+			return nil, errors.New("pattern glob ends with /")
+		}
+		if strings.Contains(g, "**") && g != "**" {
+			return nil, errGlobSegment
+		}
+		if g[0] == ':' {
+			return nil, errors.New("pattern glob contains a parameter")
+		}
+		var err error
+		if glob[i], err = url.PathUnescape(g); err != nil {
+			return nil, err
+		}
+	}
+	return glob, nil
+}
+
+// matchGlob reports whether the path segments parts match glob.
+func matchGlob(glob, parts []string, opts matchOpts) bool {
+	gm := globMatcher{glob: glob, parts: parts, opts: opts}
+	return gm.match(0, 0)
+}
+
+// A globMatcher matches the path segments parts against glob.
+type globMatcher struct {
+	glob  []string
+	parts []string
+	opts  matchOpts
+	// failed records, for each pair of positions in glob and parts, whether
+	// the ** at that glob position failed to match the parts from that
+	// position on. Without it, a glob with several ** segments could take
+	// exponential time to reject a long path.
+	failed []bool
+}
+
+// match reports whether glob[gi:] matches parts[pi:].
+func (gm *globMatcher) match(gi, pi int) bool {
+	for gi < len(gm.glob) && gm.glob[gi] != "**" {
+		if pi == len(gm.parts) || !matchGlobSegment(gm.glob[gi], gm.parts[pi], gm.opts) {
+			return false
+		}
+		gi++
+		pi++
+	}
+	if gi == len(gm.glob) {
+		return pi == len(gm.parts)
+	}
+	// glob[gi] is **, which matches any number of segments.
+	if gm.failed == nil {
+		gm.failed = make([]bool, len(gm.glob)*(len(gm.parts)+1))
+	}
+	k := gi*(len(gm.parts)+1) + pi
+	if gm.failed[k] {
+		return false
+	}
+	for i := pi; i <= len(gm.parts); i++ {
+		if gm.match(gi+1, i) {
+			return true
+		}
+	}
+	gm.failed[k] = true
+	return false
+}
+
+// matchGlobSegment reports whether the path segment s matches the segment
+// glob g, in which each * matches any (possibly empty) sequence of
+// characters. The empty segment matches nothing.
+func matchGlobSegment(g, s string, opts matchOpts) bool {
+	return s != "" && matchSegmentGlob(g, s, opts)
+}
+
+// matchSegmentGlob is like matchGlobSegment but matches the empty segment
+// as well.
+func matchSegmentGlob(g, s string, opts matchOpts) bool {
+	if strings.IndexByte(g, '*') < 0 {
+		return literalEqual(s, g, opts)
+	}
+	// On a mismatch, only the most recent * needs to be retried (matching
+	// one more byte): since a * matches anything, a match found by
+	// extending an earlier * could also be found by extending the later
+	// one. So the running time is O(len(g)*len(s)).
+	gi, si := 0, 0
+	star, starS := -1, 0
+	for si < len(s) {
+		switch {
+		case gi < len(g) && g[gi] == '*':
+			star, starS = gi, si
+			gi++
+		case gi < len(g) && byteEqual(g[gi], s[si], opts):
+			gi++
+			si++
+		case star >= 0:
+			starS++
+			gi, si = star+1, starS
+		default:
+			return false
+		}
+	}
+	for gi < len(g) && g[gi] == '*' {
+		gi++
+	}
+	return gi == len(g)
+}
+
+// byteEqual reports whether the bytes a and b are equal, ignoring ASCII case
+// if opts calls for case folding.
+func byteEqual(a, b byte, opts matchOpts) bool {
+	return a == b || opts&optFoldCase != 0 && lowerASCII(a) == lowerASCII(b)
+}
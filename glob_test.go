@@ -0,0 +1,93 @@
+package hmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/assets/*.css", testHandler("css %s", "*"))
+	b.Get("/assets/**/*.js", testHandler("js %s", "*"))
+	b.Get("/assets/*", testHandler("asset %s", "*"))
+	b.Get("/assets/img/*", testHandler("img %s", "*"))
+	b.Get("/docs/v*/**", testHandler("versioned docs %s", "*"))
+	b.Get("/:page/*.txt", testHandler("text %s %s", "page", "*"))
+	b.Get("/*", testHandler("spa %s", "*"))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/assets/site.css", "css /site.css"},
+		{"GET", "/assets/.css", "css /.css"},
+		{"GET", "/assets/a/site.css", "asset /a/site.css"},
+		{"GET", "/assets/site.css.map", "asset /site.css.map"},
+		{"GET", "/assets/app.js", "js /app.js"},
+		{"GET", "/assets/lib/x/y.js", "js /lib/x/y.js"},
+		{"GET", "/assets/lib/x/", "asset /lib/x"},
+		{"GET", "/assets/img/a.png", "img /a.png"},
+		{"GET", "/assets/img/a.js", "img /a.js"},
+		{"GET", "/docs/v2/intro", "versioned docs /v2/intro"},
+		{"GET", "/docs/v2", "versioned docs /v2"},
+		{"GET", "/docs/latest", "spa /docs/latest"},
+		{"GET", "/help/a.txt", "text help /a.txt"},
+		{"GET", "/help/a.txt/", "spa /help/a.txt"},
+		{"GET", "/other/page", "spa /other/page"},
+	})
+
+	b.CaseInsensitive(true)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/ASSETS/SITE.CSS", "css /SITE.CSS"},
+	})
+}
+
+func TestGlobConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/a/*.css", testHandler("a"))
+	b.Get("/a/*", testHandler("b"))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering an equivalent glob pattern did not panic")
+		}
+	}()
+	b.Get("/a/*.css", testHandler("c"))
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, tt := range []struct {
+		glob  string
+		path  string
+		opts  matchOpts
+		match bool
+	}{
+		{"*.css", "site.css", 0, true},
+		{"*.css", "site.js", 0, false},
+		{"*.css", "", 0, false},
+		{"a*b*c", "abc", 0, true},
+		{"a*b*c", "aXbYbZc", 0, true},
+		{"a*b*c", "aXbYc/d", 0, false},
+		{"a*b*c", "acb", 0, false},
+		{"*a*", "bab", 0, true},
+		{"**", "", 0, true},
+		{"**", "a/b", 0, true},
+		{"**/x", "x", 0, true},
+		{"**/x", "a/b/x", 0, true},
+		{"**/x", "a/x/b", 0, false},
+		{"a/**/b/**/c", "a/b/c", 0, true},
+		{"a/**/b/**/c", "a/1/b/2/3/c", 0, true},
+		{"a/**/b/**/c", "a/1/c/2/b", 0, false},
+		{"*.CSS", "site.css", 0, false},
+		{"*.CSS", "site.css", optFoldCase, true},
+		// These take exponential time with naive backtracking.
+		{"a*a*a*a*a*a*a*a*a*a*b", strings.Repeat("a", 100), 0, false},
+		{"**/**/**/**/**/**/**/**/**/**/x", strings.Repeat("a/", 100) + "b", 0, false},
+		{"**/a*a*a*a*a*b/**/**/**/**/**/x", strings.Repeat(strings.Repeat("a", 30)+"/", 50) + "b", 0, false},
+	} {
+		var parts []string
+		if tt.path != "" {
+			parts = strings.Split(tt.path, "/")
+		}
+		if got := matchGlob(strings.Split(tt.glob, "/"), parts, tt.opts); got != tt.match {
+			t.Errorf("matchGlob(%q, %q, %d): got %t; want %t", tt.glob, tt.path, tt.opts, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Group calls f with a Builder g which registers rules with b, adding prefix
+// to the beginning of the pattern of each rule and applying opts (before the
+// options given for the rule itself) to each rule:
+//
+//	b.Group("/api/v1", func(g *hmux.Builder) {
+//		g.Get("/users/:id", getUser)   // GET /api/v1/users/:id
+//		g.Prefix("/static", static)    // /api/v1/static
+//	}, hmux.Middleware(requireAuth))
+//
+// The rules are ordinary rules of b: they are listed by Mux.Routes, and a
+// rule registered with g which conflicts with another rule of b causes the
+// same panic as if it had been registered with b directly.
+//
+// The prefix must be a pattern which begins with a slash and does not end
+// with a wildcard; a trailing slash is ignored. It may contain parameters,
+// which are captured along with those of each rule's pattern. Groups may be
+// nested, in which case their prefixes and options accumulate. The special
+// patterns "" and "*" cannot be registered with g.
+//
+// g is only for registering rules, which may be done using any of the
+// methods that take a pattern, such as Get, Prefix, and ServeFile. Parameter
+// types and fragments defined on b (see RegisterParamType and DefinePattern)
+// are available to the patterns registered with g, and defining them on g
+// defines them on b. The methods which configure or build the Mux as a whole,
+// such as OnMatch, CaseInsensitive, ServeRobots, and Build, panic if called
+// on g.
+func (b *Builder) Group(prefix string, f func(g *Builder), opts ...RuleOption) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		panic("hmux: Group called with empty prefix")
+	}
+	if _, p, err := b.parsePattern(prefix); err != nil {
+		panic(fmt.Sprintf("hmux: invalid group prefix %q: %s", prefix, err))
+	} else if p.opt != patOther {
+		panic(fmt.Sprintf("hmux: invalid group prefix %q", prefix))
+	}
+	g := &Builder{group: &group{
+		parent: b,
+		prefix: prefix,
+		opts:   append([]RuleOption(nil), opts...),
+	}}
+	f(g)
+}
+
+// A group holds the state of a Builder created by Group.
+type group struct {
+	parent *Builder
+	prefix string
+	opts   []RuleOption
+}
+
+func (g *group) parsePattern(pat string) (string, pattern, error) {
+	if pat == "" || pat == "*" {
+		return "", pattern{}, fmt.Errorf("special pattern %q registered in a group", pat)
+	}
+	if !strings.HasPrefix(pat, "/") {
+		return "", pattern{}, errPatternWithoutSlash
+	}
+	return g.parent.parsePattern(g.prefix + pat)
+}
+
+func (g *group) addHandler(method, pat string, p pattern, h http.Handler, opts []RuleOption) error {
+	allOpts := append(append([]RuleOption(nil), g.opts...), opts...)
+	return g.parent.addHandler(method, pat, p, h, allOpts)
+}
+
+// checkNotGroup panics if b was created by Group. It is called by the methods
+// which configure or build the Mux as a whole.
+func (b *Builder) checkNotGroup(method string) {
+	if b.group != nil {
+		panic(fmt.Sprintf("hmux: %s called on a group Builder", method))
+	}
+}
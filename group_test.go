@@ -0,0 +1,181 @@
+package hmux
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/", testHandler("home"))
+	tag := func(s string) RuleOption {
+		return Middleware(func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(s))
+				h.ServeHTTP(w, r)
+			})
+		})
+	}
+	b.Group("/api/v1/", func(g *Builder) {
+		g.Get("/users/:id", testHandler("user %s", "id"))
+		g.Get("/", testHandler("index"))
+		g.Prefix("/static", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("static " + r.URL.Path))
+		}))
+		g.Group("/teams/:team", func(g *Builder) {
+			g.Get("/members/:id", testHandler("team %s member %s", "team", "id"), tag("[inner]"))
+		}, tag("[team]"))
+	}, tag("[v1]"))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/", "home"},
+		{"GET", "/api/v1/users/3", "[v1]user 3"},
+		{"GET", "/api/v1/", "[v1]index"},
+		{"GET", "/api/v1/static/a.css", "[v1]static /a.css"},
+		{"GET", "/api/v1/teams/x/members/4", "[v1][team][inner]team x member 4"},
+		{"GET", "/users/3", "404"},
+	})
+
+	var pats []string
+	for _, rt := range mux.Routes() {
+		pats = append(pats, rt.Pattern)
+	}
+	want := map[string]bool{
+		"/":                               true,
+		"/api/v1/users/:id":               true,
+		"/api/v1/":                        true,
+		"/api/v1/static":                  true,
+		"/api/v1/teams/:team/members/:id": true,
+	}
+	if len(pats) != len(want) {
+		t.Fatalf("got routes %q", pats)
+	}
+	for _, pat := range pats {
+		if !want[pat] {
+			t.Errorf("unexpected route pattern %q", pat)
+		}
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/api/users", testHandler(""))
+	for _, tt := range []struct {
+		name string
+		f    func()
+	}{
+		{"empty prefix", func() { b.Group("", func(*Builder) {}) }},
+		{"wildcard prefix", func() { b.Group("/api/*", func(*Builder) {}) }},
+		{"bad prefix", func() { b.Group("api", func(*Builder) {}) }},
+		{"conflict", func() { b.Group("/api", func(g *Builder) { g.Get("/users", testHandler("")) }) }},
+		{"special", func() { b.Group("/api", func(g *Builder) { g.Get("", testHandler("")) }) }},
+		{"duplicate param", func() { b.Group("/t/:id", func(g *Builder) { g.Get("/x/:id", testHandler("")) }) }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", tt.name)
+				}
+			}()
+			tt.f()
+		}()
+	}
+
+	// Failed atomic registrations in a group are undone in b.
+	b.Group("/api", func(g *Builder) {
+		if err := g.TryHandlePatterns("GET", []string{"/new", "/users"}, testHandler("")); err == nil {
+			t.Error("TryHandlePatterns with conflicting pattern succeeded")
+		}
+	})
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/api/new", "404"},
+	})
+}
+
+func TestGroupDefinitions(t *testing.T) {
+	b := NewBuilder()
+	b.Group("/api", func(g *Builder) {
+		g.DefinePattern("user", "/users/:id:int64")
+		g.RegisterParamType("upper", func(s string) (interface{}, error) {
+			if strings.ToUpper(s) != s {
+				return nil, errors.New("not upper case")
+			}
+			return s, nil
+		})
+		g.Get("/{user}", testHandler("user %s", "id"))
+		g.Get("/codes/:code:upper", testHandler("code %s", "code"))
+	})
+	// Definitions made on the group are made on b.
+	b.Get("/{user}/posts", testHandler("posts %s", "id"))
+	b.Get("/tags/:tag:upper", testHandler("tag %s", "tag"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/api/users/3", "user 3"},
+		{"GET", "/api/users/x", "404"},
+		{"GET", "/api/codes/AB", "code AB"},
+		{"GET", "/api/codes/ab", "404"},
+		{"GET", "/users/3/posts", "posts 3"},
+		{"GET", "/tags/GO", "tag GO"},
+		{"GET", "/tags/go", "404"},
+	})
+}
+
+func TestGroupMuxSettings(t *testing.T) {
+	nop := func(*http.Request) {}
+	for _, tt := range []struct {
+		name string
+		f    func(g *Builder)
+	}{
+		{"Normalize", func(g *Builder) { g.Normalize(func(r *http.Request) *http.Request { return r }) }},
+		{"OnMatch", func(g *Builder) { g.OnMatch(func(*http.Request, *Match) {}) }},
+		{"CaseInsensitive", func(g *Builder) { g.CaseInsensitive(true) }},
+		{"ServeMuxCompatible", func(g *Builder) { g.ServeMuxCompatible(true) }},
+		{"Build", func(g *Builder) { g.Build() }},
+		{"BuildWith", func(g *Builder) { g.BuildWith(NewBuilder()) }},
+		{"BuildChecked", func(g *Builder) { g.BuildChecked() }},
+		{"CanonicalBase", func(g *Builder) { g.CanonicalBase("https://example.com") }},
+		{"RedirectCase", func(g *Builder) { g.RedirectCase(true) }},
+		{"CheckResponses", func(g *Builder) { g.CheckResponses(func(*http.Request, Route, error) {}) }},
+		{"CheckCORS", func(g *Builder) { g.CheckCORS(func(*http.Request, error) {}) }},
+		{"DescribeMethods", func(g *Builder) { g.DescribeMethods(true) }},
+		{"SetEncodedSlashPolicy", func(g *Builder) { g.SetEncodedSlashPolicy(EncodedSlashKeep) }},
+		{"FallBackOnInvalidRawPath", func(g *Builder) { g.FallBackOnInvalidRawPath(nop) }},
+		{"MatrixParams", func(g *Builder) { g.MatrixParams(true) }},
+		{"ReportNearDuplicates", func(g *Builder) { g.ReportNearDuplicates(func(Route, Route, string) {}) }},
+		{"RawPathMatching", func(g *Builder) { g.RawPathMatching(true) }},
+		{"NormalizeSegments", func(g *Builder) { g.NormalizeSegments(strings.ToLower) }},
+		{"OptionalTrailingSlashes", func(g *Builder) { g.OptionalTrailingSlashes(true) }},
+		{"RedirectTrailingSlash", func(g *Builder) { g.RedirectTrailingSlash(true) }},
+		{"SetTargetAction", func(g *Builder) { g.SetTargetAction(EmptyPath, TargetReject) }},
+		{"ServeRobots", func(g *Builder) { g.ServeRobots() }},
+	} {
+		b := NewBuilder()
+		func() {
+			defer func() {
+				want := "hmux: " + tt.name + " called on a group Builder"
+				if got := recover(); got != want {
+					t.Errorf("%s: got panic %v; want %q", tt.name, got, want)
+				}
+			}()
+			b.Group("/g", tt.f)
+		}()
+	}
+
+	b := NewBuilder()
+	b.Group("/g", func(g *Builder) {
+		if err := b.Merge(g); err == nil {
+			t.Error("Merge with a group Builder succeeded")
+		}
+		if err := g.Merge(b); err == nil {
+			t.Error("Merge on a group Builder succeeded")
+		}
+		defer func() {
+			if recover() == nil {
+				t.Error("BuildWith with a group Builder did not panic")
+			}
+		}()
+		b.BuildWith(g)
+	})
+}
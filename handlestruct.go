@@ -0,0 +1,80 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var handlerType = reflect.TypeOf((*http.Handler)(nil)).Elem()
+
+// HandleStruct registers a rule for each field of the struct v (or the struct
+// pointed to by v) that has a "route" tag. The tag gives a method and a
+// pattern separated by a space, or just a pattern to match all methods, and
+// the field holds the handler:
+//
+//	type userRoutes struct {
+//		List   http.HandlerFunc `route:"GET /users"`
+//		Show   http.HandlerFunc `route:"GET /users/:id:int64"`
+//		Static http.Handler     `route:"/users/static/*"`
+//	}
+//
+//	b.HandleStruct(&userRoutes{
+//		List:   s.listUsers,
+//		Show:   s.showUser,
+//		Static: http.FileServer(http.Dir("static")),
+//	})
+//
+// Tagged fields must be exported and must have a type that implements
+// http.Handler or has the underlying type
+// func(http.ResponseWriter, *http.Request). HandleStruct panics if a tagged
+// field is nil. The options apply to every rule.
+func (b *Builder) HandleStruct(v interface{}, opts ...RuleOption) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("hmux: HandleStruct called with non-struct type %T", v))
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, ok := f.Tag.Lookup("route")
+		if !ok {
+			continue
+		}
+		if f.PkgPath != "" {
+			panic(fmt.Sprintf("hmux: HandleStruct: tagged field %s is unexported", f.Name))
+		}
+		h, err := fieldHandler(f.Name, rv.Field(i))
+		if err != nil {
+			panic("hmux: HandleStruct: " + err.Error())
+		}
+		method, pat := "", strings.TrimSpace(tag)
+		if i := strings.IndexByte(pat, ' '); i >= 0 {
+			method, pat = pat[:i], strings.TrimSpace(pat[i+1:])
+		}
+		b.Handle(method, pat, h, opts...)
+	}
+}
+
+// fieldHandler returns the handler held by fv, the value of the struct field
+// with the given name.
+func fieldHandler(name string, fv reflect.Value) (http.Handler, error) {
+	switch fv.Kind() {
+	case reflect.Func, reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan:
+		if fv.IsNil() {
+			return nil, fmt.Errorf("field %s is nil", name)
+		}
+	}
+	if fv.Type().Implements(handlerType) {
+		return fv.Interface().(http.Handler), nil
+	}
+	var hf http.HandlerFunc
+	if fv.Type().ConvertibleTo(reflect.TypeOf(hf)) {
+		return fv.Convert(reflect.TypeOf(hf)).Interface().(http.HandlerFunc), nil
+	}
+	return nil, fmt.Errorf("field %s has type %s which is not a handler", name, fv.Type())
+}
@@ -0,0 +1,55 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandleStruct(t *testing.T) {
+	type routes struct {
+		List   http.HandlerFunc                         `route:"GET /users"`
+		Show   func(http.ResponseWriter, *http.Request) `route:"GET  /users/:id:int64"`
+		Any    http.Handler                             `route:"/users/any"`
+		Ignore http.HandlerFunc
+	}
+	b := NewBuilder()
+	b.HandleStruct(&routes{
+		List: testHandler("list"),
+		Show: testHandler("show %d", "id:int64"),
+		Any:  testHandler("any"),
+	})
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/users", "list"},
+		{"GET", "/users/3", "show 3"},
+		{"POST", "/users/any", "any"},
+		{"POST", "/users", "405 GET"},
+	})
+
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{3, "non-struct"},
+		{struct {
+			X http.HandlerFunc `route:"GET /x"`
+		}{}, "field X is nil"},
+		{struct {
+			X string `route:"GET /x"`
+		}{"x"}, "field X has type string which is not a handler"},
+		{struct {
+			x http.HandlerFunc `route:"GET /x"`
+		}{testHandler("")}, "tagged field x is unexported"},
+	} {
+		var got string
+		func() {
+			defer func() {
+				got, _ = recover().(string)
+			}()
+			NewBuilder().HandleStruct(tt.v)
+		}()
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("HandleStruct(%#v): got panic %q; want substring %q", tt.v, got, tt.want)
+		}
+	}
+}
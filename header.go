@@ -0,0 +1,53 @@
+package hmux
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Header returns a RuleOption which restricts a rule to requests having the
+// header name with the given value (among any other values of the same
+// header). If value is empty, the header need only be present, with any
+// value. For example, to serve WebSocket connections and ordinary requests
+// for the same path with different handlers:
+//
+//	b.Get("/events", serveEventsWebSocket, hmux.Header("Upgrade", "websocket"))
+//	b.Get("/events", serveEventsJSON)
+//
+// The header name is case-insensitive but the value is compared exactly.
+//
+// The header restriction is a condition (see Conditions): if the request's
+// headers do not match, the Mux continues looking for a matching rule.
+func Header(name, value string) RuleOption {
+	if name == "" {
+		panic("hmux: Header called with empty name")
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, headerCond{http.CanonicalHeaderKey(name), value})
+	}
+}
+
+type headerCond struct {
+	name  string // canonical
+	value string
+}
+
+func (c headerCond) check(r *http.Request, _ *rule, _ *Params) int {
+	vals, ok := r.Header[c.name]
+	if !ok {
+		return condSkip
+	}
+	if c.value == "" {
+		return condOK
+	}
+	for _, v := range vals {
+		if v == c.value {
+			return condOK
+		}
+	}
+	return condSkip
+}
+
+func (c headerCond) key() string {
+	return "header:" + c.name + "=" + url.QueryEscape(c.value)
+}
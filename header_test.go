@@ -0,0 +1,51 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/events", testHandler("websocket"), Header("upgrade", "websocket"))
+	b.Get("/events", testHandler("json"))
+	b.Get("/events", testHandler("traced websocket"), Header("Upgrade", "websocket"), Header("X-Trace", ""))
+	b.Get("/internal", testHandler("internal"), Header("X-Internal", ""))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		path   string
+		header http.Header
+		want   string
+	}{
+		{"/events", nil, "json"},
+		{"/events", http.Header{"Upgrade": {"websocket"}}, "websocket"},
+		{"/events", http.Header{"Upgrade": {"h2c"}}, "json"},
+		{"/events", http.Header{"Upgrade": {"h2c", "websocket"}}, "websocket"},
+		{"/events", http.Header{"Upgrade": {"websocket"}, "X-Trace": {""}}, "traced websocket"},
+		{"/internal", http.Header{"X-Internal": {"1"}}, "internal"},
+		{"/internal", nil, "404 page not found\n"},
+	} {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		for k, v := range tt.header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("GET %s with header %v: got %q; want %q", tt.path, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler("a"), Header("X-A", "1"))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule with the same header restriction did not panic")
+		}
+	}()
+	b.Get("/x", testHandler("b"), Header("x-a", "1"))
+}
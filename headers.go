@@ -0,0 +1,156 @@
+package hmux
+
+import (
+	"net/http"
+)
+
+// A HeaderTransform describes a set of changes to HTTP headers. The changes
+// are applied in the order of the fields: first Rename, then Remove, Set, and
+// Add.
+type HeaderTransform struct {
+	// Rename maps header names to new names. The values of each renamed
+	// header are moved to the new name, replacing any existing values.
+	Rename map[string]string
+	// Remove lists headers to delete.
+	Remove []string
+	// Set maps header names to values which replace any existing values.
+	Set map[string]string
+	// Add maps header names to values which are appended to any existing
+	// values.
+	Add map[string]string
+}
+
+func (t HeaderTransform) apply(h http.Header) {
+	for from, to := range t.Rename {
+		if vs, ok := h[http.CanonicalHeaderKey(from)]; ok {
+			h.Del(from)
+			h[http.CanonicalHeaderKey(to)] = vs
+		}
+	}
+	for _, k := range t.Remove {
+		h.Del(k)
+	}
+	for k, v := range t.Set {
+		h.Set(k, v)
+	}
+	for k, v := range t.Add {
+		h.Add(k, v)
+	}
+}
+
+// These headers are not modified by HeaderTransforms applied to rules marked
+// using RangeRequests.
+var (
+	rangeRequestHeaders  = []string{"Range", "If-Range"}
+	rangeResponseHeaders = []string{"Accept-Ranges", "Content-Range"}
+)
+
+// applyKeeping is like apply but leaves the headers named by keep (which must
+// be in canonical form) unchanged.
+func (t HeaderTransform) applyKeeping(h http.Header, keep []string) {
+	if len(keep) == 0 {
+		t.apply(h)
+		return
+	}
+	saved := make(map[string][]string, len(keep))
+	for _, k := range keep {
+		if vs, ok := h[k]; ok {
+			saved[k] = vs
+		}
+	}
+	t.apply(h)
+	for _, k := range keep {
+		if vs, ok := saved[k]; ok {
+			h[k] = vs
+		} else {
+			delete(h, k)
+		}
+	}
+}
+
+// RequestHeaders returns a RuleOption which applies t to the headers of each
+// request matched by the rule before the request is passed to the handler.
+// The Mux does not modify the original request's headers; the handler
+// receives a copy. If the rule is marked using RangeRequests, the Range and
+// If-Range headers are passed through unchanged.
+func RequestHeaders(t HeaderTransform) RuleOption {
+	return func(rl *rule) {
+		rl.mws = append(rl.mws, func(h http.Handler) http.Handler {
+			var keep []string
+			if rl.ranges {
+				keep = rangeRequestHeaders
+			}
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r1 := new(http.Request)
+				*r1 = *r
+				r1.Header = r.Header.Clone()
+				if r1.Header == nil {
+					r1.Header = make(http.Header)
+				}
+				t.applyKeeping(r1.Header, keep)
+				h.ServeHTTP(w, r1)
+			})
+		})
+	}
+}
+
+// ResponseHeaders returns a RuleOption which applies t to the headers of each
+// response written by the rule's handler. The transformation is applied when
+// the handler writes the response status (explicitly or implicitly by
+// writing the body). If the rule is marked using RangeRequests, the
+// Accept-Ranges and Content-Range headers written by the handler are
+// preserved.
+func ResponseHeaders(t HeaderTransform) RuleOption {
+	return func(rl *rule) {
+		rl.mws = append(rl.mws, func(h http.Handler) http.Handler {
+			var keep []string
+			if rl.ranges {
+				keep = rangeResponseHeaders
+			}
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hw := &headerWriter{ResponseWriter: w, t: t, keep: keep}
+				h.ServeHTTP(hw, r)
+				// Handle handlers that don't write anything.
+				hw.applyOnce()
+			})
+		})
+	}
+}
+
+// A headerWriter is an http.ResponseWriter which applies a HeaderTransform
+// just before the header is written.
+type headerWriter struct {
+	http.ResponseWriter
+	t       HeaderTransform
+	keep    []string
+	applied bool
+}
+
+func (w *headerWriter) applyOnce() {
+	if !w.applied {
+		w.applied = true
+		w.t.applyKeeping(w.ResponseWriter.Header(), w.keep)
+	}
+}
+
+func (w *headerWriter) WriteHeader(code int) {
+	w.applyOnce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	w.applyOnce()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerWriter) Flush() {
+	w.applyOnce()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *headerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,99 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHeaderTransforms(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "secret")
+		fmt.Fprintf(w, "%s|%s|%s|%s", r.Header.Get("X-A"), r.Header.Get("X-B"), r.Header.Get("X-C"), r.Header.Get("X-D"))
+	},
+		RequestHeaders(HeaderTransform{
+			Rename: map[string]string{"x-old": "X-A"},
+			Remove: []string{"X-B"},
+			Set:    map[string]string{"X-C": "set"},
+			Add:    map[string]string{"X-D": "added"},
+		}),
+		ResponseHeaders(HeaderTransform{
+			Remove: []string{"Server"},
+			Set:    map[string]string{"X-Frame-Options": "DENY"},
+		}),
+	)
+	b.Get("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Internal", "1")
+	}, ResponseHeaders(HeaderTransform{
+		Rename: map[string]string{"X-Internal": "X-Public"},
+	}))
+	mux := b.Build()
+
+	r := httptest.NewRequest("GET", "/x", nil)
+	r.Header.Set("X-Old", "renamed")
+	r.Header.Set("X-B", "removed")
+	r.Header.Set("X-C", "replaced")
+	r.Header.Set("X-D", "orig")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if got, want := w.Body.String(), "renamed||set|orig"; got != want {
+		t.Errorf("got body %q; want %q", got, want)
+	}
+	if got := r.Header.Get("X-B"); got != "removed" {
+		t.Errorf("original request header was modified")
+	}
+	wantHeader := http.Header{
+		"Content-Type":    {"text/plain; charset=utf-8"},
+		"X-Frame-Options": {"DENY"},
+	}
+	if got := w.Result().Header; !reflect.DeepEqual(got, wantHeader) {
+		t.Errorf("got response header %v; want %v", got, wantHeader)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/empty", nil))
+	if got := w.Result().Header.Get("X-Public"); got != "1" {
+		t.Errorf("got X-Public=%q; want 1", got)
+	}
+}
+
+func TestHeaderTransformsRangeRequests(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/video", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Range", "bytes 0-9/100")
+		w.Header().Set("X-Upstream", "1")
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, r.Header.Get("Range"))
+	},
+		ResponseHeaders(HeaderTransform{
+			Remove: []string{"Accept-Ranges", "Content-Range", "X-Upstream"},
+		}),
+		RequestHeaders(HeaderTransform{
+			Remove: []string{"Range"},
+		}),
+		RangeRequests(),
+	)
+	mux := b.Build()
+
+	r := httptest.NewRequest("GET", "/video", nil)
+	r.Header.Set("Range", "bytes=0-9")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if got, want := w.Body.String(), "bytes=0-9"; got != want {
+		t.Errorf("got body %q; want %q", got, want)
+	}
+	h := w.Result().Header
+	if got := h.Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("got Accept-Ranges=%q; want bytes", got)
+	}
+	if got := h.Get("Content-Range"); got != "bytes 0-9/100" {
+		t.Errorf("got Content-Range=%q; want bytes 0-9/100", got)
+	}
+	if got := h.Get("X-Upstream"); got != "" {
+		t.Errorf("got X-Upstream=%q; want it removed", got)
+	}
+}
@@ -53,25 +53,53 @@
 // and Builder.ServeFS, which always treat their inputs as wildcard patterns
 // even if they don't have the ending *.
 //
+// The remainder of the path matched by a wildcard may be constrained with a
+// glob, which begins with the first segment containing * other than a final
+// plain *. Within a glob, a segment ** matches any number of path segments
+// and, in other segments, * matches any sequence of characters within one
+// segment:
+//
+//	b.Get("/assets/*.css", serveCSS)      // /assets/site.css
+//	b.Get("/assets/**/*.js", serveJS)     // /assets/app.js, /assets/lib/x/y.js
+//	b.Get("/assets/*", serveOtherAssets)  // anything else under /assets/
+//
+// Glob patterns do not match paths ending with a slash. A wildcard pattern
+// with a glob is more specific than the same wildcard pattern without one
+// (see Routing); avoid registering otherwise equivalent patterns with
+// different globs which match the same paths, since which of them matches is
+// unspecified.
+//
 // There are two special patterns which don't begin with a slash: "*" and "".
 //
 // The pattern "*" matches (only) the request URL "*". This is typically used
-// with OPTIONS requests.
+// with OPTIONS requests:
+//
+//	b.Options("*", h)
 //
 // The empty pattern ("") matches any request URL.
 //
-// A Builder does not accept two rules with overlapping methods and the same
-// pattern.
+// A Builder does not accept two rules with the same method (or two rules for
+// all methods) and equivalent patterns: that is, patterns that are identical
+// apart from parameter names.
+//
+//	b.Get("/x/:one", h1)
+//	b.Get("/x/:two", h2) // panic: conflicts with previously registered pattern "/x/:one"
+//
+// Patterns with parameters of different types are not equivalent, so these
+// rules may be registered together (see Routing for their precedence), as may
+// a rule for a specific method and a rule for all methods:
 //
-//	b.Handle("", "/x/:one", h1)
-//	b.Get("/x/:two", h2) // panic: pattern is already registered for all methods
+//	b.Get("/x/:id:int32", h1)
+//	b.Get("/x/:id:int64", h2)
+//	b.Get("/x/:name", h3)
+//	b.Any("/x/:name", h4)
 //
-// To avoid confusion, apart from wildcard patterns and the special pattern "*",
-// asterisks are not allowed in patterns. Additionally, a pattern segment cannot
-// be empty.
+// To avoid confusion, apart from wildcards, globs, and the special pattern
+// "*", asterisks are not allowed in patterns. Additionally, a pattern segment
+// cannot be empty.
 //
-//	b.Get("/a*b", handler)  // panic: pattern contains *
-//	b.Get("/a//b", handler) // panic: pattern contains empty segment
+//	b.Get("/a/**b", handler) // panic: pattern glob contains ** within a segment
+//	b.Get("/a//b", handler)  // panic: pattern contains empty segment
 //
 // Literal pattern segments are interpeted as URL-escaped strings. Therefore, to
 // create a pattern which matches a path containing characters reserved for
@@ -93,8 +121,13 @@
 // least specific, are:
 //
 //   - literal ("/a")
+//   - enum parameter ("/:p:enum(a|b)")
 //   - int32 parameter ("/:p:int32")
 //   - int64 parameter ("/:p:int64")
+//   - rfc3339 parameter ("/:p:rfc3339")
+//   - hex parameter ("/:p:hex")
+//   - custom parameter ("/:p:ulid"; see Builder.RegisterParamType)
+//   - length-bounded string parameter ("/:p:string(1,64)")
 //   - string parameter ("/:p")
 //
 // For two patterns having the same segment specificity, a pattern ending with
@@ -106,7 +139,7 @@
 //	b.Get("/x/:p:int32", handlerB)
 //	b.Get("/x/:p", handlerC)
 //	b.Get("/:p/y", handlerD)
-//	b.Handle("", "/x/y", handlerE)
+//	b.Any("/x/y", handlerE)
 //
 // Requests are routed as follows:
 //
@@ -116,13 +149,33 @@
 //	GET /y/y   handlerD
 //	POST /x/y  handlerE
 //
+// The specificity ordering may be overridden for individual rules using
+// Priority.
+//
 // If a request matches the patterns of one or more rules but does not match the
 // methods of any of those rules, the Mux writes an HTTP 405 ("Method Not
 // Allowed") response with an Allow header that lists all of the matching
 // methods.
 //
 // If there is no matching rule pattern at all, the Mux writes an HTTP 404
-// ("Not Found") response.
+// ("Not Found") response (but see Builder.RedirectTrailingSlash and
+// Builder.RedirectCase).
+//
+// # Conditions
+//
+// Rule options such as Host, Query, Header, and Cookie attach conditions to a
+// rule, which a request must satisfy, in addition to matching the rule's
+// pattern and method, for the rule to serve it. A rule may be given several
+// conditions, all of which must be satisfied. If a request does not satisfy
+// them, the Mux ignores the rule and continues looking for a matching rule.
+// (Some conditions, such as those given by TLS and ClientCert, call for a
+// particular response if no other rule matches.)
+//
+// Rules with conditions take precedence over rules for the same pattern and
+// method with fewer conditions, which therefore serve as fallbacks. Among
+// rules with the same number of conditions, those registered earlier are
+// tried first. Registering a rule with the same pattern, method, and
+// conditions as a previously registered rule is an error.
 //
 // Before routing, if the request path contains any segment that is "" (that is,
 // a double slash), ".", or "..", the Mux writes an HTTP 308 redirect to an
@@ -134,6 +187,10 @@
 //
 // This automatic redirection does not apply to CONNECT requests.
 //
+// Requests whose targets have unusual forms, such as the absolute URLs sent
+// to proxies and the host:port targets of CONNECT requests, are handled as
+// described by Builder.SetTargetAction.
+//
 // # Parameters
 //
 // Pattern segments may specify a type after a second colon:
@@ -143,12 +200,56 @@
 // A string parameter matches any URL path segment, and it is also the default
 // type if no parameter type is given.
 //
+// A string parameter may be restricted to path segments whose length, in
+// characters (after unescaping), lies within inclusive bounds:
+//
+//	b.Get("/articles/:slug:string(1,64)", handleArticle)
+//
+// Among length-bounded string parameters, those with narrower bounds are more
+// specific.
+//
 // The other parameter types are int32 and int64. A pattern segment with an
 // integer type matches the corresponding request URL path segment if that
 // segment can be parsed as a decimal integer of that type.
 //
 //	b.Get("/inventory/:itemid:int64/price", handlePrice)
 //
+// A pattern segment with the rfc3339 type matches a request URL path segment
+// which can be parsed as an RFC 3339 timestamp, such as
+// "2006-01-02T15:04:05Z" or "2006-01-02T15:04:05.999+07:00". The parsed time
+// is available using Params.Time.
+//
+//	b.Get("/events/since/:ts:rfc3339", handleEvents)
+//
+// A pattern segment with an enum type matches a request URL path segment equal
+// to one of the listed values. The values are separated by |, and, as with
+// literal segments, they are interpreted as URL-escaped strings. Avoid
+// registering otherwise equivalent patterns whose enums share a value; which
+// of them matches that value is unspecified.
+//
+//	b.Get("/tickets/:state:enum(open|closed|merged)", handleTickets)
+//
+// A pattern segment with the hex type matches a request URL path segment
+// consisting of an even number of hexadecimal digits (of either case). The
+// decoded bytes are available using Params.Bytes.
+//
+//	b.Get("/blobs/:sha:hex", handleBlob)
+//
+// Additional parameter types may be defined using Builder.RegisterParamType.
+//
+// A parameter may be followed by a literal suffix beginning with a period,
+// such as a file extension. The parameter matches the rest of the path
+// segment, which must not be empty.
+//
+//	b.Get("/reports/:name.json", handleReportJSON)
+//	b.Get("/reports/:name.csv", handleReportCSV)
+//	b.Get("/invoices/:id:int64.pdf", handleInvoice)
+//
+// A request for /reports/q3.json is routed to handleReportJSON with the name
+// parameter "q3". A suffix follows the parameter type, if any, and a period in
+// a parameter name begins a suffix. When otherwise equivalent parameters have
+// different suffixes, the one with the longer suffix is more specific.
+//
 // Parameters are passed to HTTP handlers using http.Request.Context. Inside an
 // HTTP handler called by a Mux, parameters are available via RequestParams.
 //
@@ -165,15 +266,19 @@ package hmux
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
 	"net/url"
 	"path"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // A Builder constructs a Mux. Rules are added to the Builder by using Handle
@@ -187,7 +292,39 @@ import (
 // syntactically invalid or if the rule conflicts with any previously registered
 // rule.
 type Builder struct {
-	matchers []*matcher
+	matchers   []*matcher
+	paramTypes map[string]*customParamType
+	fragments  map[string]string // see DefinePattern
+	normalize  []func(*http.Request) *http.Request
+	onMatch    []func(*http.Request, *Match)
+	canonical  *url.URL
+	foldCase   bool
+	serveMux   bool
+	matrix     bool
+	rawPath    bool
+	normSeg    func(string) string // see NormalizeSegments
+	anySlash   bool                // see OptionalTrailingSlashes
+	targets    [numTargetForms]TargetAction
+
+	redirectSlash   bool
+	redirectCase    bool
+	describeMethods bool
+	encodedSlash    EncodedSlashPolicy
+	invalidRawPath  func(*http.Request)
+	checkResponses  func(*http.Request, Route, error)
+	checkCORS       func(*http.Request, error)
+	nearDuplicates  func(rt1, rt2 Route, reason string)
+	group           *group // for Builders created by Group
+
+	// names holds the named rules (see Name). nameLog lists the names in
+	// the order they were added, so that atomically can remove them.
+	names   map[string]*rule
+	nameLog []string
+	// inCall is set while atomically runs. callNames is the length of
+	// nameLog when the outermost call of atomically began: the rules
+	// registered by a single call may share a name.
+	inCall    bool
+	callNames int
 }
 
 // NewBuilder creates a new Builder.
@@ -196,47 +333,220 @@ func NewBuilder() *Builder {
 }
 
 // Get registers a handler for GET requests using the given path pattern.
-func (b *Builder) Get(pat string, h http.HandlerFunc) {
-	b.Handle(http.MethodGet, pat, h)
+func (b *Builder) Get(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle(http.MethodGet, pat, h, opts...)
 }
 
 // Post registers a handler for POST requests using the given path pattern.
-func (b *Builder) Post(pat string, h http.HandlerFunc) {
-	b.Handle(http.MethodPost, pat, h)
+func (b *Builder) Post(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle(http.MethodPost, pat, h, opts...)
 }
 
 // Put registers a handler for PUT requests using the given path pattern.
-func (b *Builder) Put(pat string, h http.HandlerFunc) {
-	b.Handle(http.MethodPut, pat, h)
+func (b *Builder) Put(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle(http.MethodPut, pat, h, opts...)
 }
 
 // Delete registers a handler for DELETE requests using the given path pattern.
-func (b *Builder) Delete(pat string, h http.HandlerFunc) {
-	b.Handle(http.MethodDelete, pat, h)
+func (b *Builder) Delete(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle(http.MethodDelete, pat, h, opts...)
 }
 
 // Head registers a handler for HEAD requests using the given path pattern.
-func (b *Builder) Head(pat string, h http.HandlerFunc) {
-	b.Handle(http.MethodHead, pat, h)
+func (b *Builder) Head(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle(http.MethodHead, pat, h, opts...)
+}
+
+// Options registers a handler for OPTIONS requests using the given path
+// pattern. Use the pattern "*" to handle server-wide requests such as
+// "OPTIONS * HTTP/1.1", or the pattern "" to handle OPTIONS requests for every
+// path (for example, to answer CORS preflight requests).
+func (b *Builder) Options(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle(http.MethodOptions, pat, h, opts...)
+}
+
+// Connect registers a handler for CONNECT requests. In addition to the usual
+// path patterns, pat may be an authority pattern of the form host:port which
+// matches only CONNECT requests with authority-form request targets (such as
+// "CONNECT db.internal:5432 HTTP/1.1", as sent to proxies). The host is a
+// host pattern, as for Host, or * to match any host, and the port is a port
+// number or * to match any port:
+//
+//	b.Connect("*.internal:5432", tunnelDB)
+//	b.Connect(":region.cache.example.com:*", tunnelCache)
+//	b.Connect("", denyTunnel)
+//
+// Rules registered with authority patterns act as if they were registered with
+// the pattern "" along with conditions on the target (see Conditions), so they
+// take precedence over a rule registered with the pattern "" itself, which
+// serves as a fallback for other targets. Such rules do not match if
+// authority-form requests are rejected using SetTargetAction.
+func (b *Builder) Connect(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	if isAuthorityPattern(pat) {
+		opts = append([]RuleOption{authorityPattern(pat)}, opts...)
+		pat = ""
+	}
+	b.Handle(http.MethodConnect, pat, h, opts...)
+}
+
+// Trace registers a handler for TRACE requests using the given path pattern.
+// EchoTrace is a suitable handler which omits credentials from its responses.
+//
+// Without a TRACE rule matching its path, a TRACE request is answered by a
+// matching rule for all methods (see Any), if there is one; otherwise, the
+// Mux responds with a 404 or 405 as usual. Handlers registered for all
+// methods which should not answer TRACE requests must check the method.
+func (b *Builder) Trace(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle(http.MethodTrace, pat, h, opts...)
+}
+
+// Any registers a handler for all HTTP methods using the given path pattern.
+// It is equivalent to calling Handle with an empty method. Rules for specific
+// methods take precedence over rules for all methods with equivalent patterns
+// (see Routing).
+func (b *Builder) Any(pat string, h http.HandlerFunc, opts ...RuleOption) {
+	b.Handle("", pat, h, opts...)
 }
 
 // Handle registers a handler for the given HTTP method and path pattern.
 // If method is the empty string, the handler is registered for all HTTP methods.
-func (b *Builder) Handle(method, pat string, h http.Handler) {
-	if err := b.handle(method, pat, h); err != nil {
-		panic("hmux: " + err.Error())
+func (b *Builder) Handle(method, pat string, h http.Handler, opts ...RuleOption) {
+	if err := b.TryHandle(method, pat, h, opts...); err != nil {
+		panic(err.Error())
 	}
 }
 
-func (b *Builder) handle(method, pat string, h http.Handler) error {
+// TryHandle is like Handle but returns an error rather than panicking if pat
+// is invalid, h is nil, or the rule conflicts with a previously registered
+// rule. It is intended for programs which register rules given by runtime
+// data (such as configuration files or plugins) and want to report bad rules
+// rather than crash. If TryHandle returns an error, it registers nothing.
+//
+// TryHandle does not recover from panics in RuleOptions.
+func (b *Builder) TryHandle(method, pat string, h http.Handler, opts ...RuleOption) error {
+	if err := b.handle(method, pat, h, opts...); err != nil {
+		return fmt.Errorf("hmux: %s", err)
+	}
+	return nil
+}
+
+// HandleMethods registers a handler for each of the given HTTP methods and
+// the path pattern, as if by calling Handle for each method:
+//
+//	b.HandleMethods([]string{"GET", "HEAD"}, "/x", h)
+//
+// The rules are registered atomically: if any of them conflicts with a
+// previously registered rule, HandleMethods panics without registering any.
+// HandleMethods also panics if methods is empty or contains duplicates or the
+// empty string.
+func (b *Builder) HandleMethods(methods []string, pat string, h http.Handler, opts ...RuleOption) {
+	if err := b.TryHandleMethods(methods, pat, h, opts...); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryHandleMethods is like HandleMethods but returns an error rather than
+// panicking. (See TryHandle.)
+func (b *Builder) TryHandleMethods(methods []string, pat string, h http.Handler, opts ...RuleOption) error {
+	if len(methods) == 0 {
+		return errors.New("hmux: HandleMethods called with no methods")
+	}
+	seen := make(map[string]bool)
+	for _, method := range methods {
+		if method == "" {
+			return errors.New("hmux: HandleMethods called with empty method")
+		}
+		if seen[method] {
+			return fmt.Errorf("hmux: HandleMethods called with duplicate method %s", method)
+		}
+		seen[method] = true
+	}
+	err := b.atomically(func() error {
+		for _, method := range methods {
+			if err := b.handle(method, pat, h, opts...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("hmux: %s", err)
+	}
+	return nil
+}
+
+// HandlePatterns registers a handler for the given HTTP method and each of
+// the path patterns, as if by calling Handle for each pattern. This is useful
+// for serving legacy alias paths:
+//
+//	b.HandlePatterns("GET", []string{"/v1/users/:id", "/users/:id"}, h)
+//
+// The rules are registered atomically: if any pattern is invalid or any rule
+// conflicts with a previously registered rule (or with another of the
+// patterns), HandlePatterns panics without registering any. HandlePatterns
+// also panics if pats is empty.
+func (b *Builder) HandlePatterns(method string, pats []string, h http.Handler, opts ...RuleOption) {
+	if err := b.TryHandlePatterns(method, pats, h, opts...); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryHandlePatterns is like HandlePatterns but returns an error rather than
+// panicking. (See TryHandle.)
+func (b *Builder) TryHandlePatterns(method string, pats []string, h http.Handler, opts ...RuleOption) error {
+	if len(pats) == 0 {
+		return errors.New("hmux: HandlePatterns called with no patterns")
+	}
+	err := b.atomically(func() error {
+		for _, pat := range pats {
+			if err := b.handle(method, pat, h, opts...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("hmux: %s", err)
+	}
+	return nil
+}
+
+// atomically calls f, which registers rules with b. If f returns an error,
+// atomically undoes the registrations before returning it.
+func (b *Builder) atomically(f func() error) error {
+	if b.group != nil {
+		return b.group.parent.atomically(f)
+	}
+	// addRule replaces the elements of b.matchers rather than modifying the
+	// matchers, so a copy of the slice is enough to undo the registrations.
+	saved := b.matchers
+	b.matchers = append([]*matcher{}, saved...)
+	nnames := len(b.nameLog)
+	if !b.inCall {
+		b.inCall = true
+		b.callNames = nnames
+		defer func() { b.inCall = false }()
+	}
+	if err := f(); err != nil {
+		b.matchers = saved
+		for _, name := range b.nameLog[nnames:] {
+			delete(b.names, name)
+		}
+		b.nameLog = b.nameLog[:nnames]
+		return err
+	}
+	return nil
+}
+
+func (b *Builder) handle(method, pat string, h http.Handler, opts ...RuleOption) error {
 	if h == nil {
 		return errors.New("Handle called with nil handler")
 	}
-	p, err := parsePattern(pat)
+	pat, p, err := b.parsePattern(pat)
 	if err != nil {
 		return err
 	}
-	return b.addHandler(method, pat, p, h)
+	return b.addHandler(method, pat, p, h, opts)
 }
 
 // Prefix registers a handler at the given prefix pattern.
@@ -256,11 +566,11 @@ func (b *Builder) handle(method, pat string, h http.Handler) error {
 // "/sub", "/sub/", or "/sub/*".
 //
 // The pattern cannot be "" or "*" when calling Prefix.
-func (b *Builder) Prefix(pat string, h http.Handler) {
+func (b *Builder) Prefix(pat string, h http.Handler, opts ...RuleOption) {
 	if h == nil {
 		panic("hmux: Prefix called with nil handler")
 	}
-	p, err := parsePattern(pat)
+	pat, p, err := b.parsePattern(pat)
 	if err != nil {
 		panic("hmux: " + err.Error())
 	}
@@ -270,12 +580,15 @@ func (b *Builder) Prefix(pat string, h http.Handler) {
 	case patStar:
 		panic("hmux: Prefix called with pattern *")
 	}
+	if p.glob != nil {
+		panic("hmux: Prefix called with glob pattern")
+	}
 	p.opt = patWildcard
 	ph := prefixHandler{
 		h:    h,
 		skip: len(p.segs),
 	}
-	if err := b.addHandler("", pat, p, ph); err != nil {
+	if err := b.addHandler("", pat, p, ph, opts); err != nil {
 		panic("hmux: " + err.Error())
 	}
 }
@@ -320,58 +633,225 @@ func skipPrefix(s string, skip int) string {
 
 // ServeFile registers GET and HEAD handlers for the given pattern that serve
 // the named file using http.ServeFile.
-func (b *Builder) ServeFile(pat, name string) {
-	if err := b.handleServeFile(pat, name); err != nil {
+//
+// If pat is a wildcard pattern, name is instead the root of a directory tree
+// and the handlers serve the file named by the path remainder matched by the
+// wildcard, using http.FileServer and http.Dir:
+//
+//	b.ServeFile("/downloads/*", "/srv/downloads")
+//
+// Then a request for /downloads/a/b.zip is served the file
+// /srv/downloads/a/b.zip. As with http.Dir, the remainder cannot refer to
+// files outside the directory. If the wildcard has a glob, only files
+// matching the glob are served.
+func (b *Builder) ServeFile(pat, name string, opts ...RuleOption) {
+	if err := b.handleServeFile(pat, name, opts); err != nil {
 		panic("hmux: " + err.Error())
 	}
 }
 
-func (b *Builder) handleServeFile(pat, name string) error {
-	p, err := parsePattern(pat)
+func (b *Builder) handleServeFile(pat, name string, opts []RuleOption) error {
+	pat, p, err := b.parsePattern(pat)
 	if err != nil {
 		return err
 	}
-	var h http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, name)
+	})
+	if p.opt == patWildcard {
+		h = prefixHandler{
+			h:    http.FileServer(http.Dir(name)),
+			skip: len(p.segs),
+		}
 	}
-	if err := b.addHandler(http.MethodGet, pat, p, h); err != nil {
-		return err
-	}
-	if err := b.addHandler(http.MethodHead, pat, p, h); err != nil {
-		return err
-	}
-	return nil
+	return b.atomically(func() error {
+		if err := b.addHandler(http.MethodGet, pat, p, h, opts); err != nil {
+			return err
+		}
+		return b.addHandler(http.MethodHead, pat, p, h, opts)
+	})
 }
 
 // ServeFS serves files from fsys at a prefix pattern using http.FileServer.
 //
 // Like Prefix, the pattern prefix is removed from the beginning of the path
 // before lookup in fsys.
-func (b *Builder) ServeFS(pat string, fsys fs.FS) {
-	b.Prefix(pat, http.FileServer(http.FS(fsys)))
+//
+// By default, errors opening files are reported using http.FileServer's
+// plain-text error responses; see FSErrors to customize them.
+func (b *Builder) ServeFS(pat string, fsys fs.FS, opts ...RuleOption) {
+	h := fsHandler{fsys: fsys, fs: http.FileServer(http.FS(fsys))}
+	b.Prefix(pat, h, opts...)
+}
+
+// Normalize registers a function that the Mux calls on each incoming request
+// before any other processing, including the redirection of non-canonical
+// paths. The function may return r itself or a modified copy of it; it must
+// not return nil. This is useful for centralizing request cleanup such as
+// stripping legacy path suffixes.
+//
+// If Normalize is called multiple times, the functions are called in the order
+// they were registered.
+//
+// If a function changes the request URL's Path such that RawPath is no longer
+// a valid encoding of it, the Mux discards RawPath before matching.
+func (b *Builder) Normalize(f func(*http.Request) *http.Request) {
+	b.checkNotGroup("Normalize")
+	if f == nil {
+		panic("hmux: Normalize called with nil function")
+	}
+	b.normalize = append(b.normalize, f)
 }
 
-func (b *Builder) addHandler(method, pat string, p pattern, h http.Handler) error {
+// OnMatch registers a function that the Mux calls after it has matched a
+// request to a rule but before it calls the rule's handler. The function may
+// inspect the match and may replace m.Handler to change which handler serves
+// the request. This is useful for centralizing logic such as gradually
+// migrating some requests to a new implementation of an endpoint.
+//
+// The request passed to the function does not yet carry the matched
+// parameters; use m.Params instead of RequestParams.
+//
+// OnMatch functions are not called for requests which do not match any rule
+// (that is, those which result in a 404 or 405 response). If OnMatch is called
+// multiple times, the functions are called in the order they were registered.
+func (b *Builder) OnMatch(f func(r *http.Request, m *Match)) {
+	b.checkNotGroup("OnMatch")
+	if f == nil {
+		panic("hmux: OnMatch called with nil function")
+	}
+	b.onMatch = append(b.onMatch, f)
+}
+
+// CaseInsensitive sets whether Muxes built from b match literal pattern
+// segments (and the literal suffixes of parameters) regardless of ASCII case.
+// Parameter values are unaffected: they hold the request path segments as
+// given.
+//
+// For example, with case-insensitive matching enabled, the pattern
+// "/admin/:page" matches the path "/Admin/Users" with the page parameter
+// "Users".
+//
+// Case-insensitive matching does not change rule specificity or conflict
+// detection, so two rules whose literal segments differ only in case are
+// distinct rules, and requests are routed to the first of them in order of
+// specificity.
+func (b *Builder) CaseInsensitive(enable bool) {
+	b.checkNotGroup("CaseInsensitive")
+	b.foldCase = enable
+}
+
+// ServeMuxCompatible sets whether Muxes built from b mirror the path handling
+// of (pre-Go 1.22) http.ServeMux, to ease migrating a service from
+// http.ServeMux without changing its externally visible behavior. In this
+// mode:
+//
+//   - Requests for paths that need cleaning (see Routing) are redirected
+//     with a 301 ("Moved Permanently") rather than a 308.
+//   - A pattern ending with a slash, such as "/static/", matches every path
+//     beginning with that prefix, as would the wildcard pattern "/static/*".
+//     (Parameter segments and methods may still be used.)
+//   - A request for "/static" is redirected with a 301 to "/static/" if some
+//     pattern ending with a slash or wildcard matches "/static/" and no
+//     pattern without a trailing slash matches "/static". As with
+//     http.ServeMux, this happens even if the request would otherwise be
+//     handled by a rule with a shorter pattern, such as "/".
+//
+// Once the migration is complete, prefer to disable the mode and use
+// wildcard patterns.
+func (b *Builder) ServeMuxCompatible(enable bool) {
+	b.checkNotGroup("ServeMuxCompatible")
+	b.serveMux = enable
+}
+
+// A Match describes a rule that matched a request. See Builder.OnMatch.
+type Match struct {
+	// Route is the matched rule.
+	Route Route
+	// Params holds the parameters captured by the match, or nil if there
+	// are none.
+	Params *Params
+	// Handler is the handler which will serve the request.
+	// It must not be set to nil.
+	Handler http.Handler
+	// Scanned is the number of matchers the Mux tried, including the one
+	// that matched, where a matcher holds the rules having a particular
+	// pattern (or equivalent patterns). Recording it shows how much routing
+	// work requests take and so how much reordering the rules (see
+	// Priority) or partitioning a large route table would help.
+	Scanned int
+
+	rule *rule
+}
+
+// A Route describes a rule registered with a Builder.
+type Route struct {
+	// Method is the HTTP method matched by the rule, or "" if the rule
+	// matches all methods.
+	Method string
+	// Pattern is the pattern given when the rule was registered.
+	Pattern string
+	// Name is the name given to the rule using Name, if any.
+	Name string
+	// Meta holds the metadata attached to the rule using Meta.
+	Meta map[string]string
+	// Streaming reports whether the rule was marked using Streaming.
+	Streaming bool
+	// RangeRequests reports whether the rule was marked using
+	// RangeRequests.
+	RangeRequests bool
+}
+
+func (b *Builder) addHandler(method, pat string, p pattern, h http.Handler, opts []RuleOption) error {
+	if b.group != nil {
+		return b.group.addHandler(method, pat, p, h, opts)
+	}
+	rl := &rule{method: method, pat: pat, p: p, h: h}
+	if b.anySlash {
+		optionalTrailingSlash(rl)
+	}
+	for _, opt := range opts {
+		opt(rl)
+	}
+	return b.addRule(rl, false)
+}
+
+// addRule adds rl to b. If replace is true, rl replaces any conflicting rule;
+// otherwise, a conflict is an error.
+func (b *Builder) addRule(rl *rule, replace bool) error {
+	if !replace && rl.name != "" && !b.sameCallName(rl.name) {
+		if rl1, ok := b.names[rl.name]; ok {
+			return fmt.Errorf("%s %q is named %q, like previously registered %s %q",
+				rl.method, rl.pat, rl.name, rl1.method, rl1.pat)
+		}
+	}
+	p := rl.p
 	// Insert in descending precedence order.
 	i := sort.Search(len(b.matchers), func(i int) bool {
 		return p.compare(b.matchers[i].pat) >= 0
 	})
 	if i < len(b.matchers) && b.matchers[i].pat.compare(p) == 0 {
-		// segs has the same priority as b.matchers[i].segs
-		if !b.matchers[i].merge(method, h) {
-			return fmt.Errorf("%s %q conflicts with previously registered pattern", method, pat)
+		// segs has the same priority as b.matchers[i].segs.
+		// The matcher may be shared with built Muxes, so modify a copy.
+		ma := b.matchers[i].clone()
+		if replace {
+			ma.override(rl)
+		} else if conflict := ma.merge(rl); conflict != nil {
+			return fmt.Errorf("%s %q conflicts with previously registered pattern %q",
+				rl.method, rl.pat, conflict.pat)
+		}
+		b.matchers[i] = ma
+		if !replace {
+			b.addName(rl)
 		}
 		return nil
 	}
 	ma := &matcher{pat: p}
-	if method == "" {
-		ma.allMethods = h
-	} else {
-		ma.addMethodHandler(method, h)
-	}
+	ma.merge(rl)
 	b.matchers = append(b.matchers, nil)
 	copy(b.matchers[i+1:], b.matchers[i:])
 	b.matchers[i] = ma
+	b.addName(rl)
 	return nil
 }
 
@@ -379,65 +859,330 @@ func (b *Builder) addHandler(method, pat string, p pattern, h http.Handler) erro
 // state with b: future changes to b will not affect the built Mux and other
 // Muxes may be built from b later (possibly after adding more rules).
 func (b *Builder) Build() *Mux {
-	m := &Mux{matchers: make([]*matcher, len(b.matchers))}
-	for i, ma := range b.matchers {
-		m.matchers[i] = ma.clone()
+	b.checkNotGroup("Build")
+	m := &Mux{
+		matchers:  append([]*matcher{}, b.matchers...),
+		normalize: append([]func(*http.Request) *http.Request{}, b.normalize...),
+		onMatch:   append([]func(*http.Request, *Match){}, b.onMatch...),
+		canonical: b.canonical,
+		foldCase:  b.foldCase,
+		serveMux:  b.serveMux,
+		matrix:    b.matrix,
+		rawPath:   b.rawPath,
+		normSeg:   b.normSeg,
+		targets:   b.targets,
+
+		redirectSlash:   b.redirectSlash,
+		redirectCase:    b.redirectCase,
+		describeMethods: b.describeMethods,
+		encodedSlash:    b.encodedSlash,
+		invalidRawPath:  b.invalidRawPath,
+		checkResponses:  b.checkResponses,
+		checkCORS:       b.checkCORS,
+	}
+	// The matchers are shared with b (which copies them before making
+	// changes) except where binding requires a copy.
+	for i, ma := range m.matchers {
+		m.matchers[i] = ma.bind(m)
+	}
+	if m.normSeg != nil {
+		m.normalizeMatchers()
+	}
+	m.nprio = sort.Search(len(m.matchers), func(i int) bool {
+		return m.matchers[i].pat.priority <= 0
+	})
+	m.nlit = m.nprio + sort.Search(len(m.matchers)-m.nprio, func(i int) bool {
+		pat := m.matchers[m.nprio+i].pat
+		return pat.priority < 0 || len(pat.segs) == 0 || pat.segs[0].isParam
+	})
+	m.indexNames()
+	if b.nearDuplicates != nil {
+		reportNearDuplicates(m.matchers, b.nearDuplicates)
 	}
 	return m
 }
 
+// BuildWith creates a Mux using the current rules in b and overrides, which
+// holds rules that customize the Mux (for example, for a particular tenant in
+// a multi-tenant server). A rule in overrides replaces any rule in b having
+// an equivalent pattern (that is, a pattern that would conflict), the same
+// method, and the same conditions (see Flag and Active); the other rules in
+// overrides are added to those of b. Apart from its rules, the configuration
+// of overrides (such as functions registered with OnMatch) is ignored.
+//
+// Muxes created by BuildWith share most of their routing structures with b,
+// so deriving many variants of a large Builder is inexpensive.
+func (b *Builder) BuildWith(overrides *Builder) *Mux {
+	b.checkNotGroup("BuildWith")
+	if overrides.group != nil {
+		panic("hmux: BuildWith called with a group Builder")
+	}
+	b1 := *b
+	b1.matchers = append([]*matcher{}, b.matchers...)
+	for _, ma := range overrides.matchers {
+		ma.forEachRule(func(rl *rule) {
+			// Overriding can't fail.
+			b1.addRule(rl, true)
+		})
+	}
+	return b1.Build()
+}
+
 // Mux is an HTTP request multiplexer. It matches the URL path and HTTP method
 // of each incoming request to a list of rules and calls the handler that most
 // closely matches the request. It supplies path-based parameters named by the
 // matched rule via the HTTP request context.
 type Mux struct {
-	matchers []*matcher
+	matchers  []*matcher
+	normalize []func(*http.Request) *http.Request
+	onMatch   []func(*http.Request, *Match)
+	canonical *url.URL
+	foldCase  bool
+	serveMux  bool
+	matrix    bool
+	rawPath   bool
+	normSeg   func(string) string
+	targets   [numTargetForms]TargetAction
+	// nprio is the number of leading matchers whose rules have a positive
+	// priority. The following matchers, up to index nlit, are those whose
+	// patterns begin with a literal segment. See candidates.
+	nprio int
+	nlit  int
+
+	redirectSlash   bool
+	redirectCase    bool
+	describeMethods bool
+	encodedSlash    EncodedSlashPolicy
+	invalidRawPath  func(*http.Request)
+	checkResponses  func(*http.Request, Route, error)
+	checkCORS       func(*http.Request, error)
+	names           map[string]*rule // see Name
 }
 
 // ServeHTTP implements the http.Handler interface.
+//
+// ServeHTTP handles each request in these steps:
+//
+//  1. It calls the functions registered using Builder.Normalize (and then
+//     applies the policy set by Builder.SetEncodedSlashPolicy).
+//  2. It matches the request to a rule, checking the conditions of the
+//     candidate rules (such as those given by Query or Header), and applies
+//     the rule's parameter mappings (see MapParam).
+//  3. It calls the functions registered using Builder.OnMatch.
+//  4. It calls the middlewares of the matched rule (see Middleware), the
+//     outermost first, with a request carrying the matched parameters.
+//  5. It calls the rule's handler.
+//
+// The parameters seen in steps 3 and 4 are those passed to the handler, so
+// changes made using Params.Set are visible to the later steps.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, mr, ok := m.lookup(w, r)
+	if ok {
+		m.serve(w, r, mr)
+	}
+}
+
+// lookup normalizes r and finds the rule matching it, returning the
+// normalized request along with the match. If r should be rejected or
+// redirected before matching, lookup writes the response to w and returns
+// false.
+func (m *Mux) lookup(w http.ResponseWriter, r *http.Request) (*http.Request, matchResult, bool) {
+	if m.invalidRawPath != nil {
+		r = m.checkRawPath(r)
+	}
+	if len(m.normalize) > 0 {
+		r = m.normalizeRequest(r)
+	}
+	if m.encodedSlash != EncodedSlashKeep {
+		if r = m.applyEncodedSlashPolicy(w, r); r == nil {
+			return nil, noMatch, false
+		}
+	}
+	switch m.targetAction(r) {
+	case TargetReject:
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil, noMatch, false
+	case TargetSpecial:
+		return r, m.specialHandler(r), true
+	}
+	mr, ok := m.route(w, r)
+	return r, mr, ok
+}
+
+// serve responds to r according to mr, the result of lookup.
+func (m *Mux) serve(w http.ResponseWriter, r *http.Request, mr matchResult) {
+	if mr.rule == nil {
+		if cr, ok := mr.cond.(condResponder); ok {
+			cr.respond(w, r, mr.status)
+			return
+		}
+		if mr.status != 0 && mr.status != http.StatusNotFound {
+			http.Error(w, http.StatusText(mr.status), mr.status)
+			return
+		}
+		if mr.allow != "" {
+			w.Header().Set("Allow", mr.allow)
+			if m.describeMethods {
+				writeMethodsDocument(w, r, mr.allowed)
+				return
+			}
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	if mr.negotiated {
+		w.Header().Add("Vary", "Accept")
+	}
+	if mr.rule.produces != "" {
+		w.Header().Set("Content-Type", mr.rule.produces)
+	}
+	h := mr.rule.h
+	if mr.rule.chain != nil {
+		h = mr.rule.chain
+	}
+	if len(m.onMatch) > 0 {
+		mt := &Match{Route: mr.rule.route(), Params: mr.p, Handler: mr.rule.h, Scanned: mr.scanned, rule: mr.rule}
+		replaced := false
+		for _, f := range m.onMatch {
+			h0 := mt.Handler
+			f(r, mt)
+			replaced = replaced || !sameHandler(h0, mt.Handler)
+		}
+		switch {
+		case !replaced:
+		case mr.rule.replacedChain != nil:
+			r = r.WithContext(context.WithValue(r.Context(), replacedHandlerKey, mt.Handler))
+			h = mr.rule.replacedChain
+		default:
+			h = mt.Handler
+		}
+	}
+	if cc := mr.rule.meta[cacheControlKey]; cc != "" {
+		h = cacheHandler{h, cc}
+	}
+	if m.checkCORS != nil && isPreflight(r) {
+		h = corsCheckHandler{h, m}
+	}
+	if mr.p == nil && m.canonical != nil {
+		// Record the match for CanonicalURL.
+		mr.p = new(Params)
+	}
+	if mr.p != nil {
+		mr.p.rule = mr.rule
+		mr.p.mux = m
+		p0 := requestParams(r)
+		if p0 == nil {
+			// The Mux may be nested inside another copy of this
+			// package.
+			p0 = ImportParams(r)
+		}
+		if p0 != nil {
+			p0.merge(mr.p)
+			mr.p = p0
+		}
+		if ph, ok := h.(ParamsHandler); ok && m.canonical == nil {
+			// Fast path: skip copying the request.
+			ph.ServeHTTPParams(w, r, mr.p.orNil())
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), paramKey, mr.p))
+	}
+	h.ServeHTTP(w, r)
+}
+
+// Routes returns descriptions of all the rules used by m, in the order that
+// m considers them when matching a request.
+func (m *Mux) Routes() []Route {
+	var routes []Route
+	for _, ma := range m.matchers {
+		ma.forEachRule(func(rl *rule) {
+			routes = append(routes, rl.route())
+		})
+	}
+	return routes
+}
+
+func (m *Mux) normalizeRequest(r *http.Request) *http.Request {
+	for _, f := range m.normalize {
+		r = f(r)
+	}
+	// Restore the invariant that RawPath, if set, is an encoding of Path.
+	if r.URL.RawPath != "" && r.URL.EscapedPath() != r.URL.RawPath {
+		r1 := new(http.Request)
+		*r1 = *r
+		u := *r.URL
+		u.RawPath = ""
+		r1.URL = &u
+		r = r1
+	}
+	return r
+}
+
+// route finds the rule matching r by its path and method. If r should be
+// redirected instead, route writes the redirect and returns false.
+func (m *Mux) route(w http.ResponseWriter, r *http.Request) (matchResult, bool) {
 	// Redirect non-canonical paths.
 	if r.Method != http.MethodConnect {
-		if r.URL.RawPath == "" {
+		if m.serveMux {
+			// http.ServeMux cleans the unescaped path.
+			if targ, ok := shouldRedirect(r.URL.Path); ok {
+				u := *r.URL
+				u.Path = targ
+				u.RawPath = ""
+				http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+				return noMatch, false
+			}
+		} else if r.URL.RawPath == "" {
 			if targ, ok := shouldRedirect(r.URL.Path); ok {
 				u := *r.URL
 				u.Path = targ
 				http.Redirect(w, r, u.String(), http.StatusPermanentRedirect)
-				return
+				return noMatch, false
 			}
 		} else if targ, ok := shouldRedirect(r.URL.RawPath); ok {
 			u := *r.URL
 			u.RawPath = targ
 			u.Path = mustPathUnescape(targ)
 			http.Redirect(w, r, u.String(), http.StatusPermanentRedirect)
-			return
+			return noMatch, false
 		}
 	}
 
-	var opts matchOpts
-	pth := r.URL.Path
-	if r.URL.RawPath != "" {
-		opts |= optReencode
-		pth = r.URL.RawPath
+	pth, opts, matrix := m.matchPath(r)
+	if m.serveMux {
+		if m.shouldRedirectSlash(r, pth, opts) {
+			u := *r.URL
+			u.Path += "/"
+			if u.RawPath != "" {
+				u.RawPath += "/"
+			}
+			http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+			return noMatch, false
+		}
+		opts |= optSubtree
 	}
-	mr := m.handler(r.Method, pth, opts)
-	if mr.h == nil {
-		if mr.allow != "" {
-			w.Header().Set("Allow", mr.allow)
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
+	mr := m.handler(r, pth, opts)
+	if m.redirectSlash && !mr.matchesPath() {
+		if u, ok := m.slashRedirect(r, pth, opts); ok {
+			http.Redirect(w, r, u, http.StatusPermanentRedirect)
+			return noMatch, false
 		}
-		http.NotFound(w, r)
-		return
 	}
-	if mr.p != nil {
-		if p0 := RequestParams(r); p0 != nil {
-			p0.merge(mr.p)
-			mr.p = p0
+	if m.redirectCase && !mr.matchesPath() {
+		if u, ok := m.caseRedirect(r, pth, opts); ok {
+			http.Redirect(w, r, u, http.StatusPermanentRedirect)
+			return noMatch, false
 		}
-		r = r.WithContext(context.WithValue(r.Context(), paramKey, mr.p))
 	}
-	mr.h.ServeHTTP(w, r)
+	if mr.rule != nil && matrix != nil {
+		if mr.p == nil {
+			mr.p = new(Params)
+		}
+		mr.p.matrix = matrix
+	}
+	return mr, true
 }
 
 func shouldRedirect(pth string) (string, bool) {
@@ -475,7 +1220,59 @@ func shouldRedirect(pth string) (string, bool) {
 	return pth, false
 }
 
-func (m *Mux) handler(method, pth string, opts matchOpts) matchResult {
+// matchPath returns the path of r to be matched against m's patterns along
+// with the corresponding match options. If m parses matrix parameters,
+// matchPath removes them from the path and returns them as well.
+func (m *Mux) matchPath(r *http.Request) (string, matchOpts, []url.Values) {
+	var opts matchOpts
+	if m.foldCase {
+		opts |= optFoldCase
+	}
+	escOpt := optReencode
+	if m.rawPath {
+		escOpt = optRaw
+	}
+	if m.matrix {
+		pth, matrix := stripMatrix(r.URL.EscapedPath())
+		return pth, opts | escOpt, matrix
+	}
+	if m.rawPath {
+		return r.URL.EscapedPath(), opts | optRaw, nil
+	}
+	pth := r.URL.Path
+	if r.URL.RawPath != "" {
+		opts |= optReencode
+		pth = r.URL.RawPath
+	}
+	return pth, opts, nil
+}
+
+// shouldRedirectSlash reports whether, in ServeMux-compatible mode, a request
+// for pth should be redirected to pth with a trailing slash.
+func (m *Mux) shouldRedirectSlash(r *http.Request, pth string, opts matchOpts) bool {
+	if pth == "*" || strings.HasSuffix(pth, "/") {
+		return false
+	}
+	parts, opts := m.splitPath(pth, opts)
+	found := false
+	for _, ma := range m.matchers {
+		switch ma.pat.opt {
+		case patOther:
+			if ma.match(r, parts, opts).matchesPath() {
+				return false
+			}
+		case patTrailingSlash, patWildcard:
+			if !found && len(ma.pat.segs) == len(parts) {
+				found = ma.match(r, parts, opts|optTrailingSlash).matchesPath()
+			}
+		}
+	}
+	return found
+}
+
+// splitPath splits pth into its (unescaped) segments, adding the options
+// implied by its form to opts.
+func splitPath(pth string, opts matchOpts) ([]string, matchOpts) {
 	var parts []string
 	if pth == "*" {
 		opts |= optStar
@@ -490,28 +1287,85 @@ func (m *Mux) handler(method, pth string, opts matchOpts) matchResult {
 		}
 	}
 	if opts&optReencode != 0 {
+		// Unescape each segment once for all the matchers.
 		for i, part := range parts {
 			parts[i] = mustPathUnescape(part)
 		}
 	}
+	return parts, opts
+}
+
+func (m *Mux) handler(r *http.Request, pth string, opts matchOpts) matchResult {
+	parts, opts := m.splitPath(pth, opts)
+	return m.matchParts(r, parts, opts)
+}
+
+// matchParts is like handler but takes the path as split by splitPath.
+func (m *Mux) matchParts(r *http.Request, parts []string, opts matchOpts) matchResult {
 	result := noMatch
-	for _, ma := range m.matchers {
-		mr := ma.match(method, parts, opts)
-		if mr.h != nil {
-			return mr
-		}
-		// Keep the first 405 result we get, if any.
-		if result == noMatch {
-			result = mr
+	scanned := 0
+	prio, lits, rest := m.candidates(parts, opts)
+	for _, matchers := range [3][]*matcher{prio, lits, rest} {
+		for _, ma := range matchers {
+			scanned++
+			mr := ma.match(r, parts, opts)
+			if mr.rule != nil || mr.status != 0 {
+				mr.scanned = scanned
+				return mr
+			}
+			// Keep the first 405 result we get, if any.
+			if result.allow == "" {
+				result = mr
+			}
 		}
 	}
+	result.scanned = scanned
 	return result
 }
 
+// candidates returns, in precedence order, the matchers of m whose rules
+// have a positive priority, the matchers whose patterns begin with a literal
+// segment and might match parts, and the rest of the matchers.
+//
+// Apart from those with a positive priority, the matchers with literal first
+// segments precede the others and are sorted by that segment (in descending
+// order), so those with the same first segment as parts can be found by
+// binary search rather than by trying each in turn.
+func (m *Mux) candidates(parts []string, opts matchOpts) (prio, lits, rest []*matcher) {
+	prio = m.matchers[:m.nprio]
+	lits, rest = m.matchers[m.nprio:m.nlit], m.matchers[m.nlit:]
+	if len(parts) == 0 {
+		return prio, nil, rest
+	}
+	if opts&optFoldCase != 0 {
+		// The order does not account for case folding.
+		return prio, lits, rest
+	}
+	s := rawUnescape(parts[0], opts)
+	i := sort.Search(len(lits), func(i int) bool { return lits[i].pat.segs[0].s <= s })
+	j := sort.Search(len(lits), func(i int) bool { return lits[i].pat.segs[0].s < s })
+	return prio, lits[i:j], rest
+}
+
 type segment struct {
 	s       string // literal or param name
 	isParam bool
-	ptyp    paramType // if segParam
+	ptyp    paramType        // if segParam
+	custom  *customParamType // if ptyp is paramCustom
+	enum    []string         // if ptyp is paramEnum; sorted
+	minLen  int              // if ptyp is paramStringLen
+	maxLen  int              // if ptyp is paramStringLen
+	suffix  string           // if isParam; literal following the param
+}
+
+func (seg segment) typeName() string {
+	switch seg.ptyp {
+	case paramCustom:
+		return seg.custom.name
+	case paramStringLen:
+		return fmt.Sprintf("string(%d,%d)", seg.minLen, seg.maxLen)
+	}
+	return seg.ptyp.String()
 }
 
 var (
@@ -519,7 +1373,7 @@ var (
 	errEmptyParamName = errors.New("pattern contains a param segment with an empty name")
 )
 
-func parseSegment(s string) (segment, error) {
+func parseSegment(s string, custom map[string]*customParamType) (segment, error) {
 	var seg segment
 	// Wildcards are handled separately and the input is not empty.
 	if strings.Contains(s, "*") {
@@ -534,11 +1388,15 @@ func parseSegment(s string) (segment, error) {
 		seg.s, err = url.PathUnescape(s)
 		return seg, err
 	}
-	s = s[1:]
+	s, suffix, err := splitParamSuffix(s[1:])
+	if err != nil {
+		return seg, err
+	}
 	if s == "" {
 		return seg, errEmptyParamName
 	}
 	seg.isParam = true
+	seg.suffix = suffix
 	i := strings.IndexByte(s, ':')
 	if i < 0 {
 		seg.s = s
@@ -555,13 +1413,106 @@ func parseSegment(s string) (segment, error) {
 		seg.ptyp = paramInt32
 	case "int64":
 		seg.ptyp = paramInt64
+	case "rfc3339":
+		seg.ptyp = paramRFC3339
+	case "hex":
+		seg.ptyp = paramHex
 	default:
-		return seg, fmt.Errorf("unknown parameter type %q", s[i+1:])
+		if args, ok := typeArgs(s[i+1:], "string"); ok {
+			min, max, err := parseLenBounds(args)
+			if err != nil {
+				return seg, err
+			}
+			seg.ptyp = paramStringLen
+			seg.minLen = min
+			seg.maxLen = max
+			break
+		}
+		if args, ok := typeArgs(s[i+1:], "enum"); ok {
+			vals, err := parseEnum(args)
+			if err != nil {
+				return seg, err
+			}
+			seg.ptyp = paramEnum
+			seg.enum = vals
+			break
+		}
+		ct, ok := custom[s[i+1:]]
+		if !ok {
+			return seg, fmt.Errorf("unknown parameter type %q", s[i+1:])
+		}
+		seg.ptyp = paramCustom
+		seg.custom = ct
 	}
 	seg.s = s[:i]
 	return seg, nil
 }
 
+// splitParamSuffix splits a parameter segment (without the leading colon)
+// into the parameter name and type and the unescaped literal suffix, if any.
+// The suffix begins with the first period following the name and type (where
+// a period inside parentheses, as in "enum(a.b|c)", is part of the type).
+func splitParamSuffix(s string) (param, suffix string, err error) {
+	start := strings.LastIndexByte(s, ')') + 1
+	i := strings.IndexByte(s[start:], '.')
+	if i < 0 {
+		return s, "", nil
+	}
+	i += start
+	suffix, err = url.PathUnescape(s[i:])
+	return s[:i], suffix, err
+}
+
+// typeArgs reports whether the parameter type typ has the form name(args) and,
+// if so, returns args.
+func typeArgs(typ, name string) (args string, ok bool) {
+	if !strings.HasPrefix(typ, name+"(") || !strings.HasSuffix(typ, ")") {
+		return "", false
+	}
+	return typ[len(name)+1 : len(typ)-1], true
+}
+
+// parseLenBounds parses the "min,max" arguments of a length-bounded string
+// parameter type.
+func parseLenBounds(args string) (min, max int, err error) {
+	i := strings.IndexByte(args, ',')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("string parameter type bounds %q are not of the form min,max", args)
+	}
+	min, err1 := strconv.Atoi(args[:i])
+	max, err2 := strconv.Atoi(args[i+1:])
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("string parameter type bounds %q are not of the form min,max", args)
+	}
+	if min < 1 || max < min {
+		return 0, 0, fmt.Errorf("string parameter type has invalid bounds (%d,%d)", min, max)
+	}
+	return min, max, nil
+}
+
+// parseEnum parses the |-separated values of an enum parameter type. Like
+// literal segments, the values are URL-unescaped.
+func parseEnum(args string) ([]string, error) {
+	var vals []string
+	for _, v := range strings.Split(args, "|") {
+		if v == "" {
+			return nil, errors.New("enum parameter type contains an empty value")
+		}
+		v, err := url.PathUnescape(v)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	sort.Strings(vals)
+	for i := 1; i < len(vals); i++ {
+		if vals[i] == vals[i-1] {
+			return nil, fmt.Errorf("enum parameter type contains duplicate value %q", vals[i])
+		}
+	}
+	return vals, nil
+}
+
 // A patternOpt indicates one of several mutually exclusive types of patterns.
 type patternOpt int
 
@@ -577,6 +1528,15 @@ const (
 type pattern struct {
 	segs []segment
 	opt  patternOpt
+	// glob constrains the remainder matched by a wildcard pattern. Each
+	// element is "**" or a (unescaped) segment glob. It is nil for plain
+	// wildcard patterns.
+	glob []string
+	// priority is set by Priority. It takes precedence over specificity.
+	priority int
+	// anySlash indicates that the pattern (whose opt is patOther) matches
+	// paths with or without a trailing slash. See OptionalTrailingSlash.
+	anySlash bool
 }
 
 var (
@@ -584,7 +1544,9 @@ var (
 	errPatternSlash        = errors.New("pattern contains //")
 )
 
-func parsePattern(pat string) (pattern, error) {
+// parsePattern parses pat. Parameter types other than the built-in types are
+// looked up in custom.
+func parsePattern(pat string, custom map[string]*customParamType) (pattern, error) {
 	var p pattern
 	if pat == "" {
 		p.opt = patEmpty
@@ -603,6 +1565,16 @@ func parsePattern(pat string) (pattern, error) {
 	var ok bool
 	if pat, ok = trimSuffix(pat, "/*"); ok {
 		p.opt = patWildcard
+	} else if i := strings.IndexByte(pat, '*'); i >= 0 {
+		// A glob begins with the segment containing the first *.
+		i = strings.LastIndexByte(pat[:i], '/')
+		glob, err := parseGlob(pat[i+1:])
+		if err != nil {
+			return p, err
+		}
+		pat = pat[:i]
+		p.opt = patWildcard
+		p.glob = glob
 	}
 	if pat, ok = trimSuffix(pat, "/"); ok {
 		p.opt = patTrailingSlash
@@ -622,7 +1594,7 @@ func parsePattern(pat string) (pattern, error) {
 		} else {
 			part, pat = pat, ""
 		}
-		seg, err := parseSegment(part)
+		seg, err := parseSegment(part, custom)
 		if err != nil {
 			return p, err
 		}
@@ -637,7 +1609,31 @@ func parsePattern(pat string) (pattern, error) {
 	return p, nil
 }
 
+func (p pattern) hasParams() bool {
+	for _, seg := range p.segs {
+		if seg.isParam {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pattern) hasParam(name string) bool {
+	for _, seg := range p.segs {
+		if seg.isParam && seg.s == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (p pattern) compare(p1 pattern) int {
+	if p.priority != p1.priority {
+		if p.priority < p1.priority {
+			return -1
+		}
+		return 1
+	}
 	n := len(p.segs)
 	if n > len(p1.segs) {
 		n = len(p1.segs)
@@ -657,6 +1653,37 @@ func (p pattern) compare(p1 pattern) int {
 			if seg0.ptyp != seg1.ptyp {
 				return int(seg0.ptyp - seg1.ptyp)
 			}
+			if seg0.ptyp == paramCustom && seg0.custom != seg1.custom {
+				// Earlier-registered types are more specific.
+				if seg0.custom.order < seg1.custom.order {
+					return 1
+				}
+				return -1
+			}
+			if seg0.ptyp == paramStringLen {
+				// Narrower bounds are more specific.
+				w0 := seg0.maxLen - seg0.minLen
+				w1 := seg1.maxLen - seg1.minLen
+				if w0 != w1 {
+					return w1 - w0
+				}
+				if seg0.minLen != seg1.minLen {
+					return seg0.minLen - seg1.minLen
+				}
+			}
+			if seg0.ptyp == paramEnum {
+				// Distinct enums are ordered arbitrarily.
+				if c := compareStrings(seg0.enum, seg1.enum); c != 0 {
+					return c
+				}
+			}
+			if seg0.suffix != seg1.suffix {
+				// Longer suffixes are more specific.
+				if len(seg0.suffix) != len(seg1.suffix) {
+					return len(seg0.suffix) - len(seg1.suffix)
+				}
+				return strings.Compare(seg0.suffix, seg1.suffix)
+			}
 		} else {
 			if seg0.s != seg1.s {
 				return strings.Compare(seg0.s, seg1.s)
@@ -669,123 +1696,575 @@ func (p pattern) compare(p1 pattern) int {
 	if len(p1.segs) > n {
 		return -1
 	}
-	return int(p.opt - p1.opt)
+	if p.opt != p1.opt {
+		return int(p.opt - p1.opt)
+	}
+	if p.anySlash != p1.anySlash {
+		// Patterns which match either form of a path are less specific.
+		if p.anySlash {
+			return -1
+		}
+		return 1
+	}
+	// Wildcard patterns with globs are more specific than those without;
+	// distinct globs are ordered arbitrarily.
+	if (p.glob == nil) != (p1.glob == nil) {
+		if p.glob == nil {
+			return -1
+		}
+		return 1
+	}
+	return compareStrings(p.glob, p1.glob)
+}
+
+func compareStrings(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// A rule is a handler registered with a Builder for a method and pattern.
+type rule struct {
+	method string // "" for all methods
+	pat    string
+	p      pattern
+	h      http.Handler
+	meta   map[string]string
+	// mws are middlewares which the Mux applies to the handler when the
+	// rule matches (mws[0] is outermost).
+	mws []func(http.Handler) http.Handler
+	// responses are the declared responses (see Responds).
+	responses []responseSpec
+	// conds are request conditions which must be satisfied for the rule
+	// to match.
+	conds []condition
+	// streaming is set by Streaming.
+	streaming bool
+	// ranges is set by RangeRequests.
+	ranges bool
+	// condParams indicates that some of conds add params.
+	condParams bool
+	// produces is the media type set by Produces.
+	produces string
+	// name is set by Name.
+	name string
+	// fsErrors is set by FSErrors.
+	fsErrors func(w http.ResponseWriter, r *http.Request, err error)
+	// paramMaps are set by MapParam.
+	paramMaps []paramMap
+	// chain is h wrapped in mws (and in a contractHandler, if the Mux
+	// checks responses). It is built when the rule is bound to a Mux so
+	// that the middlewares are called once rather than for each request;
+	// it is nil if there is nothing to wrap. replacedChain is the same
+	// chain around a replacedHandler, for requests whose handler an
+	// OnMatch function replaced.
+	chain         http.Handler
+	replacedChain http.Handler
+}
+
+// A condition is a request predicate attached to a rule.
+type condition interface {
+	// check reports whether r satisfies the condition for rl, which has
+	// matched r with params p. It returns condOK if so. The condition may
+	// add params to p, which is non-nil if rl.condParams is set;
+	// otherwise, p may be nil.
+	// Otherwise, it returns either condSkip, to ignore the rule, or an
+	// HTTP status code with which the Mux should respond if no other rule
+	// for the same pattern and method matches.
+	check(r *http.Request, rl *rule, p *Params) int
+	// key identifies the condition. Rules for the same pattern and method
+	// conflict if their conditions have the same keys.
+	key() string
+}
+
+// A condResponder is a condition which writes its own response when it calls
+// for an error response, rather than leaving that to the Mux.
+type condResponder interface {
+	condition
+	respond(w http.ResponseWriter, r *http.Request, status int)
+}
+
+const (
+	condOK   = 0
+	condSkip = -1
+)
+
+// check evaluates the conditions of rl, returning the first result which is
+// not condOK along with the condition that produced it.
+func (rl *rule) check(r *http.Request, p *Params) (int, condition) {
+	for _, c := range rl.conds {
+		if status := c.check(r, rl, p); status != condOK {
+			return status, c
+		}
+	}
+	if len(rl.paramMaps) > 0 && !rl.mapParams(p) {
+		return condSkip, nil
+	}
+	return condOK, nil
+}
+
+func (rl *rule) condKey() string {
+	keys := make([]string, len(rl.conds))
+	for i, c := range rl.conds {
+		keys[i] = c.key()
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\x00")
+}
+
+func (rl *rule) route() Route {
+	rt := Route{
+		Method:        rl.method,
+		Pattern:       rl.pat,
+		Name:          rl.name,
+		Streaming:     rl.streaming,
+		RangeRequests: rl.ranges,
+	}
+	if len(rl.meta) > 0 {
+		rt.Meta = make(map[string]string, len(rl.meta))
+		for k, v := range rl.meta {
+			rt.Meta[k] = v
+		}
+	}
+	return rt
 }
 
+// A matcher holds all the rules that have equivalent patterns. For each
+// method, rules with conditions precede rules without them.
+//
+// The slices held by a matcher are never modified after they are created
+// (changes are made by replacing them), so clones of a matcher may share them.
 type matcher struct {
-	pat         pattern
-	byMethod    map[string]http.Handler
+	pat pattern
+	// common holds the rules for each of commonMethods, indexed by
+	// commonMethodIndex; other holds the rules for any other methods.
+	common      [len(commonMethods)][]*rule
+	other       []methodRules
 	methodNames []string
-	allMethods  http.Handler
+	allMethods  []*rule
+}
+
+type methodRules struct {
+	method string
+	rules  []*rule
+}
+
+// commonMethods are the methods for which a matcher stores rules in a
+// fixed-size table rather than a slice that must be searched.
+var commonMethods = [...]string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+func commonMethodIndex(method string) int {
+	switch method {
+	case http.MethodGet:
+		return 0
+	case http.MethodHead:
+		return 1
+	case http.MethodPost:
+		return 2
+	case http.MethodPut:
+		return 3
+	case http.MethodPatch:
+		return 4
+	case http.MethodDelete:
+		return 5
+	case http.MethodOptions:
+		return 6
+	}
+	return -1
+}
+
+// rules returns the rules of m for method, not including the rules for all
+// methods.
+func (m *matcher) rules(method string) []*rule {
+	if i := commonMethodIndex(method); i >= 0 {
+		return m.common[i]
+	}
+	for _, mr := range m.other {
+		if mr.method == method {
+			return mr.rules
+		}
+	}
+	return nil
+}
+
+// setRules replaces the rules of m for method.
+func (m *matcher) setRules(method string, rules []*rule) {
+	if i := commonMethodIndex(method); i >= 0 {
+		m.common[i] = rules
+		return
+	}
+	other := make([]methodRules, len(m.other), len(m.other)+1)
+	copy(other, m.other)
+	for i := range other {
+		if other[i].method == method {
+			other[i].rules = rules
+			m.other = other
+			return
+		}
+	}
+	m.other = append(other, methodRules{method, rules})
 }
 
 func (m *matcher) clone() *matcher {
 	m1 := *m
-	m1.byMethod = make(map[string]http.Handler)
-	for k, v := range m.byMethod {
-		m1.byMethod[k] = v
-	}
-	m1.methodNames = append([]string(nil), m.methodNames...)
 	return &m1
 }
 
+// forEachRule calls f for each rule of m, ordered by method with the
+// all-methods rules last.
+func (m *matcher) forEachRule(f func(rl *rule)) {
+	for _, method := range m.methodNames {
+		for _, rl := range m.rules(method) {
+			f(rl)
+		}
+	}
+	for _, rl := range m.allMethods {
+		f(rl)
+	}
+}
+
+// A muxBinder is a handler which needs a reference to the Mux that serves it.
+// When a Mux is built, such handlers are replaced by the result of bindMux.
+type muxBinder interface {
+	bindMux(m *Mux) http.Handler
+}
+
+// A condBinder is a condition which needs a reference to the Mux that
+// evaluates it or to the rule it belongs to. When a Mux is built, such
+// conditions are replaced by the result of bindCond.
+type condBinder interface {
+	bindCond(m *Mux, rl *rule) condition
+}
+
+// bind returns a copy of rl for mux in which the handler and conditions that
+// implement muxBinder and condBinder are replaced and the handler chain is
+// built (see rule.chain). If rl needs none of these, it returns false.
+func (rl *rule) bind(mux *Mux) (*rule, bool) {
+	var rl1 *rule
+	if mb, ok := rl.h.(muxBinder); ok {
+		r := *rl
+		rl1 = &r
+		rl1.h = mb.bindMux(mux)
+	}
+	if len(rl.mws) > 0 || mux.checkResponses != nil && len(rl.responses) > 0 {
+		if rl1 == nil {
+			r := *rl
+			rl1 = &r
+		}
+		rl1.chain = rl1.wrap(mux, rl1.h)
+		if len(mux.onMatch) > 0 {
+			rl1.replacedChain = rl1.wrap(mux, replacedHandler{})
+		}
+	}
+	var conds []condition
+	for i, c := range rl.conds {
+		cb, ok := c.(condBinder)
+		if !ok {
+			continue
+		}
+		if conds == nil {
+			conds = append([]condition(nil), rl.conds...)
+		}
+		conds[i] = cb.bindCond(mux, rl)
+	}
+	if conds != nil {
+		if rl1 == nil {
+			r := *rl
+			rl1 = &r
+		}
+		rl1.conds = conds
+	}
+	return rl1, rl1 != nil
+}
+
+// wrap returns h wrapped in the middlewares of rl and, if mux checks
+// responses, the check of rl's declared responses.
+func (rl *rule) wrap(mux *Mux, h http.Handler) http.Handler {
+	if mux.checkResponses != nil && len(rl.responses) > 0 {
+		h = contractHandler{h, rl, mux.checkResponses}
+	}
+	for i := len(rl.mws) - 1; i >= 0; i-- {
+		h = rl.mws[i](h)
+	}
+	return h
+}
+
+// A replacedHandler is the innermost handler of rule.replacedChain. It calls
+// the handler given by an OnMatch function, which serve passes in the
+// request context.
+type replacedHandler struct{}
+
+func (replacedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Context().Value(replacedHandlerKey).(http.Handler).ServeHTTP(w, r)
+}
+
+// sameHandler reports whether h0 and h1 are certainly the same handler. It
+// only compares pointers, since comparing other handlers (such as
+// http.HandlerFuncs) may panic; for those it returns false.
+func sameHandler(h0, h1 http.Handler) bool {
+	v0, v1 := reflect.ValueOf(h0), reflect.ValueOf(h1)
+	return v0.Kind() == reflect.Ptr && v0.Type() == v1.Type() && v0.Pointer() == v1.Pointer()
+}
+
+// bind returns a matcher for mux in which the rules of m are bound (see
+// rule.bind). If no rules need binding, it returns m; otherwise, it returns a
+// copy of m, since m may be shared with a Builder or other Muxes.
+func (m *matcher) bind(mux *Mux) *matcher {
+	bindRules := func(rules []*rule) ([]*rule, bool) {
+		var rules1 []*rule
+		for i, rl := range rules {
+			rl1, ok := rl.bind(mux)
+			if !ok {
+				continue
+			}
+			if rules1 == nil {
+				rules1 = append([]*rule(nil), rules...)
+			}
+			rules1[i] = rl1
+		}
+		return rules1, rules1 != nil
+	}
+	m1 := m
+	for _, method := range m.methodNames {
+		if rules, ok := bindRules(m.rules(method)); ok {
+			if m1 == m {
+				m1 = m.clone()
+			}
+			m1.setRules(method, rules)
+		}
+	}
+	if rules, ok := bindRules(m.allMethods); ok {
+		if m1 == m {
+			m1 = m.clone()
+		}
+		m1.allMethods = rules
+	}
+	return m1
+}
+
 type matchOpts uint8
 
 const (
 	optTrailingSlash matchOpts = 1 << iota
 	optStar
 	optReencode
+	optFoldCase
+	optSubtree // patterns ending with a slash match subtrees
+	optRaw     // path segments are escaped; see Builder.RawPathMatching
 )
 
 // A matchResult indicates how a matcher matches (or fails to match) a request.
-// There are three possibilities:
-//
-//  1. If the matcher matches the path and the method, h and p are set.
-//  2. If the matcher matches the path but not the method, allow is set to
+// There are four possibilities:
+//
+//  1. If the matcher matches the path, the method, and the conditions of a
+//     rule, rule and p are set.
+//  2. If the matcher matches the path and method but the conditions of the
+//     rules for the method call for an error response, status is set, along
+//     with the condition responsible.
+//  3. If the matcher matches the path but not the method, allow is set to
 //     indicate the Allow header in the 405 response.
-//  3. If the matcher doesn't match at all, match returns noMatch.
+//  4. If the matcher doesn't match at all, match returns noMatch.
 type matchResult struct {
-	h     http.Handler
-	p     *Params
-	allow string
+	rule    *rule
+	p       *Params
+	status  int
+	cond    condition // if status is set
+	allow   string
+	allowed *matcher // if allow is set
+	// negotiated indicates that the result depends on the Accept header
+	// (see Produces).
+	negotiated bool
+	scanned    int // number of matchers tried; see Match.Scanned
 }
 
 var noMatch matchResult
 
-func (m *matcher) match(method string, parts []string, opts matchOpts) matchResult {
-	switch m.pat.opt {
+// matchesPath reports whether mr results from a matcher matching the path.
+func (mr matchResult) matchesPath() bool {
+	return mr.rule != nil || mr.status != 0 || mr.allow != ""
+}
+
+func (m *matcher) match(r *http.Request, parts []string, opts matchOpts) matchResult {
+	p, ok := m.pat.match(parts, opts)
+	if !ok {
+		return noMatch
+	}
+	return m.matchMethod(r, p)
+}
+
+// match reports whether pat matches the path given by parts and opts,
+// returning the matched params (or nil, if there are none).
+func (pat pattern) match(parts []string, opts matchOpts) (*Params, bool) {
+	switch pat.opt {
 	case patOther:
-		if opts&optTrailingSlash != 0 {
-			return noMatch
+		if opts&optTrailingSlash != 0 && !pat.anySlash {
+			return nil, false
 		}
 	case patEmpty:
-		return m.matchMethod(method, nil)
+		return nil, true
 	case patStar:
 		if opts&optStar != 0 {
-			return m.matchMethod(method, nil)
+			return nil, true
 		}
-		return noMatch
+		return nil, false
 	case patTrailingSlash:
-		if opts&optTrailingSlash == 0 {
-			return noMatch
+		if opts&(optTrailingSlash|optSubtree) == 0 {
+			return nil, false
 		}
 	}
-	if m.pat.opt == patWildcard {
-		if len(parts) < len(m.pat.segs) {
-			return noMatch
+	wildcard := pat.opt == patWildcard ||
+		(pat.opt == patTrailingSlash && opts&optSubtree != 0)
+	if wildcard {
+		if len(parts) < len(pat.segs) {
+			return nil, false
 		}
 	} else {
-		if len(parts) != len(m.pat.segs) {
-			return noMatch
+		if len(parts) != len(pat.segs) {
+			return nil, false
 		}
 	}
 	var p *Params
 	for i, part := range parts {
-		if i == len(m.pat.segs) {
+		if i == len(pat.segs) {
 			break
 		}
-		seg := m.pat.segs[i]
+		seg := pat.segs[i]
 		if seg.isParam {
-			pr, ok := matchParam(seg, part, opts)
+			if seg.suffix != "" {
+				n := len(part) - len(seg.suffix)
+				if n <= 0 || !literalEqual(part[n:], seg.suffix, opts) {
+					return nil, false
+				}
+				part = part[:n]
+			}
+			if part == "" {
+				// Parameters are never empty. (An empty
+				// segment remains when matrix parameters are
+				// removed from ";x=1".)
+				return nil, false
+			}
+			pr, ok := matchParam(seg, part)
 			if !ok {
-				return noMatch
+				return nil, false
 			}
 			if p == nil {
 				p = new(Params)
 			}
 			p.ps = append(p.ps, pr)
 		} else {
-			if part != seg.s {
-				return noMatch
+			if !literalEqual(rawUnescape(part, opts), seg.s, opts) {
+				return nil, false
 			}
 		}
 	}
-	if m.pat.opt == patWildcard {
+	if wildcard {
 		// The pattern "/x/*" should not match requests for "/x".
 		// (But it should match "/x/".)
-		if len(parts) == len(m.pat.segs) && opts&optTrailingSlash == 0 {
-			return noMatch
+		if len(parts) == len(pat.segs) && opts&optTrailingSlash == 0 {
+			return nil, false
 		}
 		if p == nil {
 			p = new(Params)
 		}
-		p.wildcard = "/" + strings.Join(parts[len(m.pat.segs):], "/")
-		if opts&optReencode != 0 {
-			p.wildcard = mustPathUnescape(p.wildcard)
+		rest := parts[len(pat.segs):]
+		if pat.glob != nil {
+			if opts&optTrailingSlash != 0 || !matchGlob(pat.glob, rawUnescapeAll(rest, opts), opts) {
+				return nil, false
+			}
 		}
+		p.wildcard = "/" + strings.Join(rest, "/")
 		p.hasWildcard = true
 	}
-	return m.matchMethod(method, p)
+	return p, true
 }
 
-func (m *matcher) matchMethod(method string, p *Params) matchResult {
-	if h, ok := m.byMethod[method]; ok {
-		return matchResult{h: h, p: p}
+// literalEqual reports whether the path segment (or segment suffix) s matches
+// the literal lit.
+func literalEqual(s, lit string, opts matchOpts) bool {
+	if s == lit {
+		return true
+	}
+	if opts&optFoldCase == 0 || len(s) != len(lit) {
+		return false
 	}
-	if h := m.allMethods; h != nil {
-		return matchResult{h: h, p: p}
+	for i := 0; i < len(s); i++ {
+		if lowerASCII(s[i]) != lowerASCII(lit[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + ('a' - 'A')
 	}
-	return matchResult{allow: strings.Join(m.methodNames, ", ")}
+	return c
+}
+
+func (m *matcher) matchMethod(r *http.Request, p *Params) matchResult {
+	methodRules := m.rules(r.Method)
+	if len(methodRules) == 0 && len(m.allMethods) == 0 {
+		return matchResult{allow: strings.Join(m.methodNames, ", "), allowed: m}
+	}
+	result := noMatch
+	maxQ := -1.0 // computed for the first rule with a Produces option
+	for _, rules := range [2][]*rule{methodRules, m.allMethods} {
+		for _, rl := range rules {
+			if rl.produces != "" {
+				if maxQ < 0 {
+					maxQ = bestQuality(r, methodRules, m.allMethods)
+					result.negotiated = true
+				}
+				if acceptQuality(r.Header.Get("Accept"), rl.produces) < maxQ {
+					// Another rule produces a preferred
+					// media type.
+					continue
+				}
+			}
+			if p == nil && rl.condParams {
+				p = new(Params)
+			}
+			n := 0
+			if p != nil {
+				n = len(p.ps)
+				// The params were named using m.pat, but
+				// rules registered for different methods may
+				// use different names.
+				i := 0
+				for _, seg := range rl.p.segs {
+					if seg.isParam {
+						p.ps[i].name = seg.s
+						i++
+					}
+				}
+			}
+			status, c := rl.check(r, p)
+			if status == condOK {
+				return matchResult{rule: rl, p: p, negotiated: result.negotiated}
+			}
+			if status > 0 && result.status == 0 {
+				result.status = status
+				result.cond = c
+			}
+			if p != nil {
+				// Discard any params added by the conditions.
+				p.ps = p.ps[:n]
+			}
+		}
+	}
+	return result
 }
 
 func mustPathUnescape(s string) string {
@@ -798,72 +2277,154 @@ func mustPathUnescape(s string) string {
 	return s1
 }
 
-func (m *matcher) merge(method string, h http.Handler) bool {
-	if method == "" {
-		if m.allMethods != nil {
-			return false
+// merge adds rl to m. If rl conflicts with a rule of m, merge returns that
+// rule and leaves m unchanged.
+func (m *matcher) merge(rl *rule) (conflict *rule) {
+	if rl.method == "" {
+		rules, conflict := insertRule(m.allMethods, rl)
+		if conflict == nil {
+			m.allMethods = rules
 		}
-		m.allMethods = h
+		return conflict
+	}
+	rules0 := m.rules(rl.method)
+	rules, conflict := insertRule(rules0, rl)
+	if conflict != nil {
+		return conflict
 	}
-	return m.addMethodHandler(method, h)
+	if len(rules0) == 0 {
+		n := len(m.methodNames)
+		names := append(m.methodNames[:n:n], rl.method)
+		sort.Strings(names)
+		m.methodNames = names
+	}
+	m.setRules(rl.method, rules)
+	return nil
 }
 
-func (m *matcher) addMethodHandler(method string, h http.Handler) (added bool) {
-	if _, ok := m.byMethod[method]; ok {
-		return false
+// override is like merge except that rl replaces a conflicting rule of m
+// rather than being rejected.
+func (m *matcher) override(rl *rule) {
+	rules := m.allMethods
+	if rl.method != "" {
+		rules = m.rules(rl.method)
+	}
+	key := rl.condKey()
+	for i, rl1 := range rules {
+		if rl1.condKey() != key {
+			continue
+		}
+		rules1 := append([]*rule(nil), rules...)
+		rules1[i] = rl
+		if rl.method == "" {
+			m.allMethods = rules1
+		} else {
+			m.setRules(rl.method, rules1)
+		}
+		return
 	}
-	if m.byMethod == nil {
-		m.byMethod = make(map[string]http.Handler)
+	m.merge(rl)
+}
+
+// insertRule returns a new slice holding rules, which are for the same method
+// and equivalent patterns, and rl, unless rl conflicts with one of them, in
+// which case insertRule returns that rule. Rules with more conditions are
+// placed first; otherwise, rules are kept in registration order.
+func insertRule(rules []*rule, rl *rule) ([]*rule, *rule) {
+	key := rl.condKey()
+	for _, rl1 := range rules {
+		if rl1.condKey() == key {
+			return rules, rl1
+		}
 	}
-	m.byMethod[method] = h
-	m.methodNames = append(m.methodNames, method)
-	sort.Strings(m.methodNames)
-	return true
+	i := sort.Search(len(rules), func(i int) bool {
+		return len(rules[i].conds) < len(rl.conds)
+	})
+	rules1 := make([]*rule, 0, len(rules)+1)
+	rules1 = append(rules1, rules[:i]...)
+	rules1 = append(rules1, rl)
+	rules1 = append(rules1, rules[i:]...)
+	return rules1, nil
 }
 
 type contextKey int
 
-var paramKey contextKey
+const (
+	paramKey contextKey = iota
+	rewriteDepthKey
+	localeKey
+	replacedHandlerKey
+)
 
 type paramType int8
 
 const (
 	// In precedence order.
 	paramString paramType = iota
+	paramStringLen
+	paramCustom
+	paramHex
+	paramRFC3339
 	paramInt64
 	paramInt32
+	paramEnum
 )
 
 func (t paramType) String() string {
 	switch t {
-	case paramString:
+	case paramString, paramStringLen:
 		return "string"
 	case paramInt32:
 		return "int32"
 	case paramInt64:
 		return "int64"
+	case paramRFC3339:
+		return "rfc3339"
+	case paramHex:
+		return "hex"
+	case paramEnum:
+		return "enum"
+	case paramCustom:
+		return "custom"
 	default:
 		panic("bad paramType")
 	}
 }
 
 type param struct {
-	name string
-	val  string
-	n    int64
-	typ  paramType
+	name   string
+	val    string
+	n      int64
+	v      interface{} // parsed value of an rfc3339, hex, or custom type
+	typ    paramType
+	custom *customParamType
 }
 
-func matchParam(seg segment, s string, opts matchOpts) (p param, ok bool) {
+func (pp param) typeName() string {
+	if pp.typ == paramCustom {
+		return pp.custom.name
+	}
+	return pp.typ.String()
+}
+
+// matchParam matches the unescaped path segment s against the parameter
+// segment seg.
+func matchParam(seg segment, s string) (p param, ok bool) {
 	p.name = seg.s
 	p.typ = seg.ptyp
-	if opts&optReencode == 0 {
-		p.val = s
-	} else {
-		p.val = mustPathUnescape(s)
-	}
+	p.custom = seg.custom
+	p.val = s
 	switch p.typ {
 	case paramString:
+	case paramStringLen:
+		if n := utf8.RuneCountInString(s); n < seg.minLen || n > seg.maxLen {
+			return p, false
+		}
+	case paramEnum:
+		i := sort.SearchStrings(seg.enum, s)
+		if i == len(seg.enum) || seg.enum[i] != s {
+			return p, false
+		}
 	case paramInt32:
 		n, err := strconv.ParseInt(s, 10, 32)
 		if err != nil {
@@ -876,6 +2437,24 @@ func matchParam(seg segment, s string, opts matchOpts) (p param, ok bool) {
 			return p, false
 		}
 		p.n = n
+	case paramRFC3339:
+		t, err := time.Parse(time.RFC3339, p.val)
+		if err != nil {
+			return p, false
+		}
+		p.v = t
+	case paramHex:
+		b, err := hex.DecodeString(p.val)
+		if err != nil {
+			return p, false
+		}
+		p.v = b
+	case paramCustom:
+		v, err := seg.custom.parse(p.val)
+		if err != nil {
+			return p, false
+		}
+		p.v = v
 	}
 	return p, true
 }
@@ -886,13 +2465,23 @@ type Params struct {
 	ps          []param
 	wildcard    string
 	hasWildcard bool
+	matrix      []url.Values // by path segment; see Builder.MatrixParams
+
+	// The matched rule and the Mux that matched it.
+	rule *rule
+	mux  *Mux
 }
 
 func (p *Params) merge(p1 *Params) {
+	p.rule = p1.rule
+	p.mux = p1.mux
 	if p1.hasWildcard {
 		p.wildcard = p1.wildcard
 		p.hasWildcard = true
 	}
+	if p1.matrix != nil {
+		p.matrix = p1.matrix
+	}
 	ps0 := p.ps
 outer:
 	for _, pp1 := range p1.ps {
@@ -929,10 +2518,48 @@ func (p *Params) get(name string) param {
 //
 // Note that, by construction, a parameter value cannot be empty, so Get never
 // returns the empty string.
+//
+// The value may have been changed by an OnMatch function or a middleware
+// using Set.
 func (p *Params) Get(name string) string {
 	return p.get(name).val
 }
 
+// Set changes the value of a named string parameter. It panics if p does not
+// include a parameter matching the provided name, if the parameter has a type
+// other than string (including length-bounded strings and enums), or if
+// value is empty. Set does not check value against the bounds or values of
+// the parameter's type.
+//
+// Set allows an OnMatch function or a middleware to adjust the parameters
+// seen by the handler. For example, a middleware may canonicalize a username:
+//
+//	func canonicalUser(h http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			p := hmux.RequestParams(r)
+//			p.Set("user", strings.ToLower(p.Get("user")))
+//			h.ServeHTTP(w, r)
+//		})
+//	}
+func (p *Params) Set(name, value string) {
+	if value == "" {
+		panic("hmux: Set called with empty value")
+	}
+	for i, pp := range p.ps {
+		if pp.name != name {
+			continue
+		}
+		switch pp.typ {
+		case paramString, paramStringLen, paramEnum:
+		default:
+			panic(fmt.Sprintf("hmux: Set called on parameter %q of type %s", name, pp.typeName()))
+		}
+		p.ps[i].val = value
+		return
+	}
+	panic(fmt.Sprintf("hmux: route does not include a parameter named %q", name))
+}
+
 // Int returns the value of a named integer-typed parameter as an int.
 // It panics if p does not include a parameter matching the provided name
 // or if the parameter exists but does not have an integer type.
@@ -953,7 +2580,7 @@ func (p *Params) Int(name string) int {
 	case paramInt32, paramInt64:
 		return int(pp.n)
 	default:
-		panic(fmt.Sprintf("hmux: parameter %q has non-integer type %s", name, pp.typ))
+		panic(fmt.Sprintf("hmux: parameter %q has non-integer type %s", name, pp.typeName()))
 	}
 }
 
@@ -971,7 +2598,7 @@ func (p *Params) Int(name string) int {
 func (p *Params) Int32(name string) int32 {
 	pp := p.get(name)
 	if pp.typ != paramInt32 {
-		panic(fmt.Sprintf("hmux: parameter %q has type %s, not int32", name, pp.typ))
+		panic(fmt.Sprintf("hmux: parameter %q has type %s, not int32", name, pp.typeName()))
 	}
 	return int32(pp.n)
 }
@@ -993,7 +2620,74 @@ func (p *Params) Int64(name string) int64 {
 	case paramInt32, paramInt64:
 		return pp.n
 	default:
-		panic(fmt.Sprintf("hmux: parameter %q has non-integer type %s", name, pp.typ))
+		panic(fmt.Sprintf("hmux: parameter %q has non-integer type %s", name, pp.typeName()))
+	}
+}
+
+// Time returns the value of a named rfc3339-typed parameter.
+// It panics if p does not include a parameter matching the provided name
+// or if the parameter exists but does not have the rfc3339 type.
+//
+// The returned time has the UTC offset given in the URL. If loc is non-nil,
+// the time is converted to loc instead (as with time.Time.In).
+//
+// For example, if a rule is registered as
+//
+//	mux.Get("/events/since/:ts:rfc3339", handleEvents)
+//
+// then the timestamp may be retrieved in UTC inside handleEvents with
+//
+//	p.Time("ts", time.UTC)
+func (p *Params) Time(name string, loc *time.Location) time.Time {
+	pp := p.get(name)
+	if pp.typ != paramRFC3339 {
+		panic(fmt.Sprintf("hmux: parameter %q has type %s, not rfc3339", name, pp.typeName()))
+	}
+	t := pp.v.(time.Time)
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t
+}
+
+// Bytes returns the decoded value of a named hex-typed parameter.
+// It panics if p does not include a parameter matching the provided name
+// or if the parameter exists but does not have the hex type.
+//
+// For example, if a rule is registered as
+//
+//	mux.Get("/blobs/:sha:hex", handleBlob)
+//
+// then the digest may be retrieved inside handleBlob with
+//
+//	p.Bytes("sha")
+func (p *Params) Bytes(name string) []byte {
+	pp := p.get(name)
+	if pp.typ != paramHex {
+		panic(fmt.Sprintf("hmux: parameter %q has type %s, not hex", name, pp.typeName()))
+	}
+	return append([]byte(nil), pp.v.([]byte)...)
+}
+
+// Value returns the value of a named parameter as parsed according to its
+// type: a string for string parameters, an int32 or int64 for integer
+// parameters, a time.Time for rfc3339 parameters, a []byte for hex
+// parameters, and the value returned by the parse function for custom
+// parameter types (see Builder.RegisterParamType). It panics if p does not
+// include a parameter matching the provided name.
+func (p *Params) Value(name string) interface{} {
+	pp := p.get(name)
+	switch pp.typ {
+	case paramInt32:
+		return int32(pp.n)
+	case paramInt64:
+		return pp.n
+	case paramRFC3339, paramCustom:
+		return pp.v
+	case paramHex:
+		return append([]byte(nil), pp.v.([]byte)...)
+	default:
+		return pp.val
 	}
 }
 
@@ -1014,8 +2708,23 @@ func (p *Params) Wildcard() string {
 }
 
 // RequestParams retrieves the Params previously registered via matching a Mux
-// rule. It returns nil if there are no params in the rule.
+// rule. It returns nil if there are no params in the rule (and no matrix
+// parameters; see Builder.MatrixParams).
 func RequestParams(r *http.Request) *Params {
+	return requestParams(r).orNil()
+}
+
+// orNil returns p, or nil if p holds no parameters.
+func (p *Params) orNil() *Params {
+	if p == nil || (len(p.ps) == 0 && !p.hasWildcard && p.matrix == nil) {
+		return nil
+	}
+	return p
+}
+
+// requestParams is like RequestParams but it returns the Params stored in
+// r even if they hold no parameters.
+func requestParams(r *http.Request) *Params {
 	p, _ := r.Context().Value(paramKey).(*Params)
 	return p
 }
@@ -8,10 +8,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 )
 
 func TestRedirects(t *testing.T) {
@@ -44,6 +46,21 @@ func TestSpecialPatterns(t *testing.T) {
 	testRequests(t, b.Build(), testCases)
 }
 
+func TestOptions(t *testing.T) {
+	b := NewBuilder()
+	b.Options("*", testHandler("server options"))
+	b.Options("/a", testHandler("a options"))
+	b.Get("/a", testHandler("a"))
+	testCases := []reqTest{
+		{"OPTIONS", "*", "server options"},
+		{"GET", "*", "405 OPTIONS"},
+		{"OPTIONS", "/a", "a options"},
+		{"GET", "/a", "a"},
+		{"POST", "/a", "405 GET, OPTIONS"},
+	}
+	testRequests(t, b.Build(), testCases)
+}
+
 // Test the example presented in doc comments.
 func TestDocPriorities(t *testing.T) {
 	b := NewBuilder()
@@ -51,7 +68,7 @@ func TestDocPriorities(t *testing.T) {
 	b.Get("/x/:p:int32", testHandler("B"))
 	b.Get("/x/:p", testHandler("C"))
 	b.Get("/:p/y", testHandler("D"))
-	b.Handle("", "/x/y", testHandler("E"))
+	b.Any("/x/y", testHandler("E"))
 
 	testCases := []reqTest{
 		{"GET", "/x/y", "A"},
@@ -168,6 +185,20 @@ func Test405(t *testing.T) {
 	testRequests(t, b.Build(), testCases)
 }
 
+func TestParamNamesByMethod(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x/:a", testHandler("get %s", "a"))
+	b.Put("/x/:b", testHandler("put %s", "b"))
+	b.Handle("", "/x/:c", testHandler("any %s", "c"))
+
+	testCases := []reqTest{
+		{"GET", "/x/1", "get 1"},
+		{"PUT", "/x/2", "put 2"},
+		{"POST", "/x/3", "any 3"},
+	}
+	testRequests(t, b.Build(), testCases)
+}
+
 func TestNonStandardMethod(t *testing.T) {
 	b := NewBuilder()
 	b.Get("/x/y", testHandler("a"))
@@ -182,6 +213,339 @@ func TestNonStandardMethod(t *testing.T) {
 	testRequests(t, b.Build(), testCases)
 }
 
+func TestBuilderReuse(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler("get"))
+	b.Handle("MYMETHOD", "/x", testHandler("my"))
+	mux1 := b.Build()
+	b.Put("/x", testHandler("put"))
+	b.Handle("OTHER", "/x", testHandler("other"))
+	b.Handle("MYMETHOD", "/x", testHandler("my2"), Active(time.Time{}, time.Now().Add(time.Hour)))
+	mux2 := b.Build()
+
+	testRequests(t, mux1, []reqTest{
+		{"GET", "/x", "get"},
+		{"MYMETHOD", "/x", "my"},
+		{"PUT", "/x", "405 GET, MYMETHOD"},
+	})
+	testRequests(t, mux2, []reqTest{
+		{"GET", "/x", "get"},
+		{"MYMETHOD", "/x", "my2"},
+		{"OTHER", "/x", "other"},
+		{"PUT", "/x", "put"},
+		{"POST", "/x", "405 GET, MYMETHOD, OTHER, PUT"},
+	})
+}
+
+func TestBuildSharesMatchers(t *testing.T) {
+	b := NewBuilder()
+	for i := 0; i < 100; i++ {
+		b.Get(fmt.Sprintf("/x%d/:id", i), testHandler(""))
+	}
+	b.ServeRobots()
+	mux1 := b.Build()
+	mux2 := b.Build()
+	for i, ma := range mux1.matchers {
+		shared := ma == mux2.matchers[i]
+		if want := ma.pat.segs[0].s != "robots.txt"; shared != want {
+			t.Errorf("matcher %d: got shared=%t; want %t", i, shared, want)
+		}
+	}
+}
+
+func TestBuildWith(t *testing.T) {
+	base := NewBuilder()
+	base.Get("/", testHandler("home"))
+	base.Get("/users/:id", testHandler("user %s", "id"))
+	base.Handle("", "/any", testHandler("any"))
+	base.Post("/login", testHandler("login"))
+
+	acme := NewBuilder()
+	acme.Get("/", testHandler("acme home"))
+	acme.Get("/users/:name", testHandler("acme user %s", "name"))
+	acme.Handle("", "/any", testHandler("acme any"))
+	acme.Get("/login", testHandler("acme login form"))
+	acme.Get("/reports/*", testHandler("acme reports %s", "*"))
+	acmeMux := base.BuildWith(acme)
+	baseMux := base.Build()
+
+	testRequests(t, acmeMux, []reqTest{
+		{"GET", "/", "acme home"},
+		{"GET", "/users/bob", "acme user bob"},
+		{"PUT", "/any", "acme any"},
+		{"GET", "/login", "acme login form"},
+		{"POST", "/login", "login"},
+		{"GET", "/reports/a/b", "acme reports /a/b"},
+	})
+	testRequests(t, baseMux, []reqTest{
+		{"GET", "/", "home"},
+		{"GET", "/users/bob", "user bob"},
+		{"PUT", "/any", "any"},
+		{"GET", "/login", "405 POST"},
+		{"GET", "/reports/a/b", "404"},
+	})
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	b := NewBuilder()
+	b.CaseInsensitive(true)
+	b.Get("/admin/:page", testHandler("admin %s", "page"))
+	b.Get("/reports/:name.json", testHandler("report %s", "name"))
+	b.Get("/caf%C3%A9", testHandler("café"))
+	testCases := []reqTest{
+		{"GET", "/admin/Users", "admin Users"},
+		{"GET", "/Admin/Users", "admin Users"},
+		{"GET", "/ADMIN/x", "admin x"},
+		{"GET", "/REPORTS/Q3.JSON", "report Q3"},
+		{"GET", "/CAF%C3%A9", "café"},
+		{"GET", "/CAF%C3%89", "404"}, // only ASCII is folded
+	}
+	testRequests(t, b.Build(), testCases)
+
+	b.CaseInsensitive(false)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/admin/Users", "admin Users"},
+		{"GET", "/Admin/Users", "404"},
+	})
+}
+
+func TestServeMuxCompatible(t *testing.T) {
+	patterns := []string{
+		"/",
+		"/static/",
+		"/static/img/",
+		"/api",
+		"/api/",
+		"/docs/",
+		"/docs",
+		"/x/y",
+	}
+	b := NewBuilder()
+	b.ServeMuxCompatible(true)
+	sm := http.NewServeMux()
+	for _, pat := range patterns {
+		b.Handle("", pat, testHandler(pat))
+		sm.Handle(pat, testHandler(pat))
+	}
+	mux := b.Build()
+
+	for _, pth := range []string{
+		"/",
+		"/a/b",
+		"/static",
+		"/static/",
+		"/static/css/a.css",
+		"/static/img",
+		"/static/img/a.png",
+		"/api",
+		"/api/",
+		"/api/v1",
+		"/docs",
+		"/docs/a",
+		"/x",
+		"/x/y",
+		"/x/y/",
+		"/x//y",
+		"/static/../api?q=1",
+		"/static?q=1",
+		"/a/./b/",
+	} {
+		w0 := httptest.NewRecorder()
+		sm.ServeHTTP(w0, httptest.NewRequest("GET", pth, nil))
+		w1 := httptest.NewRecorder()
+		mux.ServeHTTP(w1, httptest.NewRequest("GET", pth, nil))
+		want := fmt.Sprintf("%d %q %q", w0.Code, w0.Header().Get("Location"), w0.Body)
+		got := fmt.Sprintf("%d %q %q", w1.Code, w1.Header().Get("Location"), w1.Body)
+		if got != want {
+			t.Errorf("GET %s: got %s; http.ServeMux gives %s", pth, got, want)
+		}
+	}
+
+	b.ServeMuxCompatible(false)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/static", "404"},
+		{"GET", "/static/css/a.css", "404"},
+		{"GET", "/x//y", "308 /x/y"},
+	})
+}
+
+func TestPriority(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/users/new", testHandler("new"))
+	b.Get("/users/:id", testHandler("user %s", "id"), Priority(1))
+	b.Get("/users/:id", testHandler("fallback user %s", "id"))
+	b.Get("/a/b", testHandler("a/b"))
+	b.Get("/a/*", testHandler("a/*"), Priority(-1))
+	b.Get("/:p/b", testHandler("%s/b", "p"))
+	b.Post("/x/:p", testHandler("post %s", "p"), Priority(2))
+	b.Get("/x/y", testHandler("x/y"))
+
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/users/new", "user new"},
+		{"GET", "/users/3", "user 3"},
+		{"GET", "/a/b", "a/b"},
+		{"GET", "/c/b", "c/b"},
+		{"GET", "/a/c", "a/*"},
+		{"GET", "/x/y", "x/y"},
+		{"POST", "/x/y", "post y"},
+	})
+}
+
+func TestManyLiterals(t *testing.T) {
+	b := NewBuilder()
+	for i := 0; i < 100; i++ {
+		b.Get(fmt.Sprintf("/lit%d", i), testHandler(fmt.Sprintf("lit%d", i)))
+		b.Get(fmt.Sprintf("/lit%d/:p", i), testHandler(fmt.Sprintf("lit%d %%s", i), "p"))
+	}
+	b.Get("/lit5/x/*", testHandler("lit5 wild %s", "*"))
+	b.Get("/:p", testHandler("param %s", "p"))
+	b.Get("/:p/x", testHandler("param %s x", "p"))
+	b.Get("/", testHandler("index"))
+	b.Get("*", testHandler("star"))
+	testCases := []reqTest{
+		{"GET", "/lit0", "lit0"},
+		{"GET", "/lit99", "lit99"},
+		{"GET", "/lit42/a", "lit42 a"},
+		{"GET", "/lit5/x/y/z", "lit5 wild /y/z"},
+		{"GET", "/lit5/x", "lit5 x"},
+		{"GET", "/lit100", "param lit100"},
+		{"GET", "/lit100/x", "param lit100 x"},
+		{"GET", "/lit1/x/y", "404"},
+		{"GET", "/a", "param a"},
+		{"GET", "/", "index"},
+		{"GET", "*", "star"},
+		{"POST", "/lit7", "405 GET"},
+	}
+	testRequests(t, b.Build(), testCases)
+
+	b.CaseInsensitive(true)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/LIT7", "lit7"},
+		{"GET", "/Lit42/a", "lit42 a"},
+	})
+}
+
+func TestHandleMethods(t *testing.T) {
+	b := NewBuilder()
+	b.HandleMethods([]string{"GET", "HEAD", "PROPFIND"}, "/x/:p", testHandler("x %s", "p"))
+	b.Post("/y", testHandler("post y"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/x/a", "x a"},
+		{"PROPFIND", "/x/b", "x b"},
+		{"POST", "/x/a", "405 GET, HEAD, PROPFIND"},
+	})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("HandleMethods with a conflicting method did not panic")
+			}
+		}()
+		b.HandleMethods([]string{"PUT", "POST"}, "/y", testHandler("y"))
+	}()
+	// The PUT rule must not have been registered.
+	testRequests(t, b.Build(), []reqTest{
+		{"PUT", "/y", "405 POST"},
+	})
+
+	for _, methods := range [][]string{nil, {"GET", ""}, {"GET", "GET"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("HandleMethods(%q, ...) did not panic", methods)
+				}
+			}()
+			b.HandleMethods(methods, "/z", testHandler("z"))
+		}()
+	}
+}
+
+func TestHandlePatterns(t *testing.T) {
+	b := NewBuilder()
+	b.HandlePatterns("GET", []string{"/v1/users/:id", "/users/:id"}, testHandler("user %s", "id"))
+	b.Get("/old", testHandler("old"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/v1/users/3", "user 3"},
+		{"GET", "/users/4", "user 4"},
+	})
+
+	for _, pats := range [][]string{
+		nil,
+		{"/new", "/old"},
+		{"/new", "/a//b"},
+		{"/new", "/new"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("HandlePatterns(%q) did not panic", pats)
+				}
+			}()
+			b.HandlePatterns("GET", pats, testHandler("new"))
+		}()
+	}
+	// None of the failed registrations took effect.
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/new", "404"},
+		{"GET", "/old", "old"},
+	})
+}
+
+func TestTryHandle(t *testing.T) {
+	b := NewBuilder()
+	if err := b.TryHandle("GET", "/x/:id", testHandler("x %s", "id")); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"bad pattern",
+			b.TryHandle("GET", "/a//b", testHandler("")),
+			"hmux: pattern contains //",
+		},
+		{
+			"conflict",
+			b.TryHandle("GET", "/x/:name", testHandler("")),
+			`hmux: GET "/x/:name" conflicts with previously registered pattern "/x/:id"`,
+		},
+		{
+			"nil handler",
+			b.TryHandle("GET", "/y", nil),
+			"hmux: Handle called with nil handler",
+		},
+		{
+			"no methods",
+			b.TryHandleMethods(nil, "/y", testHandler("")),
+			"hmux: HandleMethods called with no methods",
+		},
+		{
+			"methods conflict",
+			b.TryHandleMethods([]string{"PUT", "GET"}, "/x/:name", testHandler("")),
+			`hmux: GET "/x/:name" conflicts with previously registered pattern "/x/:id"`,
+		},
+		{
+			"patterns conflict",
+			b.TryHandlePatterns("GET", []string{"/z", "/x/:name"}, testHandler("")),
+			`hmux: GET "/x/:name" conflicts with previously registered pattern "/x/:id"`,
+		},
+	} {
+		if tt.err == nil {
+			t.Errorf("%s: got nil error", tt.name)
+		} else if got := tt.err.Error(); got != tt.want {
+			t.Errorf("%s: got error %q; want %q", tt.name, got, tt.want)
+		}
+	}
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/x/3", "x 3"},
+		{"PUT", "/x/3", "405 GET"},
+		{"GET", "/y", "404"},
+		{"GET", "/z", "404"},
+	})
+}
+
 func TestNestedMuxes(t *testing.T) {
 	b0 := NewBuilder()
 	b0.Get("/x", testHandler("a"))
@@ -292,6 +656,9 @@ func TestPathEncoding(t *testing.T) {
 		{"GET", "/a/bc/x/def", "404"},
 		{"GET", "/%2E/a%2f/%2E%2E", "non-canonical"},
 		{"GET", "/:param:int32/foo", "fake param"},
+		{"GET", "/abc/%2525/def", "%25"},
+		{"GET", "/abc/%25/def", "%"},
+		{"GET", "/xyz/%2525/a%2fb", "xyz /%25/a/b"},
 	}
 	testRequests(t, b.Build(), testCases)
 }
@@ -318,6 +685,27 @@ func TestParams(t *testing.T) {
 			"int64:int64",
 		),
 	)
+	b.Get("/t/:ts:rfc3339", testHandler("rfc3339 %s", "ts:time"))
+	b.Get("/t/:foo", testHandler("/t/string %s", "foo"))
+	b.Get("/h/:h:hex", testHandler("hex %q", "h:bytes"))
+	b.Get("/h/:foo", testHandler("/h/string %s", "foo"))
+	b.Get("/e/:state:enum(open|closed|merged)", testHandler("enum %s", "state"))
+	b.Get("/e/:state:enum(draft|new%20item)", testHandler("enum2 %s", "state"))
+	b.Get("/e/:n:int32", testHandler("/e/int32 %d", "n:int32"))
+	b.Get("/e/:foo", testHandler("/e/string %s", "foo"))
+	b.Get("/e2/:n:enum(1|2)", testHandler("/e2/enum %s", "n"))
+	b.Get("/e2/:n:int32", testHandler("/e2/int32 %d", "n:int32"))
+	b.Get("/s/:slug:string(1,3)", testHandler("short %s", "slug"))
+	b.Get("/s/:slug:string(4,8)", testHandler("long %s", "slug"))
+	b.Get("/s/:slug:string(1,8)", testHandler("any %s", "slug"))
+	b.Get("/s/:slug", testHandler("/s/string %s", "slug"))
+	b.Get("/r/:name.json", testHandler("json %s", "name"))
+	b.Get("/r/:name.csv", testHandler("csv %s", "name"))
+	b.Get("/r/:name.tar.gz", testHandler("tar.gz %s", "name"))
+	b.Get("/r/:name.gz", testHandler("gz %s", "name"))
+	b.Get("/r/:id:int32.json", testHandler("int32 json %d", "id:int32"))
+	b.Get("/r/:state:enum(a.b|c).json", testHandler("enum json %s", "state"))
+	b.Get("/r/:foo", testHandler("/r/string %s", "foo"))
 	b.Get("/y/:foo/", testHandler("trailing slash %s", "foo"))
 	b.Get("/yy/:foo", testHandler("no trailing slash %s", "foo"))
 	b.Get("/z/:f%6fo", testHandler("foo %s", "f%6fo")) // param name isn't escaped
@@ -350,6 +738,37 @@ func TestParams(t *testing.T) {
 		{"GET", "/9223372036854775808", "string 9223372036854775808"},
 		{"GET", "/x/-123", "/x/int64 int=-123 int64=-123"},
 		{"GET", "/x/123", "/x/int64 int=123 int64=123"},
+		{"GET", "/t/2006-01-02T15:04:05Z", "rfc3339 2006-01-02T15:04:05Z"},
+		{"GET", "/t/2006-01-02T15:04:05.5-07:00", "rfc3339 2006-01-02T22:04:05.5Z"},
+		{"GET", "/t/2006-01-02T15:04:05%2B01:00", "rfc3339 2006-01-02T14:04:05Z"},
+		{"GET", "/t/2006-01-02", "/t/string 2006-01-02"},
+		{"GET", "/t/2006-13-02T15:04:05Z", "/t/string 2006-13-02T15:04:05Z"},
+		{"GET", "/h/00ff", `hex "\x00\xff"`},
+		{"GET", "/h/4A4b", `hex "JK"`},
+		{"GET", "/h/abc", "/h/string abc"},
+		{"GET", "/h/zz", "/h/string zz"},
+		{"GET", "/e/open", "enum open"},
+		{"GET", "/e/merged", "enum merged"},
+		{"GET", "/e/draft", "enum2 draft"},
+		{"GET", "/e/new%20item", "enum2 new item"},
+		{"GET", "/e/opened", "/e/string opened"},
+		{"GET", "/e/3", "/e/int32 3"},
+		{"GET", "/e2/2", "/e2/enum 2"},
+		{"GET", "/e2/3", "/e2/int32 3"},
+		{"GET", "/s/abc", "short abc"},
+		{"GET", "/s/%C3%A9t%C3%A9", "short été"},
+		{"GET", "/s/abcd", "long abcd"},
+		{"GET", "/s/abcdefgh", "long abcdefgh"},
+		{"GET", "/s/abcdefghi", "/s/string abcdefghi"},
+		{"GET", "/r/q3.json", "json q3"},
+		{"GET", "/r/q3.csv", "csv q3"},
+		{"GET", "/r/q3.v2.csv", "csv q3.v2"},
+		{"GET", "/r/q3.tar.gz", "tar.gz q3"},
+		{"GET", "/r/q3.gz", "gz q3"},
+		{"GET", "/r/12.json", "int32 json 12"},
+		{"GET", "/r/a.b.json", "enum json a.b"},
+		{"GET", "/r/.json", "/r/string .json"},
+		{"GET", "/r/q3.xml", "/r/string q3.xml"},
 		{"GET", "/y/123", "404"},
 		{"GET", "/y/123/", "trailing slash 123"},
 		{"GET", "/yy", "string yy"},
@@ -362,6 +781,32 @@ func TestParams(t *testing.T) {
 	testRequests(t, b.Build(), testCases)
 }
 
+func TestParamsTime(t *testing.T) {
+	b := NewBuilder()
+	var p *Params
+	b.Get("/:ts:rfc3339", func(_ http.ResponseWriter, r *http.Request) {
+		p = RequestParams(r)
+	})
+	b.Build().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/2006-01-02T15:04:05+07:00", nil))
+	if p == nil {
+		t.Fatal("rfc3339 rule did not match")
+	}
+	want := time.Date(2006, 1, 2, 8, 4, 5, 0, time.UTC)
+	got := p.Time("ts", nil)
+	if !got.Equal(want) {
+		t.Errorf("Time: got %s; want %s", got, want)
+	}
+	if _, offset := got.Zone(); offset != 7*60*60 {
+		t.Errorf("Time with nil location: got offset %d; want %d", offset, 7*60*60)
+	}
+	if got := p.Time("ts", time.UTC); got != want {
+		t.Errorf("Time with UTC: got %s; want %s", got, want)
+	}
+	if got := p.Value("ts"); !got.(time.Time).Equal(want) {
+		t.Errorf("Value: got %v; want %s", got, want)
+	}
+}
+
 func TestMalformedPattern(t *testing.T) {
 	for _, tt := range []struct {
 		pat  string
@@ -369,9 +814,10 @@ func TestMalformedPattern(t *testing.T) {
 	}{
 		{"/a//", errPatternSlash},
 		{"a/", errPatternWithoutSlash},
-		{"/a*/b", errSegmentStar},
-		{"/a*b", errSegmentStar},
-		{"/a/b*/", errSegmentStar},
+		{"/a/b*/", "glob ends with /"},
+		{"/a/**b", errGlobSegment},
+		{"/a/***", errGlobSegment},
+		{"/a/*.css/:x", "glob contains a parameter"},
 		{"/:", errEmptyParamName},
 		{"/:/foo", errEmptyParamName},
 		{"/::int32", errEmptyParamName},
@@ -381,6 +827,17 @@ func TestMalformedPattern(t *testing.T) {
 		{"/:x:str", "unknown parameter type"},
 		{"/:x:int", "unknown parameter type"},
 		{"/:x:", "unknown parameter type"},
+		{"/:x:enum", "unknown parameter type"},
+		{"/:x:enum(a|b", "unknown parameter type"},
+		{"/:x:enum()", "empty value"},
+		{"/:x:enum(a||b)", "empty value"},
+		{"/:x:enum(a|b|a)", "duplicate value"},
+		{"/:.json", errEmptyParamName},
+		{"/:x:int32.json/:x.csv", "duplicate parameter"},
+		{"/:x:string(1)", "not of the form"},
+		{"/:x:string(a,b)", "not of the form"},
+		{"/:x:string(0,5)", "invalid bounds"},
+		{"/:x:string(5,4)", "invalid bounds"},
 		{"/:x/:y/:x:int32", "duplicate parameter"},
 	} {
 		mux := NewBuilder()
@@ -444,6 +901,13 @@ outer:
 			{method: "", pat: "/x"},
 			{method: "", pat: "/x"},
 		},
+		{
+			{method: "GET", pat: "/x/:a:int32"},
+			{method: "GET", pat: "/x/:a:int64"},
+			{method: "GET", pat: "/x/:b"},
+			{method: "", pat: "/x/:c"},
+			{method: "GET", pat: "/x/:d:int32"},
+		},
 	} {
 		b := NewBuilder()
 		h := testHandler("x")
@@ -460,7 +924,7 @@ outer:
 			t.Errorf(`handle(%q, %q, h) (last): got nil error; want conflict`, rule.method, rule.pat)
 			continue
 		}
-		if !strings.Contains(err.Error(), "conflicts with previously registered pattern") {
+		if !strings.Contains(err.Error(), "conflicts with previously registered pattern "+strconv.Quote(rules[0].pat)) {
 			t.Errorf(`handle(%q, %q, h) (last): got %s; want conflict error`, rule.method, rule.pat, err)
 			continue
 		}
@@ -496,6 +960,44 @@ func TestServeFile(t *testing.T) {
 	testRequests(t, b.Build(), testCases)
 }
 
+func TestServeFileDir(t *testing.T) {
+	td := t.TempDir()
+	for name, data := range map[string]string{
+		"secret.txt":         "secret",
+		"root/a.txt":         "a",
+		"root/sub/b.txt":     "b",
+		"root/sub/style.css": "css",
+	} {
+		name = filepath.Join(td, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatalf("error writing %s: %s", name, err)
+		}
+	}
+
+	root := filepath.Join(td, "root")
+	b := NewBuilder()
+	b.ServeFile("/downloads/*", root)
+	b.ServeFile("/styles/**/*.css", root)
+	b.ServeFile("/single/", filepath.Join(root, "a.txt"))
+
+	testCases := []reqTest{
+		{"GET", "/downloads/a.txt", "a"},
+		{"HEAD", "/downloads/a.txt", ""},
+		{"GET", "/downloads/sub/b.txt", "b"},
+		{"GET", "/downloads/nope.txt", "404"},
+		{"GET", "/downloads/..%2fsecret.txt", "404"},
+		{"GET", "/downloads/sub/..%2f..%2fsecret.txt", "404"},
+		{"POST", "/downloads/a.txt", "405 GET, HEAD"},
+		{"GET", "/styles/sub/style.css", "css"},
+		{"GET", "/styles/sub/b.txt", "404"},
+		{"GET", "/single/", "a"},
+	}
+	testRequests(t, b.Build(), testCases)
+}
+
 func TestServeFS(t *testing.T) {
 	fsys := fstest.MapFS{
 		"hello.txt": &fstest.MapFile{
@@ -519,13 +1021,130 @@ func TestServeFS(t *testing.T) {
 	testRequests(t, b.Build(), testCases)
 }
 
+func TestNormalize(t *testing.T) {
+	b := NewBuilder()
+	b.Normalize(func(r *http.Request) *http.Request {
+		// Strip legacy ;jsessionid= suffixes.
+		i := strings.Index(r.URL.Path, ";jsessionid=")
+		if i < 0 {
+			return r
+		}
+		r1 := r.Clone(r.Context())
+		r1.URL.Path = r.URL.Path[:i]
+		if j := strings.Index(r.URL.RawPath, ";jsessionid="); j >= 0 {
+			r1.URL.RawPath = r.URL.RawPath[:j]
+		}
+		return r1
+	})
+	b.Normalize(func(r *http.Request) *http.Request {
+		r.Header.Set("X-Normalized", "true")
+		return r
+	})
+	b.Get("/a/:name", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", RequestParams(r).Get("name"), r.Header.Get("X-Normalized"))
+	})
+	b.Get("/x/*", testHandler("x %s", "*"))
+
+	testCases := []reqTest{
+		{"GET", "/a/b", "b true"},
+		{"GET", "/a/b;jsessionid=123", "b true"},
+		{"GET", "/a/b%2fc;jsessionid=123", "b/c true"},
+		{"GET", "/x/a/.;jsessionid=123", "308 /x/a"},
+		{"GET", "/x/a%2fb", "x /a/b"},
+	}
+	testRequests(t, b.Build(), testCases)
+}
+
+func TestOnMatch(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/tenants/:tenant/x", testHandler("new %s", "tenant"))
+	b.Handle("", "/y", testHandler("y"))
+	var routes []string
+	b.OnMatch(func(r *http.Request, m *Match) {
+		routes = append(routes, fmt.Sprintf("%s %q", r.Method, m.Route.Method+" "+m.Route.Pattern))
+	})
+	b.OnMatch(func(r *http.Request, m *Match) {
+		if m.Params != nil && m.Params.Get("tenant") == "legacy" {
+			m.Handler = testHandler("old %s", "tenant")
+		}
+	})
+
+	testCases := []reqTest{
+		{"GET", "/tenants/acme/x", "new acme"},
+		{"GET", "/tenants/legacy/x", "old legacy"},
+		{"POST", "/tenants/legacy/x", "405 GET"},
+		{"POST", "/y", "y"},
+		{"GET", "/z", "404"},
+	}
+	testRequests(t, b.Build(), testCases)
+
+	want := []string{
+		`GET "GET /tenants/:tenant/x"`,
+		`GET "GET /tenants/:tenant/x"`,
+		`POST " /y"`,
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("got matches\n%q\nwant\n%q", routes, want)
+	}
+}
+
+func TestMatchScanned(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/a/x", testHandler(""))
+	b.Get("/a/:p", testHandler(""))
+	b.Get("/b", testHandler(""))
+	b.Get("/:p/z", testHandler(""))
+	b.Get("/:p/:q", testHandler(""))
+	var scanned int
+	b.OnMatch(func(r *http.Request, m *Match) {
+		scanned = m.Scanned
+	})
+	mux := b.Build()
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/a/x", 1},
+		{"/a/q", 2},
+		{"/b", 1},
+		{"/c/z", 1},
+		{"/c/w", 2},
+	} {
+		scanned = 0
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+		if scanned != tt.want {
+			t.Errorf("GET %s: got Scanned=%d; want %d", tt.path, scanned, tt.want)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x/:a", testHandler(""))
+	b.Put("/x/:b", testHandler(""))
+	b.Handle("", "/x/:c", testHandler(""))
+	b.Get("/x/y", testHandler(""), Streaming())
+	b.Prefix("/p", testHandler(""))
+	got := b.Build().Routes()
+	want := []Route{
+		{Method: "GET", Pattern: "/x/y", Streaming: true},
+		{Method: "GET", Pattern: "/x/:a"},
+		{Method: "PUT", Pattern: "/x/:b"},
+		{Method: "", Pattern: "/x/:c"},
+		{Method: "", Pattern: "/p"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got routes\n%+v\nwant\n%+v", got, want)
+	}
+}
+
 type reqTest struct {
 	method string
 	path   string
 	want   string
 }
 
-func testRequests(t *testing.T, mux *Mux, tests []reqTest) {
+func testRequests(t *testing.T, mux http.Handler, tests []reqTest) {
 	t.Helper()
 	for _, tt := range tests {
 		w := httptest.NewRecorder()
@@ -533,7 +1152,7 @@ func testRequests(t *testing.T, mux *Mux, tests []reqTest) {
 		mux.ServeHTTP(w, r)
 
 		switch {
-		case tt.want == "404" || tt.want == "403":
+		case len(tt.want) == 3 && isDigits(tt.want):
 			want, err := strconv.Atoi(tt.want)
 			if err != nil {
 				panic("can't happen")
@@ -556,16 +1175,17 @@ func testRequests(t *testing.T, mux *Mux, tests []reqTest) {
 				t.Errorf("%s %s: got 405 response with Allow=%q instead of %q",
 					tt.method, tt.path, got, allow)
 			}
-		case strings.HasPrefix(tt.want, "308 "):
-			if w.Code != 308 {
-				t.Errorf("%s %s: got status %d instead of 308",
-					tt.method, tt.path, w.Code)
+		case strings.HasPrefix(tt.want, "30") && len(tt.want) > 4 && tt.want[3] == ' ':
+			code := tt.want[:3]
+			if strconv.Itoa(w.Code) != code {
+				t.Errorf("%s %s: got status %d instead of %s",
+					tt.method, tt.path, w.Code, code)
 				continue
 			}
-			targ := strings.TrimPrefix(tt.want, "308 ")
+			targ := tt.want[4:]
 			if got := w.Result().Header.Get("Location"); got != targ {
-				t.Errorf("%s %s: got 308 redirect to %q instead of %q",
-					tt.method, tt.path, got, targ)
+				t.Errorf("%s %s: got %s redirect to %q instead of %q",
+					tt.method, tt.path, code, got, targ)
 			}
 		case w.Code != 200:
 			t.Errorf("%s %s: got status %d instead of 200",
@@ -579,6 +1199,15 @@ func testRequests(t *testing.T, mux *Mux, tests []reqTest) {
 	}
 }
 
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 func testHandler(format string, params ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := RequestParams(r)
@@ -590,6 +1219,10 @@ func testHandler(format string, params ...string) http.HandlerFunc {
 				args[i] = p.Int64(pn)
 			} else if pn, ok := trimSuffix(pn, ":int"); ok {
 				args[i] = p.Int(pn)
+			} else if pn, ok := trimSuffix(pn, ":bytes"); ok {
+				args[i] = p.Bytes(pn)
+			} else if pn, ok := trimSuffix(pn, ":time"); ok {
+				args[i] = p.Time(pn, time.UTC).Format(time.RFC3339Nano)
 			} else if pn == "*" {
 				args[i] = p.Wildcard()
 			} else {
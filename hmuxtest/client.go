@@ -0,0 +1,143 @@
+// Package hmuxtest provides utilities for testing handlers routed by an
+// hmux.Mux.
+package hmuxtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cespare/hmux"
+)
+
+// A Client sends requests for the named rules of a Mux (see hmux.Name) to a
+// handler and records the responses using httptest. Since requests are made
+// by rule name, tests using a Client keep working when the patterns of the
+// rules change:
+//
+//	c := hmuxtest.NewClient(t, mux)
+//	resp := c.Get("user", hmux.Args{"id": 3})
+//	if resp.StatusCode != 200 { ... }
+//
+// The methods of a Client report problems, such as an unknown rule name or
+// arguments which do not fit its pattern, by calling the Fatal method of the
+// testing.TB given to NewClient, so they must be called from the goroutine
+// running the test.
+type Client struct {
+	// Mux resolves the rule names to URLs.
+	Mux *hmux.Mux
+	// Handler serves the requests. NewClient sets it to Mux; it may be
+	// replaced by a handler which wraps Mux (with middleware, say).
+	Handler http.Handler
+	// Header holds headers added to each request made by the Client.
+	Header http.Header
+
+	t testing.TB
+}
+
+// NewClient returns a Client which sends requests to mux.
+func NewClient(t testing.TB, mux *hmux.Mux) *Client {
+	return &Client{
+		Mux:     mux,
+		Handler: mux,
+		Header:  make(http.Header),
+		t:       t,
+	}
+}
+
+// NewRequest returns a request with the given method and body for the URL of
+// the rule with the given name, formed as by hmux.Mux.URLMap from args. The
+// request includes the headers in c.Header. It may be modified (to add a
+// query, for instance) before being passed to Do.
+func (c *Client) NewRequest(method, name string, args hmux.Args, body io.Reader) *http.Request {
+	c.t.Helper()
+	u, err := c.Mux.URLMap(name, args)
+	if err != nil {
+		c.t.Fatalf("hmuxtest: %s", err)
+	}
+	r := httptest.NewRequest(method, u, body)
+	for k, vs := range c.Header {
+		r.Header[k] = append(r.Header[k], vs...)
+	}
+	return r
+}
+
+// Do sends r to c.Handler and returns the recorded response.
+func (c *Client) Do(r *http.Request) *Response {
+	w := httptest.NewRecorder()
+	c.Handler.ServeHTTP(w, r)
+	return &Response{
+		StatusCode: w.Code,
+		Header:     w.Header(),
+		Body:       w.Body.Bytes(),
+		t:          c.t,
+	}
+}
+
+// Get sends a GET request for the URL of the named rule.
+func (c *Client) Get(name string, args hmux.Args) *Response {
+	c.t.Helper()
+	return c.Do(c.NewRequest(http.MethodGet, name, args, nil))
+}
+
+// Head sends a HEAD request for the URL of the named rule.
+func (c *Client) Head(name string, args hmux.Args) *Response {
+	c.t.Helper()
+	return c.Do(c.NewRequest(http.MethodHead, name, args, nil))
+}
+
+// Delete sends a DELETE request for the URL of the named rule.
+func (c *Client) Delete(name string, args hmux.Args) *Response {
+	c.t.Helper()
+	return c.Do(c.NewRequest(http.MethodDelete, name, args, nil))
+}
+
+// Post sends a POST request with the given body and Content-Type for the URL
+// of the named rule.
+func (c *Client) Post(name string, args hmux.Args, contentType string, body io.Reader) *Response {
+	c.t.Helper()
+	return c.send(http.MethodPost, name, args, contentType, body)
+}
+
+// Put is like Post but sends a PUT request.
+func (c *Client) Put(name string, args hmux.Args, contentType string, body io.Reader) *Response {
+	c.t.Helper()
+	return c.send(http.MethodPut, name, args, contentType, body)
+}
+
+// Patch is like Post but sends a PATCH request.
+func (c *Client) Patch(name string, args hmux.Args, contentType string, body io.Reader) *Response {
+	c.t.Helper()
+	return c.send(http.MethodPatch, name, args, contentType, body)
+}
+
+func (c *Client) send(method, name string, args hmux.Args, contentType string, body io.Reader) *Response {
+	c.t.Helper()
+	r := c.NewRequest(method, name, args, body)
+	r.Header.Set("Content-Type", contentType)
+	return c.Do(r)
+}
+
+// A Response is a response recorded by a Client.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	t testing.TB
+}
+
+// String returns the body of the response.
+func (r *Response) String() string {
+	return string(r.Body)
+}
+
+// JSON decodes the body of the response, which must be JSON, into v.
+func (r *Response) JSON(v interface{}) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("hmuxtest: decoding response body %q: %s", r.Body, err)
+	}
+}
@@ -0,0 +1,121 @@
+package hmuxtest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cespare/hmux"
+)
+
+func testMux() *hmux.Mux {
+	b := hmux.NewBuilder()
+	b.Get("/users/:id:int64", func(w http.ResponseWriter, r *http.Request) {
+		id := hmux.RequestParams(r).Int64("id")
+		fmt.Fprintf(w, `{"id": %d, "auth": %q}`, id, r.Header.Get("Authorization"))
+	}, hmux.Name("user"))
+	b.HandleMethods([]string{"POST", "PUT", "PATCH"}, "/users/:id:int64/notes",
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			fmt.Fprintf(w, "%s %s %s %s", r.Method, r.URL.Path, r.Header.Get("Content-Type"), body)
+		}), hmux.Name("notes"))
+	b.Delete("/users/:id:int64", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, hmux.Name("user.delete"))
+	return b.Build()
+}
+
+func TestClient(t *testing.T) {
+	c := NewClient(t, testMux())
+	c.Header.Set("Authorization", "Bearer x")
+
+	resp := c.Get("user", hmux.Args{"id": 3})
+	var user struct {
+		ID   int64
+		Auth string
+	}
+	resp.JSON(&user)
+	if resp.StatusCode != 200 || user.ID != 3 || user.Auth != "Bearer x" {
+		t.Errorf("Get: got %d %+v", resp.StatusCode, user)
+	}
+	if resp := c.Head("user", hmux.Args{"id": 3}); resp.StatusCode != 405 {
+		t.Errorf("Head: got status %d; want 405", resp.StatusCode)
+	}
+	if resp := c.Delete("user.delete", hmux.Args{"id": 3}); resp.StatusCode != 204 {
+		t.Errorf("Delete: got status %d; want 204", resp.StatusCode)
+	}
+	for _, tt := range []struct {
+		send func(string, hmux.Args, string, io.Reader) *Response
+		want string
+	}{
+		{c.Post, "POST /users/4/notes text/plain hi"},
+		{c.Put, "PUT /users/4/notes text/plain hi"},
+		{c.Patch, "PATCH /users/4/notes text/plain hi"},
+	} {
+		resp := tt.send("notes", hmux.Args{"id": 4}, "text/plain", strings.NewReader("hi"))
+		if got := resp.String(); got != tt.want {
+			t.Errorf("got %q; want %q", got, tt.want)
+		}
+	}
+
+	r := c.NewRequest("GET", "user", hmux.Args{"id": 5}, nil)
+	r.Header.Set("Authorization", "other")
+	resp = c.Do(r)
+	resp.JSON(&user)
+	if user.ID != 5 || user.Auth != "other" {
+		t.Errorf("Do: got %+v", user)
+	}
+
+	// The Handler may wrap the Mux.
+	c.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Wrapped", "1")
+		c.Mux.ServeHTTP(w, r)
+	})
+	if resp := c.Get("user", hmux.Args{"id": 3}); resp.Header.Get("X-Wrapped") != "1" {
+		t.Error("request did not go through Handler")
+	}
+}
+
+// fatalTB records calls to Fatalf, stopping the caller with a panic.
+type fatalTB struct {
+	testing.TB
+	msg string
+}
+
+func (t *fatalTB) Helper() {}
+
+func (t *fatalTB) Fatalf(format string, args ...interface{}) {
+	t.msg = fmt.Sprintf(format, args...)
+	panic(t)
+}
+
+func TestClientErrors(t *testing.T) {
+	for _, tt := range []struct {
+		f    func(c *Client)
+		want string
+	}{
+		{func(c *Client) { c.Get("nope", nil) }, `hmuxtest: hmux: no rule named "nope"`},
+		{func(c *Client) { c.Get("user", hmux.Args{"id": "x"}) }, `does not match parameter "id"`},
+		{func(c *Client) { c.Get("user", hmux.Args{"id": 3, "q": 1}) }, `has no parameter "q"`},
+		{func(c *Client) {
+			var v struct{}
+			c.Delete("user.delete", hmux.Args{"id": 3}).JSON(&v)
+		}, "hmuxtest: decoding response body"},
+	} {
+		tb := new(fatalTB)
+		func() {
+			defer func() {
+				if r := recover(); r != nil && r != tb {
+					panic(r)
+				}
+			}()
+			tt.f(NewClient(tb, testMux()))
+		}()
+		if !strings.Contains(tb.msg, tt.want) {
+			t.Errorf("got failure %q; want failure containing %q", tb.msg, tt.want)
+		}
+	}
+}
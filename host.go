@@ -0,0 +1,146 @@
+package hmux
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Host returns a RuleOption which restricts a rule to requests whose Host
+// (as given by http.Request.Host, ignoring any port) matches pattern. Host
+// names are compared case-insensitively.
+//
+// The pattern is a host name made of dot-separated labels, such as
+// "api.example.com". The first label may be *, in which case the pattern
+// matches any subdomain (at any depth) of the rest of the pattern, but not the
+// rest of the pattern itself. The pattern must not include a port.
+//
+// A label may instead be a parameter, which begins with a colon and matches
+// any single label of the host. Host parameters are string parameters that
+// are available to handlers alongside path parameters (the value is
+// lowercased), so their names must differ from those of the rule's path
+// parameters.
+//
+//	b.Get("/dashboard", serveDashboard, hmux.Host(":tenant.example.com"))
+//	...
+//	tenant := hmux.RequestParams(r).Get("tenant")
+//
+// The host restriction is a condition (see Conditions): if the request's host
+// does not match, the Mux continues looking for a matching rule, so a rule for
+// the same pattern and method without a host restriction serves as a fallback
+// for other hosts. Rules restricted to different hosts are tried in the order
+// they were registered.
+//
+//	b.Get("/", serveAPIIndex, hmux.Host("api.example.com"))
+//	b.Get("/", serveTenantIndex, hmux.Host("*.example.com"))
+//	b.Get("/", serveIndex)
+func Host(pattern string) RuleOption {
+	c, err := parseHostPattern(pattern)
+	if err != nil {
+		panic("hmux: " + err.Error())
+	}
+	return func(rl *rule) {
+		for _, label := range c.labels {
+			if label.isParam && rl.p.hasParam(label.s) {
+				panic(fmt.Sprintf("hmux: host parameter %q duplicates a path parameter of %q", label.s, rl.pat))
+			}
+			if label.isParam {
+				rl.condParams = true
+			}
+		}
+		rl.conds = append(rl.conds, c)
+	}
+}
+
+type hostCond struct {
+	labels   []segment // only string params
+	wildcard bool      // pattern begins with "*."
+	k        string
+}
+
+func parseHostPattern(pattern string) (hostCond, error) {
+	var c hostCond
+	bad := func() (hostCond, error) {
+		return hostCond{}, fmt.Errorf("invalid host pattern %q", pattern)
+	}
+	if pattern == "" || strings.ContainsAny(pattern, "/[]") {
+		return bad()
+	}
+	labels := strings.Split(strings.ToLower(pattern), ".")
+	if labels[0] == "*" {
+		c.wildcard = true
+		labels = labels[1:]
+		if len(labels) == 0 {
+			return bad()
+		}
+	}
+	keys := make([]string, len(labels))
+	names := make(map[string]bool)
+	for i, label := range labels {
+		if label == "" || strings.Contains(label, "*") {
+			return bad()
+		}
+		if label[0] != ':' {
+			if strings.Contains(label, ":") {
+				return bad()
+			}
+			c.labels = append(c.labels, segment{s: label})
+			keys[i] = label
+			continue
+		}
+		name := label[1:]
+		if name == "" || strings.Contains(name, ":") {
+			return bad()
+		}
+		if names[name] {
+			return hostCond{}, fmt.Errorf("host pattern %q contains duplicate parameter %q", pattern, name)
+		}
+		names[name] = true
+		c.labels = append(c.labels, segment{s: name, isParam: true})
+		keys[i] = ":"
+	}
+	c.k = "host:" + strings.Join(keys, ".")
+	if c.wildcard {
+		c.k = "host:*." + strings.Join(keys, ".")
+	}
+	return c, nil
+}
+
+func (c hostCond) check(r *http.Request, _ *rule, p *Params) int {
+	host := strings.TrimSuffix(strings.ToLower(requestHost(r)), ".")
+	labels := strings.Split(host, ".")
+	if c.wildcard {
+		if len(labels) <= len(c.labels) {
+			return condSkip
+		}
+		labels = labels[len(labels)-len(c.labels):]
+	} else if len(labels) != len(c.labels) {
+		return condSkip
+	}
+	for i, seg := range c.labels {
+		if !seg.isParam && labels[i] != seg.s {
+			return condSkip
+		}
+		if seg.isParam && labels[i] == "" {
+			return condSkip
+		}
+	}
+	for i, seg := range c.labels {
+		if seg.isParam {
+			p.ps = append(p.ps, param{name: seg.s, val: labels[i], typ: paramString})
+		}
+	}
+	return condOK
+}
+
+func (c hostCond) key() string { return c.k }
+
+// requestHost returns the host of r without any port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
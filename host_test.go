@@ -0,0 +1,108 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/", testHandler("api"), Host("API.example.com"))
+	b.Get("/", testHandler("tenant"), Host("*.example.com"))
+	b.Get("/", testHandler("default"))
+	b.Get("/admin", testHandler("admin"), Host("admin.example.com"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		host string
+		path string
+		want string
+	}{
+		{"api.example.com", "/", "api"},
+		{"Api.Example.com:8080", "/", "api"},
+		{"acme.example.com", "/", "tenant"},
+		{"a.b.example.com", "/", "tenant"},
+		{"example.com", "/", "default"},
+		{"notexample.com", "/", "default"},
+		{"admin.example.com", "/admin", "admin"},
+		{"api.example.com", "/admin", "404"},
+		{"[::1]:80", "/", "default"},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.Host = tt.host
+		mux.ServeHTTP(w, r)
+		got := w.Body.String()
+		if tt.want == "404" {
+			if w.Code != 404 {
+				t.Errorf("GET %s%s: got %d %q; want 404", tt.host, tt.path, w.Code, got)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("GET %s%s: got %q; want %q", tt.host, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestHostParams(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/", testHandler("api %s", "region"), Host("api.:region.example.com"))
+	b.Get("/", testHandler("tenant %s", "tenant"), Host(":tenant.example.com"))
+	b.Get("/users/:id", testHandler("%s user %s", "tenant", "id"), Host(":tenant.example.com"))
+	b.Get("/users/:id", testHandler("user %s", "id"))
+	b.Get("/deep", testHandler("deep %s", "team"), Host("*.:team.example.org"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		host string
+		path string
+		want string
+	}{
+		{"api.eu.example.com", "/", "api eu"},
+		{"ACME.example.com", "/", "tenant acme"},
+		{"acme.example.com", "/users/5", "acme user 5"},
+		{"example.com", "/users/5", "user 5"},
+		{"a.b.example.com", "/users/5", "user 5"},
+		{"x.y.blue.example.org", "/deep", "deep blue"},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.Host = tt.host
+		mux.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("GET %s%s: got %q; want %q", tt.host, tt.path, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("host parameter with the same name as a path parameter did not panic")
+		}
+	}()
+	b.Get("/x/:id", testHandler(""), Host(":id.example.com"))
+}
+
+func TestHostErrors(t *testing.T) {
+	for _, pat := range []string{
+		"",
+		"*",
+		"*example.com",
+		"a.*.com",
+		"example.com:80",
+		"a/b",
+		"a..com",
+		":.example.com",
+		":a.:a.com",
+		"[::1]",
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Host(%q): got no panic", pat)
+				}
+			}()
+			Host(pat)
+		}()
+	}
+}
@@ -0,0 +1,219 @@
+package hmux
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A StoredResponse is a response saved by an IdempotencyStore.
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// An IdempotencyStore saves the responses of rules using Idempotent, keyed by
+// idempotency key. Its methods must be safe to call concurrently.
+type IdempotencyStore interface {
+	// Begin is called when a request with the given key arrives. If a
+	// response has been saved for the key, Begin returns it. Otherwise,
+	// if no request with the key is in progress, Begin claims the key
+	// for the caller and returns claimed = true. (If another request with
+	// the key is in progress, it returns a nil response and false.)
+	Begin(key string) (resp *StoredResponse, claimed bool, err error)
+	// Finish is called when the handler for a request whose key was
+	// claimed by Begin returns. It saves resp for the key or, if resp is
+	// nil, releases the key so that the request may be retried.
+	Finish(key string, resp *StoredResponse) error
+}
+
+// Idempotent returns a RuleOption which makes a rule's handler safe to retry,
+// using the Idempotency-Key request header as in many HTTP APIs. It is
+// typically used with POST rules:
+//
+//	store := hmux.MemoryIdempotencyStore(24 * time.Hour)
+//	b.Post("/payments", createPayment, hmux.Idempotent(store))
+//
+// The Mux responds to a request without the header with a 400 ("Bad
+// Request"). The first request with a given key is passed to the handler,
+// and its response is saved in store (unless the status is 5xx, or the
+// handler panics, in which case the key is released so that the client can
+// retry). Later requests with the same key for the same method and path are
+// answered with the saved response, which has the additional header
+// "Idempotent-Replayed: true", without calling the handler. While the first
+// request is in progress, others with its key receive a 409 ("Conflict").
+// If store returns an error, the Mux responds with a 503 ("Service
+// Unavailable").
+//
+// Keys are expected to be unique, such as random UUIDs chosen by clients; the
+// Mux does not check that a retried request has the same body as the
+// original one.
+//
+// The response is saved in addition to being written to the client, so it
+// is held in memory while the handler runs. Idempotent has no effect on rules
+// marked using Streaming.
+func Idempotent(store IdempotencyStore) RuleOption {
+	if store == nil {
+		panic("hmux: Idempotent called with nil store")
+	}
+	return func(rl *rule) {
+		rl.mws = append(rl.mws, func(h http.Handler) http.Handler {
+			if rl.streaming {
+				return h
+			}
+			return idempotentHandler{h, store}
+		})
+	}
+}
+
+type idempotentHandler struct {
+	h     http.Handler
+	store IdempotencyStore
+}
+
+func (h idempotentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		http.Error(w, "missing Idempotency-Key header", http.StatusBadRequest)
+		return
+	}
+	key = r.Method + " " + r.URL.EscapedPath() + " " + key
+	resp, claimed, err := h.store.Begin(key)
+	switch {
+	case err != nil:
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	case resp != nil:
+		for k, vs := range resp.Header {
+			w.Header()[k] = append([]string(nil), vs...)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
+		return
+	case !claimed:
+		http.Error(w, "a request with this Idempotency-Key is in progress", http.StatusConflict)
+		return
+	}
+
+	rw := &recordingWriter{ResponseWriter: w}
+	finished := false
+	defer func() {
+		if !finished {
+			// The handler panicked.
+			h.store.Finish(key, nil)
+		}
+	}()
+	h.h.ServeHTTP(rw, r)
+	finished = true
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.status >= 500 {
+		h.store.Finish(key, nil)
+		return
+	}
+	h.store.Finish(key, &StoredResponse{
+		Status: rw.status,
+		Header: rw.header,
+		Body:   rw.body.Bytes(),
+	})
+}
+
+// A recordingWriter is an http.ResponseWriter which records the response
+// as it is written.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// MemoryIdempotencyStore returns an IdempotencyStore which keeps responses in
+// memory for the duration ttl. It is suitable for a single server process
+// (and for tests); servers behind a load balancer need a shared store.
+func MemoryIdempotencyStore(ttl time.Duration) IdempotencyStore {
+	if ttl <= 0 {
+		panic("hmux: MemoryIdempotencyStore called with non-positive TTL")
+	}
+	return &memoryIdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+type memoryIdempotencyStore struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	resp    *StoredResponse // nil while in progress
+	expires time.Time
+}
+
+func (s *memoryIdempotencyStore) Begin(key string) (*StoredResponse, bool, error) {
+	now := timeNow()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) >= s.ttl {
+		for k, e := range s.entries {
+			if e.resp != nil && !now.Before(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	e, ok := s.entries[key]
+	if ok && (e.resp == nil || now.Before(e.expires)) {
+		return e.resp, false, nil
+	}
+	s.entries[key] = &idempotencyEntry{}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Finish(key string, resp *StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if resp == nil {
+		delete(s.entries, key)
+		return nil
+	}
+	s.entries[key] = &idempotencyEntry{resp: resp, expires: timeNow().Add(s.ttl)}
+	return nil
+}
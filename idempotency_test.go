@@ -0,0 +1,125 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdempotent(t *testing.T) {
+	store := MemoryIdempotencyStore(time.Hour)
+	var calls int
+	b := NewBuilder()
+	b.Post("/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", fmt.Sprintf("/payments/%d", calls))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "payment %d", calls)
+	}, Idempotent(store))
+	b.Post("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls%2 == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "ok %d", calls)
+	}, Idempotent(store))
+	mux := b.Build()
+
+	post := func(path, key string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("POST", path, nil)
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("/payments", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("without key: got status %d; want 400", w.Code)
+	}
+	for i, tt := range []struct {
+		path     string
+		key      string
+		code     int
+		body     string
+		replayed bool
+	}{
+		{"/payments", "a", 201, "payment 1", false},
+		{"/payments", "a", 201, "payment 1", true},
+		{"/payments", "b", 201, "payment 2", false},
+		{"/payments", "a", 201, "payment 1", true},
+		// Keys are scoped to the path.
+		{"/flaky", "a", 503, "unavailable\n", false},
+		// 5xx responses are not saved.
+		{"/flaky", "a", 200, "ok 4", false},
+		{"/flaky", "a", 200, "ok 4", true},
+	} {
+		w := post(tt.path, tt.key)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("[%d] POST %s (key %q): got %d %q; want %d %q",
+				i, tt.path, tt.key, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+		if got := w.Header().Get("Idempotent-Replayed") == "true"; got != tt.replayed {
+			t.Errorf("[%d] POST %s (key %q): got replayed=%t; want %t", i, tt.path, tt.key, got, tt.replayed)
+		}
+	}
+	if w := post("/payments", "b"); w.Header().Get("Location") != "/payments/2" {
+		t.Errorf("replayed response has Location %q; want /payments/2", w.Header().Get("Location"))
+	}
+}
+
+func TestIdempotentInProgress(t *testing.T) {
+	store := MemoryIdempotencyStore(time.Hour)
+	var inner *httptest.ResponseRecorder
+	b := NewBuilder()
+	var mux *Mux
+	b.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		inner = httptest.NewRecorder()
+		mux.ServeHTTP(inner, r)
+		if r.Header.Get("X-Panic") != "" {
+			panic("boom")
+		}
+	}, Idempotent(store))
+	mux = b.Build()
+
+	func() {
+		defer func() { recover() }()
+		r := httptest.NewRequest("POST", "/orders", nil)
+		r.Header.Set("Idempotency-Key", "k")
+		r.Header.Set("X-Panic", "1")
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+	if inner == nil || inner.Code != http.StatusConflict {
+		t.Fatalf("got nested response %v; want 409", inner)
+	}
+	// The panic released the key.
+	if _, claimed, _ := store.Begin("POST /orders k"); !claimed {
+		t.Error("key not released after panic")
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { timeNow = time.Now }()
+	timeNow = func() time.Time { return now }
+
+	s := MemoryIdempotencyStore(time.Minute)
+	if _, claimed, _ := s.Begin("k"); !claimed {
+		t.Fatal("first Begin did not claim key")
+	}
+	saved := &StoredResponse{Status: 200, Body: []byte("x")}
+	s.Finish("k", saved)
+	now = now.Add(59 * time.Second)
+	if resp, claimed, _ := s.Begin("k"); resp != saved || claimed {
+		t.Fatalf("Begin before expiry: got (%v, %t); want saved response", resp, claimed)
+	}
+	now = now.Add(time.Second)
+	if resp, claimed, _ := s.Begin("k"); resp != nil || !claimed {
+		t.Fatalf("Begin after expiry: got (%v, %t); want new claim", resp, claimed)
+	}
+}
@@ -0,0 +1,67 @@
+package hmux
+
+import (
+	"context"
+	"net/http"
+)
+
+// Each copy of this package in a program (such as a vendored copy, or a
+// different major version) has its own context key for Params, so one copy
+// cannot see the Params stored by another. ExportParams and ImportParams
+// pass Params between copies using a context key and value whose types are
+// unnamed, and therefore identical in every copy. The key is versioned so
+// that the representation may change in the future.
+var interopKey = struct{ HmuxParamsV1 struct{} }{}
+
+type interopFunc = func() (names, values []string, wildcard string, hasWildcard bool)
+
+// ExportParams returns a handler which makes the Params of each request (see
+// RequestParams) available to other copies of this package before calling h.
+//
+// This is needed when a Mux serves a handler that uses a different copy of
+// this package, such as a Mux from a vendored copy or another major version.
+// Muxes merge the exported Params with their own, as they do for Params from
+// Muxes of the same copy; other code may retrieve them using ImportParams.
+//
+//	outer.Prefix("/legacy/:tenant", hmux.ExportParams(legacyMux))
+func ExportParams(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := RequestParams(r); p != nil {
+			var f interopFunc = p.export
+			r = r.WithContext(context.WithValue(r.Context(), interopKey, f))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (p *Params) export() (names, values []string, wildcard string, hasWildcard bool) {
+	names = make([]string, len(p.ps))
+	values = make([]string, len(p.ps))
+	for i, pp := range p.ps {
+		names[i] = pp.name
+		values[i] = pp.val
+	}
+	return names, values, p.wildcard, p.hasWildcard
+}
+
+// ImportParams retrieves the Params exported by ExportParams, which may belong
+// to any copy of this package, from r. It returns nil if there are none.
+//
+// The parameters of the returned Params are all strings, whatever their types
+// were in the exporting copy; use Get to retrieve them.
+func ImportParams(r *http.Request) *Params {
+	f, ok := r.Context().Value(interopKey).(interopFunc)
+	if !ok {
+		return nil
+	}
+	names, values, wildcard, hasWildcard := f()
+	p := &Params{
+		ps:          make([]param, len(names)),
+		wildcard:    wildcard,
+		hasWildcard: hasWildcard,
+	}
+	for i, name := range names {
+		p.ps[i] = param{name: name, val: values[i], typ: paramString}
+	}
+	return p
+}
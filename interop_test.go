@@ -0,0 +1,52 @@
+package hmux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExportParams(t *testing.T) {
+	inner := NewBuilder()
+	inner.Get("/:id:int32", testHandler("%s %d", "tenant", "id:int32"))
+	outer := NewBuilder()
+	outer.Prefix("/t/:tenant", ExportParams(inner.Build()))
+	outer.Handle("GET", "/x/:a", ExportParams(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ImportParams(r).Get("a")))
+	})))
+	testRequests(t, outer.Build(), []reqTest{
+		{"GET", "/t/acme/3", "acme 3"},
+		{"GET", "/x/y", "y"},
+	})
+}
+
+func TestImportParamsFromOtherCopy(t *testing.T) {
+	// Simulate Params exported by another copy of this package, which uses
+	// a distinct (but identical) key type.
+	f := func() (names, values []string, wildcard string, hasWildcard bool) {
+		return []string{"tenant"}, []string{"acme"}, "/rest", true
+	}
+	key := struct{ HmuxParamsV1 struct{} }{}
+	r := httptest.NewRequest("GET", "/users/5", nil)
+	r = r.WithContext(context.WithValue(r.Context(), key, f))
+
+	p := ImportParams(r)
+	if p == nil {
+		t.Fatal("ImportParams returned nil")
+	}
+	if got := p.Get("tenant"); got != "acme" {
+		t.Errorf(`Get("tenant"): got %q; want "acme"`, got)
+	}
+	if got := p.Wildcard(); got != "/rest" {
+		t.Errorf(`Wildcard(): got %q; want "/rest"`, got)
+	}
+
+	b := NewBuilder()
+	b.Get("/users/:id", testHandler("%s %s", "tenant", "id"))
+	w := httptest.NewRecorder()
+	b.Build().ServeHTTP(w, r)
+	if got, want := w.Body.String(), "acme 5"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
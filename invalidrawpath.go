@@ -0,0 +1,45 @@
+package hmux
+
+import (
+	"net/http"
+)
+
+// FallBackOnInvalidRawPath makes Muxes built from b route requests whose
+// URLs have an invalid RawPath by their (decoded) Path instead.
+//
+// A Mux normally matches the escaped form of a request's path given by
+// RawPath, when it is set, so that escaped slashes and other reserved
+// characters are routed correctly (see Routing). The net/http server only
+// sets RawPath to a valid encoding of Path, but requests constructed by other
+// code (such as an in-process proxy which forwards a technically invalid path
+// from upstream) may carry a RawPath that is malformed or that does not
+// encode Path. The Mux may then misroute such requests, or panic if the
+// RawPath contains an invalid escape sequence.
+//
+// With this option, the Mux instead clears the RawPath of such a request,
+// as if Path had been set without it, before doing anything else (in
+// particular, before calling the functions registered with Normalize). If
+// report is not nil, the Mux calls it with the original request so that the
+// problem can be logged or counted.
+func (b *Builder) FallBackOnInvalidRawPath(report func(r *http.Request)) {
+	b.checkNotGroup("FallBackOnInvalidRawPath")
+	if report == nil {
+		report = func(*http.Request) {}
+	}
+	b.invalidRawPath = report
+}
+
+// checkRawPath returns r or, if the RawPath of r is not a valid encoding of
+// its Path, a copy of r without the RawPath.
+func (m *Mux) checkRawPath(r *http.Request) *http.Request {
+	if r.URL.RawPath == "" || r.URL.EscapedPath() == r.URL.RawPath {
+		return r
+	}
+	m.invalidRawPath(r)
+	r1 := new(http.Request)
+	*r1 = *r
+	u := *r.URL
+	u.RawPath = ""
+	r1.URL = &u
+	return r1
+}
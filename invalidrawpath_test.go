@@ -0,0 +1,51 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFallBackOnInvalidRawPath(t *testing.T) {
+	var reported []string
+	b := NewBuilder()
+	b.FallBackOnInvalidRawPath(func(r *http.Request) {
+		reported = append(reported, r.URL.RawPath)
+	})
+	b.Get("/a/b", testHandler("a b"))
+	b.Get("/a/:p", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("param " + RequestParams(r).Get("p") + " " + r.URL.RawPath))
+	})
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		path    string
+		rawPath string
+		want    string
+	}{
+		{"/a/b", "", "a b"},
+		{"/a/b/c", "/a/b%2Fc", "param b/c /a/b%2Fc"},
+		{"/a/b", "/a/c%2Fd", "a b"},        // RawPath doesn't encode Path
+		{"/a/b", "/a/b%zz", "a b"},         // invalid escape
+		{"/a/x y", "/a/x y", "param x y "}, // not escaped
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.URL.Path = tt.path
+		r.URL.RawPath = tt.rawPath
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("Path=%q RawPath=%q: got %q; want %q", tt.path, tt.rawPath, got, tt.want)
+		}
+	}
+	want := []string{"/a/c%2Fd", "/a/b%zz", "/a/x y"}
+	if len(reported) != len(want) {
+		t.Fatalf("got reports %q; want %q", reported, want)
+	}
+	for i := range want {
+		if reported[i] != want[i] {
+			t.Errorf("got reports %q; want %q", reported, want)
+			break
+		}
+	}
+}
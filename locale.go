@@ -0,0 +1,57 @@
+package hmux
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// A Locale describes the conventions for formatting a response: the
+// language and the time zone.
+type Locale struct {
+	// Language is a BCP 47 language tag, such as "en-US".
+	Language string
+	// Location is the time zone. A nil Location is treated as UTC by
+	// RequestLocale.
+	Location *time.Location
+}
+
+// WithLocale returns a RuleOption which resolves the locale of each request
+// matched by the rule using f and places the result in the request context,
+// where the handler (and middleware) can retrieve it using RequestLocale.
+// This keeps formatting consistent across the routes serving a region:
+//
+//	euLocale := hmux.WithLocale(func(r *http.Request) hmux.Locale {
+//		return hmux.Locale{Language: "de-DE", Location: berlin}
+//	})
+//	b.Get("/eu/orders", handleOrders, euLocale)
+//	b.Get("/eu/invoices/:id", handleInvoice, euLocale)
+//
+// The function is called after the request is matched, with the matched
+// parameters available via RequestParams. Like Middleware, WithLocale wraps
+// the handler, so middlewares which use the locale must be given after it.
+func WithLocale(f func(r *http.Request) Locale) RuleOption {
+	if f == nil {
+		panic("hmux: WithLocale called with nil function")
+	}
+	return func(rl *rule) {
+		rl.mws = append(rl.mws, func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				loc := f(r)
+				r = r.WithContext(context.WithValue(r.Context(), localeKey, loc))
+				h.ServeHTTP(w, r)
+			})
+		})
+	}
+}
+
+// RequestLocale retrieves the Locale resolved for r by a WithLocale function.
+// It reports false if the matched rule has no such function. The returned
+// Location is never nil.
+func RequestLocale(r *http.Request) (Locale, bool) {
+	loc, ok := r.Context().Value(localeKey).(Locale)
+	if loc.Location == nil {
+		loc.Location = time.UTC
+	}
+	return loc, ok
+}
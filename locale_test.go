@@ -0,0 +1,31 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithLocale(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	byRegion := WithLocale(func(r *http.Request) Locale {
+		if RequestParams(r).Get("region") == "jp" {
+			return Locale{Language: "ja-JP", Location: tokyo}
+		}
+		return Locale{Language: "en-US"}
+	})
+	show := func(w http.ResponseWriter, r *http.Request) {
+		loc, ok := RequestLocale(r)
+		fmt.Fprintf(w, "%s %s %t", loc.Language, loc.Location, ok)
+	}
+
+	b := NewBuilder()
+	b.Get("/:region/time", show, byRegion)
+	b.Get("/plain", show)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/jp/time", "ja-JP JST true"},
+		{"GET", "/us/time", "en-US UTC true"},
+		{"GET", "/plain", " UTC false"},
+	})
+}
@@ -0,0 +1,124 @@
+package hmux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MatchOptions describe the path given to Mux.MatchPath.
+type MatchOptions struct {
+	// TrailingSlash reports whether the path ends with a slash following
+	// its last segment (or, for a path without segments, whether it is
+	// "/" rather than empty).
+	TrailingSlash bool
+	// Star reports whether the request target is "*" (as for a
+	// server-wide OPTIONS request) rather than a path. The segments must
+	// be empty.
+	Star bool
+	// Reencode reports whether the segments are escaped, as in the
+	// RawPath of a URL. MatchPath unescapes them before matching, so that
+	// an escaped slash ("%2F") is part of its segment. Otherwise, the
+	// segments are taken to be unescaped.
+	Reencode bool
+	// Header holds the request headers seen by the rules' conditions
+	// (such as those of Produces or Header), if any.
+	Header http.Header
+}
+
+// MatchPath finds the rule of m which matches a request with the given method
+// and path, without an HTTP request. It allows programs which receive
+// requests by other means (such as RPC layers and message queues) to route
+// them using m. The path is given as its segments: "/users/3/" is
+// []string{"users", "3"} with opts.TrailingSlash set.
+//
+// MatchPath matches the path as given: it does not redirect non-canonical
+// paths, strip matrix parameters, or apply the functions registered with
+// Normalize. It does apply case folding (see CaseInsensitive) and segment
+// normalization (see NormalizeSegments). The rules' conditions see a request
+// having only the method, the path, and opts.Header.
+//
+// If a rule matches, MatchPath returns a Match describing it, as given to
+// OnMatch functions (which MatchPath does not call). Otherwise, it returns
+// nil and the status code with which ServeHTTP would respond: 405 ("Method
+// Not Allowed"), along with the methods allowed for the path, if the path
+// matches rules for other methods; the code called for by a rule's
+// conditions; or 404 ("Not Found"). If opts.Reencode is set and a segment is
+// not validly escaped, the status is 400 ("Bad Request").
+func (m *Mux) MatchPath(method string, segments []string, opts MatchOptions) (mt *Match, status int, allowed []string) {
+	if opts.Star && len(segments) > 0 {
+		panic("hmux: MatchPath called with Star and segments")
+	}
+	var mo matchOpts
+	if m.foldCase {
+		mo |= optFoldCase
+	}
+	if opts.TrailingSlash {
+		mo |= optTrailingSlash
+	}
+	if opts.Star {
+		mo |= optStar
+	}
+	parts := make([]string, len(segments))
+	escaped := make([]string, len(segments))
+	for i, seg := range segments {
+		if opts.Reencode {
+			part, err := url.PathUnescape(seg)
+			if err != nil {
+				return nil, http.StatusBadRequest, nil
+			}
+			parts[i], escaped[i] = part, seg
+		} else {
+			parts[i], escaped[i] = seg, url.PathEscape(seg)
+		}
+	}
+
+	u := &url.URL{Path: "*"}
+	if !opts.Star {
+		u.Path = "/" + strings.Join(parts, "/")
+		u.RawPath = "/" + strings.Join(escaped, "/")
+		if opts.TrailingSlash && len(parts) > 0 {
+			u.Path += "/"
+			u.RawPath += "/"
+		}
+		if u.RawPath == u.EscapedPath() {
+			u.RawPath = ""
+		}
+	}
+	header := opts.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	r := &http.Request{Method: method, URL: u, Header: header, RequestURI: u.RequestURI()}
+
+	if m.rawPath {
+		parts = escaped
+		mo |= optRaw
+	} else if m.normSeg != nil {
+		for i, part := range parts {
+			parts[i] = m.normSeg(part)
+		}
+	}
+	mr := m.matchParts(r, parts, mo)
+	switch {
+	case mr.rule != nil:
+	case mr.status != 0:
+		return nil, mr.status, nil
+	case mr.allow != "":
+		return nil, http.StatusMethodNotAllowed, strings.Split(mr.allow, ", ")
+	default:
+		return nil, http.StatusNotFound, nil
+	}
+	if mr.p != nil {
+		mr.p.rule = mr.rule
+		mr.p.mux = m
+	}
+	mt = &Match{
+		Route:   mr.rule.route(),
+		Params:  mr.p,
+		Handler: mr.rule.h,
+		Scanned: mr.scanned,
+		rule:    mr.rule,
+	}
+	return mt, 0, nil
+}
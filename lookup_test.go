@@ -0,0 +1,106 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/", testHandler("home"), Name("home"))
+	b.Get("/users/:id:int64", testHandler("user"), Name("user"))
+	b.Put("/users/:id:int64", testHandler("put user"))
+	b.Get("/dirs/", testHandler("dirs"), Name("dirs"))
+	b.Get("/files/:name", testHandler("file"), Name("file"))
+	b.Get("/static/*", testHandler("static"), Name("static"))
+	b.Options("*", testHandler("options"), Name("star"))
+	b.Get("/report", testHandler("json"), Produces("application/json"), Name("json"))
+	b.Get("/report", testHandler("csv"), Produces("text/csv"), Name("csv"))
+	b.Get("/admin", testHandler("admin"), Header("X-Admin", "1"), Name("admin"))
+	mux := b.Build()
+
+	type params map[string]string
+	for _, tt := range []struct {
+		method string
+		segs   []string
+		opts   MatchOptions
+		want   string // the rule name, or the status and allowed methods
+		params params
+	}{
+		{"GET", nil, MatchOptions{TrailingSlash: true}, "home", nil},
+		{"GET", []string{"users", "3"}, MatchOptions{}, "user", params{"id": "3"}},
+		{"GET", []string{"users", "x"}, MatchOptions{}, "404", nil},
+		{"DELETE", []string{"users", "3"}, MatchOptions{}, "405 GET, PUT", nil},
+		{"GET", []string{"dirs"}, MatchOptions{TrailingSlash: true}, "dirs", nil},
+		// No redirect is made for the missing slash.
+		{"GET", []string{"dirs"}, MatchOptions{}, "404", nil},
+		{"GET", []string{"files", "a/b"}, MatchOptions{}, "file", params{"name": "a/b"}},
+		{"GET", []string{"files", "a%2Fb"}, MatchOptions{Reencode: true}, "file", params{"name": "a/b"}},
+		{"GET", []string{"files", "a%2Fb"}, MatchOptions{}, "file", params{"name": "a%2Fb"}},
+		{"GET", []string{"files", "%zz"}, MatchOptions{Reencode: true}, "400", nil},
+		{"GET", []string{"static", "css", "x.css"}, MatchOptions{}, "static", params{"*": "/css/x.css"}},
+		{"OPTIONS", nil, MatchOptions{Star: true}, "star", nil},
+		{"GET", []string{"report"}, MatchOptions{}, "json", nil},
+		{"GET", []string{"report"}, MatchOptions{Header: http.Header{"Accept": {"text/csv"}}}, "csv", nil},
+		{"GET", []string{"admin"}, MatchOptions{}, "404", nil},
+		{"GET", []string{"admin"}, MatchOptions{Header: http.Header{"X-Admin": {"1"}}}, "admin", nil},
+	} {
+		mt, status, allowed := mux.MatchPath(tt.method, tt.segs, tt.opts)
+		desc := fmt.Sprintf("%s %q %+v", tt.method, tt.segs, tt.opts)
+		var got string
+		var gotParams params
+		if mt == nil {
+			got = strings.TrimSpace(fmt.Sprintf("%d %s", status, strings.Join(allowed, ", ")))
+		} else {
+			got = mt.Route.Name
+			if mt.Params != nil {
+				gotParams = make(params)
+				for _, pp := range mt.Params.ps {
+					gotParams[pp.name] = pp.val
+				}
+				if mt.Params.hasWildcard {
+					gotParams["*"] = mt.Params.Wildcard()
+				}
+			}
+		}
+		if got != tt.want || !reflect.DeepEqual(gotParams, tt.params) {
+			t.Errorf("%s: got %q %v; want %q %v", desc, got, gotParams, tt.want, tt.params)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MatchPath with Star and segments did not panic")
+		}
+	}()
+	mux.MatchPath("OPTIONS", []string{"x"}, MatchOptions{Star: true})
+}
+
+func TestMatchPathSettings(t *testing.T) {
+	b := NewBuilder()
+	b.CaseInsensitive(true)
+	b.Get("/Users/:name", testHandler("user"), Name("user"))
+	mux := b.Build()
+	mt, _, _ := mux.MatchPath("GET", []string{"users", "Bob"}, MatchOptions{})
+	if mt == nil || mt.Params.Get("name") != "Bob" {
+		t.Errorf("with CaseInsensitive: got %+v", mt)
+	}
+
+	b = NewBuilder()
+	b.RawPathMatching(true)
+	b.Get("/files/:name", testHandler("file"), Name("file"))
+	mux = b.Build()
+	for _, opts := range []MatchOptions{{}, {Reencode: true}} {
+		seg := "a/b"
+		if opts.Reencode {
+			seg = "a%2Fb"
+		}
+		mt, _, _ := mux.MatchPath("GET", []string{"files", seg}, opts)
+		if mt == nil || mt.Params.Get("name") != "a%2Fb" {
+			t.Errorf("with RawPathMatching and %+v: got %+v", opts, mt)
+		}
+	}
+}
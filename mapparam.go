@@ -0,0 +1,74 @@
+package hmux
+
+import (
+	"fmt"
+)
+
+// MapParam returns a RuleOption which replaces the value of the named
+// parameter of a rule's pattern with f(value) before the value is made
+// available through Params, so that handlers see a normalized form. For
+// example, to compare usernames case-insensitively:
+//
+//	b.Get("/users/:name", serveUser, hmux.MapParam("name", strings.ToLower))
+//
+// The mapping is applied once the rule's pattern and conditions match the
+// request, before the functions registered with Builder.OnMatch are called.
+// If several mappings are given for a parameter, they are applied in order.
+// Since parameter values are never empty, if f returns the empty string, the
+// Mux ignores the rule and continues looking for a matching rule, as when a
+// condition is not satisfied (see Conditions).
+//
+// MapParam panics if the rule's pattern has no parameter with the given name
+// or if the parameter's type is not string (with or without length bounds).
+func MapParam(name string, f func(string) string) RuleOption {
+	if f == nil {
+		panic("hmux: MapParam called with nil function")
+	}
+	return func(rl *rule) {
+		checkStringParam(rl, "MapParam", name)
+		rl.paramMaps = append(rl.paramMaps, paramMap{name, f})
+	}
+}
+
+// checkStringParam panics if the pattern of rl has no string parameter with
+// the given name. The function name fn is used in the message.
+func checkStringParam(rl *rule, fn, name string) {
+	for _, seg := range rl.p.segs {
+		if !seg.isParam || seg.s != name {
+			continue
+		}
+		if seg.ptyp != paramString && seg.ptyp != paramStringLen {
+			panic(fmt.Sprintf("hmux: %s called for parameter %q of type %s", fn, name, seg.typeName()))
+		}
+		return
+	}
+	panic(fmt.Sprintf("hmux: %s called for parameter %q not in pattern %q", fn, name, rl.pat))
+}
+
+type paramMap struct {
+	name string
+	f    func(string) string
+}
+
+// mapParams applies the mappings of rl to p, which holds the params of a
+// match of rl. If a mapping returns the empty string, mapParams leaves p
+// unchanged and returns false.
+func (rl *rule) mapParams(p *Params) bool {
+	vals := make([]string, len(p.ps))
+	for i, pp := range p.ps {
+		vals[i] = pp.val
+	}
+	for _, pm := range rl.paramMaps {
+		for i := range p.ps {
+			if p.ps[i].name == pm.name {
+				if vals[i] = pm.f(vals[i]); vals[i] == "" {
+					return false
+				}
+			}
+		}
+	}
+	for i, v := range vals {
+		p.ps[i].val = v
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMapParam(t *testing.T) {
+	b := NewBuilder()
+	var seen string
+	b.OnMatch(func(r *http.Request, m *Match) {
+		if m.Route.Pattern == "/users/:name" {
+			seen = m.Params.Get("name")
+		}
+	})
+	b.Get("/users/:name", testHandler("user %s", "name"), MapParam("name", strings.ToLower))
+	b.Get("/slugs/:a/:b:string(1,8)", testHandler("%s %s", "a", "b"),
+		MapParam("b", strings.TrimSpace), MapParam("b", strings.ToUpper))
+	b.Get("/raw/:name", testHandler("raw %s", "name"))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/users/Alice", "user alice"},
+		{"GET", "/slugs/Xy/%20ab", "Xy AB"},
+		{"GET", "/raw/Alice", "raw Alice"},
+	})
+	testRequests(t, mux, []reqTest{{"GET", "/users/BOB", "user bob"}})
+	if seen != "bob" {
+		t.Errorf("OnMatch saw name %q; want %q", seen, "bob")
+	}
+
+	for _, tt := range []struct {
+		pat  string
+		name string
+	}{
+		{"/users/:id", "name"},
+		{"/users/:id:int64", "id"},
+		{"/users/:id:enum(a|b)", "id"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MapParam(%q) for %q did not panic", tt.name, tt.pat)
+				}
+			}()
+			NewBuilder().Get(tt.pat, testHandler(""), MapParam(tt.name, strings.ToLower))
+		}()
+	}
+}
+
+func TestMapParamEmpty(t *testing.T) {
+	stripDashes := func(s string) string { return strings.Trim(s, "-") }
+	b := NewBuilder()
+	b.Get("/tags/:tag", testHandler("tag %s", "tag"), MapParam("tag", stripDashes))
+	b.Any("/tags/:tag", testHandler("any %s", "tag"))
+	b.Get("/ids/:id", testHandler("id %s", "id"), MapParam("id", func(string) string { return "" }))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/tags/-go-", "tag go"},
+		{"GET", "/tags/---", "any ---"},
+		{"GET", "/ids/x", "404"},
+	})
+}
@@ -0,0 +1,78 @@
+package hmux
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MatrixParams sets whether Muxes built from b parse matrix parameters: the
+// parameters that some clients attach to individual path segments following
+// semicolons, as in
+//
+//	/items;sort=asc;limit=10/42
+//
+// When enabled, the Mux removes the matrix parameters from each segment of
+// the request path before matching it, so the path above matches the
+// pattern "/items/:id". The parameters are available to the handler using
+// Params.Matrix. The request itself is unchanged. A segment consisting only
+// of matrix parameters, such as the first segment of "/;v=2/42", is empty
+// once they are removed, and it never matches a parameter.
+//
+// When disabled (the default), semicolons are ordinary characters in path
+// segments.
+func (b *Builder) MatrixParams(enable bool) {
+	b.checkNotGroup("MatrixParams")
+	b.matrix = enable
+}
+
+// Matrix returns the matrix parameters of the path segment with index i (the
+// first segment after the leading slash has index 0) of the request path
+// matched by the Mux, which must have been built with matrix parameter
+// parsing enabled (see Builder.MatrixParams). It returns nil if the segment
+// has no matrix parameters or if i is out of range. A parameter without an
+// = has an empty value.
+//
+// For example, given the request path "/items;sort=asc;limit=10/42",
+// p.Matrix(0).Get("sort") gives "asc".
+func (p *Params) Matrix(i int) url.Values {
+	if p == nil || i < 0 || i >= len(p.matrix) {
+		return nil
+	}
+	return p.matrix[i]
+}
+
+// stripMatrix removes the matrix parameters from the segments of the escaped
+// path pth. It returns the resulting path and the parameters of each segment,
+// or a nil slice if there are none.
+func stripMatrix(pth string) (string, []url.Values) {
+	if !strings.Contains(pth, ";") {
+		return pth, nil
+	}
+	segs := strings.Split(strings.TrimPrefix(pth, "/"), "/")
+	matrix := make([]url.Values, len(segs))
+	for i, seg := range segs {
+		j := strings.IndexByte(seg, ';')
+		if j < 0 {
+			continue
+		}
+		vals := make(url.Values)
+		for _, kv := range strings.Split(seg[j+1:], ";") {
+			if kv == "" {
+				continue
+			}
+			k, v := kv, ""
+			if eq := strings.IndexByte(kv, '='); eq >= 0 {
+				k, v = kv[:eq], kv[eq+1:]
+			}
+			k, err1 := url.PathUnescape(k)
+			v, err2 := url.PathUnescape(v)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			vals.Add(k, v)
+		}
+		matrix[i] = vals
+		segs[i] = seg[:j]
+	}
+	return "/" + strings.Join(segs, "/"), matrix
+}
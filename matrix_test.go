@@ -0,0 +1,50 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMatrixParams(t *testing.T) {
+	matrixHandler := func(w http.ResponseWriter, r *http.Request) {
+		p := RequestParams(r)
+		fmt.Fprintf(w, "id=%s sort=%q limit=%q flag=%q id-v=%q",
+			p.Get("id"), p.Matrix(0).Get("sort"), p.Matrix(0).Get("limit"),
+			p.Matrix(0)["flag"], p.Matrix(1).Get("v"))
+	}
+	b := NewBuilder()
+	b.MatrixParams(true)
+	b.Get("/items/:id", matrixHandler)
+	b.Get("/about", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "about %q", RequestParams(r).Matrix(0).Get("lang"))
+	})
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/items/42", `id=42 sort="" limit="" flag=[] id-v=""`},
+		{"GET", "/items;sort=asc;limit=10/42", `id=42 sort="asc" limit="10" flag=[] id-v=""`},
+		{"GET", "/items;flag/42;v=2", `id=42 sort="" limit="" flag=[""] id-v="2"`},
+		{"GET", "/items;sort=a%3Bb/x%3By", `id=x;y sort="a;b" limit="" flag=[] id-v=""`},
+		{"GET", "/about;lang=en", `about "en"`},
+		{"GET", "/about", `about ""`},
+	})
+
+	b.MatrixParams(false)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/items/42;v=2", `id=42;v=2 sort="" limit="" flag=[] id-v=""`},
+		{"GET", "/items;sort=asc/42", "404"},
+	})
+}
+
+func TestMatrixParamsEmptySegment(t *testing.T) {
+	b := NewBuilder()
+	b.MatrixParams(true)
+	b.Get("/:a/:b", testHandler("a=%s b=%s", "a", "b"))
+	b.Get("/x/:b", testHandler("x b=%s", "b"))
+	b.Get("/", testHandler("root"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/y/42", "a=y b=42"},
+		{"GET", "/;x=1/42", "404"},
+		{"GET", "/x/;v=1", "404"},
+		{"GET", "/;v=1", "root"},
+	})
+}
@@ -0,0 +1,56 @@
+package hmux
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Merge adds the rules of other to b, so that a large application can
+// assemble its rule table from Builders created by separate packages:
+//
+//	b := hmux.NewBuilder()
+//	if err := b.Merge(users.Routes()); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := b.Merge(billing.Routes()); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Each rule is checked as if it had been registered with b directly: Merge
+// returns an error if a rule of other conflicts with a rule of b or has the
+// same name (see Name). The rules are merged atomically: if Merge returns an
+// error, it adds none of them.
+//
+// As with BuildWith, only the rules of other are merged; its configuration
+// (such as functions registered with OnMatch or CaseInsensitive) is ignored,
+// and that of b applies to the merged rules once they are built into a Mux.
+// The rules keep the patterns, options, and parameter types they were
+// registered with: a custom parameter type (see RegisterParamType) of other
+// is distinct from any type of b, even one with the same name, and the types
+// are not added to b. As among the types of one Builder, types registered
+// earlier are more specific. Neither b nor other may be a Builder created by
+// Group.
+func (b *Builder) Merge(other *Builder) error {
+	if other == nil {
+		return errors.New("hmux: Merge called with nil Builder")
+	}
+	if b.group != nil {
+		return errors.New("hmux: Merge called on a group Builder")
+	} else if other.group != nil {
+		return errors.New("hmux: Merge called with a group Builder")
+	}
+	var rules []*rule
+	for _, ma := range other.matchers {
+		ma.forEachRule(func(rl *rule) {
+			rules = append(rules, rl)
+		})
+	}
+	return b.atomically(func() error {
+		for _, rl := range rules {
+			if err := b.addRule(rl, false); err != nil {
+				return fmt.Errorf("hmux: %s", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,133 @@
+package hmux
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	users := NewBuilder()
+	users.Get("/users/:id", testHandler("user %s", "id"), Name("user"))
+	users.Post("/users", testHandler("create user"))
+	billing := NewBuilder()
+	billing.Get("/invoices/:id:int64", testHandler("invoice %s", "id"))
+	billing.Get("/users/:id/invoices", testHandler("invoices of %s", "id"))
+
+	b := NewBuilder()
+	b.Get("/", testHandler("home"))
+	for _, other := range []*Builder{users, billing} {
+		if err := b.Merge(other); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := b.Build()
+	testRequests(t, mux, []reqTest{
+		{"GET", "/", "home"},
+		{"GET", "/users/3", "user 3"},
+		{"POST", "/users", "create user"},
+		{"GET", "/invoices/7", "invoice 7"},
+		{"GET", "/users/3/invoices", "invoices of 3"},
+		{"GET", "/invoices/x", "404"},
+	})
+	if _, ok := mux.RouteByName("user"); !ok {
+		t.Error("merged rule name not found")
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/a", testHandler("a"))
+	b.Get("/named", testHandler("named"), Name("x"))
+	before := b.Build().Routes()
+
+	for _, tt := range []struct {
+		setup func(other *Builder)
+		want  string
+	}{
+		{
+			func(other *Builder) {
+				other.Get("/b", testHandler("b"))
+				other.Get("/a", testHandler("a2"))
+			},
+			`hmux: GET "/a" conflicts with previously registered pattern "/a"`,
+		},
+		{
+			func(other *Builder) {
+				other.Get("/c", testHandler("c"))
+				other.Get("/d", testHandler("d"), Name("x"))
+			},
+			`hmux: GET "/d" is named "x", like previously registered GET "/named"`,
+		},
+	} {
+		other := NewBuilder()
+		tt.setup(other)
+		err := b.Merge(other)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("got error %v; want %q", err, tt.want)
+		}
+		if got := b.Build().Routes(); !reflect.DeepEqual(routeStrings(got), routeStrings(before)) {
+			t.Errorf("after failed Merge, got routes %v; want %v", routeStrings(got), routeStrings(before))
+		}
+	}
+	// The rolled-back name can be registered afterwards.
+	b.Get("/e", testHandler("e"), Name("e"))
+	w := httptest.NewRecorder()
+	b.Build().ServeHTTP(w, httptest.NewRequest("GET", "/e", nil))
+	if w.Body.String() != "e" {
+		t.Errorf("GET /e: got %q", w.Body.String())
+	}
+
+	if err := b.Merge(b); err == nil {
+		t.Error("merging a Builder into itself succeeded")
+	}
+}
+
+func routeStrings(routes []Route) []string {
+	s := make([]string, len(routes))
+	for i, rt := range routes {
+		s[i] = describeRoute(rt)
+	}
+	return s
+}
+
+func TestMergeParamTypes(t *testing.T) {
+	upper := func(s string) (interface{}, error) {
+		if strings.ToUpper(s) != s {
+			return nil, errors.New("not upper case")
+		}
+		return s, nil
+	}
+	lower := func(s string) (interface{}, error) {
+		if strings.ToLower(s) != s {
+			return nil, errors.New("not lower case")
+		}
+		return s, nil
+	}
+	a := NewBuilder()
+	a.RegisterParamType("case", upper)
+	a.Get("/x/:id:case", testHandler("a %s", "id"))
+	a.Post("/y/:id:case", testHandler("a %s", "id"))
+	c := NewBuilder()
+	c.RegisterParamType("case", lower)
+	c.Get("/x/:id:case", testHandler("c %s", "id"))
+	c.Post("/z/:id:case", testHandler("c %s", "id"))
+
+	b := NewBuilder()
+	for _, other := range []*Builder{a, c} {
+		if err := b.Merge(other); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/x/ABC", "a ABC"},
+		{"GET", "/x/abc", "c abc"},
+		{"GET", "/x/AbC", "404"},
+		{"POST", "/y/ABC", "a ABC"},
+		{"POST", "/y/abc", "404"},
+		{"POST", "/z/abc", "c abc"},
+		{"POST", "/z/AbC", "404"},
+	})
+}
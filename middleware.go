@@ -0,0 +1,35 @@
+package hmux
+
+import "net/http"
+
+// Middleware returns a RuleOption which wraps a rule's handler with the
+// given middlewares when the rule matches a request. The first middleware is
+// the outermost. This allows a single rule to add authentication or logging,
+// say, without creating a nested Mux. (To apply middlewares to several rules,
+// see Group.)
+//
+// Middlewares run after the request has been matched and after any OnMatch
+// functions (see Mux.ServeHTTP for the full sequence). The request they
+// receive carries the matched parameters, which they may read using
+// RequestParams and adjust using Params.Set; the handler sees the adjusted
+// parameters:
+//
+//	b.Get("/users/:user", handleUser, hmux.Middleware(canonicalUser))
+//
+// Each middleware is called once for each Mux built from the Builder, when
+// the Mux is built (not for each request), so it may set up state, such as a
+// rate limiter, shared by the requests it serves.
+//
+// Other options which wrap the handler, such as RequestHeaders and
+// Deadlines, are applied in the same sequence: a rule's options wrap the
+// handler in the order they are given, the first outermost.
+func Middleware(mws ...func(http.Handler) http.Handler) RuleOption {
+	for _, mw := range mws {
+		if mw == nil {
+			panic("hmux: Middleware called with nil middleware")
+		}
+	}
+	return func(rl *rule) {
+		rl.mws = append(rl.mws, mws...)
+	}
+}
@@ -0,0 +1,122 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var calls []string
+	trace := func(name string) func(http.Handler) http.Handler {
+		return func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				h.ServeHTTP(w, r)
+			})
+		}
+	}
+	lowerUser := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := RequestParams(r)
+			p.Set("user", strings.ToLower(p.Get("user")))
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	b := NewBuilder()
+	b.OnMatch(func(r *http.Request, m *Match) {
+		calls = append(calls, "onmatch")
+		if m.Route.Pattern == "/items/:name" {
+			m.Params.Set("name", "item-"+m.Params.Get("name"))
+		}
+	})
+	b.Get("/users/:user", testHandler("user %s", "user"),
+		Middleware(trace("a"), lowerUser), Middleware(trace("b")))
+	b.Get("/items/:name", testHandler("%s", "name"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/users/Alice", "user alice"},
+		{"GET", "/items/x", "item-x"},
+	})
+	if got, want := fmt.Sprint(calls), "[onmatch a b onmatch]"; got != want {
+		t.Errorf("got calls %s; want %s", got, want)
+	}
+}
+
+func TestParamsSet(t *testing.T) {
+	b := NewBuilder()
+	var p *Params
+	b.Get("/:s/:n:int32/:e:enum(a|b)", func(w http.ResponseWriter, r *http.Request) {
+		p = RequestParams(r)
+	})
+	testRequests(t, b.Build(), []reqTest{{"GET", "/x/3/a", ""}})
+
+	p.Set("s", "y")
+	p.Set("e", "b")
+	if got := p.Get("s") + p.Get("e"); got != "yb" {
+		t.Errorf("after Set, got %q; want %q", got, "yb")
+	}
+	for _, tt := range []struct {
+		name, value string
+	}{
+		{"n", "4"},
+		{"s", ""},
+		{"missing", "x"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Set(%q, %q) did not panic", tt.name, tt.value)
+				}
+			}()
+			p.Set(tt.name, tt.value)
+		}()
+	}
+}
+
+func TestMiddlewareBuiltOnce(t *testing.T) {
+	var built, served int
+	counter := func(h http.Handler) http.Handler {
+		built++
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served++
+			h.ServeHTTP(w, r)
+		})
+	}
+	b := NewBuilder()
+	b.Get("/a", testHandler("a"), Middleware(counter))
+	b.Get("/b", testHandler("b"), Middleware(counter))
+	b.Get("/c", testHandler("c"))
+	mux := b.Build()
+	if built != 2 {
+		t.Fatalf("after Build, middleware called %d times; want 2", built)
+	}
+	testRequests(t, mux, []reqTest{
+		{"GET", "/a", "a"},
+		{"GET", "/a", "a"},
+		{"GET", "/b", "b"},
+		{"GET", "/c", "c"},
+	})
+	if built != 2 || served != 3 {
+		t.Errorf("got %d middleware calls and %d wrapped requests; want 2 and 3", built, served)
+	}
+
+	// A handler replaced by OnMatch is still wrapped by the middlewares,
+	// which are not rebuilt.
+	b.OnMatch(func(r *http.Request, m *Match) {
+		if m.Route.Pattern == "/b" {
+			m.Handler = testHandler("replaced")
+		}
+	})
+	built, served = 0, 0
+	mux = b.Build()
+	testRequests(t, mux, []reqTest{
+		{"GET", "/a", "a"},
+		{"GET", "/b", "replaced"},
+		{"GET", "/b", "replaced"},
+	})
+	if built != 4 || served != 3 {
+		t.Errorf("with OnMatch, got %d middleware calls and %d wrapped requests; want 4 and 3", built, served)
+	}
+}
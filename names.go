@@ -0,0 +1,85 @@
+package hmux
+
+// Name returns a RuleOption which names a rule. Names identify rules more
+// stably than their patterns do, for use as metrics labels and in
+// documentation, for instance; the name of a matched rule is available to
+// OnMatch functions as the Name field of the Match's Route, a rule can be
+// looked up by name using Mux.RouteByName, and Mux.URL builds the URL of a
+// named rule from parameter values:
+//
+//	b.Get("/users/:id", showUser, hmux.Name("user.show"))
+//
+// Names must be unique: registering a rule with the same name as a
+// previously registered rule panics. The exception is the rules registered by
+// a single call, such as the GET and HEAD rules of ServeFile or the rules of
+// HandleMethods, which share the name given to the call. (Resource instead
+// names each of its rules after its action.) The rules given to BuildWith
+// are not checked against those they override; if several rules of a Mux
+// have the same name, RouteByName returns the one that the Mux considers
+// first.
+func Name(name string) RuleOption {
+	if name == "" {
+		panic("hmux: Name called with empty name")
+	}
+	return func(rl *rule) {
+		rl.name = name
+	}
+}
+
+// addName records the name of rl, if it has one, after rl is added to b.
+func (b *Builder) addName(rl *rule) {
+	if rl.name == "" {
+		return
+	}
+	if _, ok := b.names[rl.name]; ok {
+		// Another rule registered by the same call has the name.
+		return
+	}
+	if b.names == nil {
+		b.names = make(map[string]*rule)
+	}
+	b.names[rl.name] = rl
+	b.nameLog = append(b.nameLog, rl.name)
+}
+
+// sameCallName reports whether name was given to a rule registered by the
+// current call of atomically.
+func (b *Builder) sameCallName(name string) bool {
+	if !b.inCall {
+		return false
+	}
+	for _, name1 := range b.nameLog[b.callNames:] {
+		if name1 == name {
+			return true
+		}
+	}
+	return false
+}
+
+// indexNames records the named rules of m. If two rules have the same name
+// (which may happen with BuildWith), the one which m considers first wins.
+func (m *Mux) indexNames() {
+	for _, ma := range m.matchers {
+		ma.forEachRule(func(rl *rule) {
+			if rl.name == "" {
+				return
+			}
+			if m.names == nil {
+				m.names = make(map[string]*rule)
+			}
+			if _, ok := m.names[rl.name]; !ok {
+				m.names[rl.name] = rl
+			}
+		})
+	}
+}
+
+// RouteByName returns the route of the rule of m with the given name (see
+// Name). It reports false if there is no such rule.
+func (m *Mux) RouteByName(name string) (Route, bool) {
+	rl, ok := m.names[name]
+	if !ok {
+		return Route{}, false
+	}
+	return rl.route(), true
+}
@@ -0,0 +1,94 @@
+package hmux
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/users/:id", testHandler("user %s", "id"), Name("user.show"))
+	b.Put("/users/:id", testHandler("put user %s", "id"), Name("user.update"))
+	b.Get("/", testHandler("home"))
+	var names []string
+	b.OnMatch(func(r *http.Request, m *Match) {
+		names = append(names, m.Route.Name)
+	})
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/users/3", "user 3"},
+		{"PUT", "/users/3", "put user 3"},
+		{"GET", "/", "home"},
+	})
+	if want := []string{"user.show", "user.update", ""}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got names %q; want %q", names, want)
+	}
+
+	rt, ok := mux.RouteByName("user.update")
+	if !ok || rt.Method != "PUT" || rt.Pattern != "/users/:id" || rt.Name != "user.update" {
+		t.Errorf(`RouteByName("user.update") = %+v, %t`, rt, ok)
+	}
+	if _, ok := mux.RouteByName("user.delete"); ok {
+		t.Error(`RouteByName("user.delete") found a route`)
+	}
+}
+
+func TestNameConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/a", testHandler("a"), Name("x"))
+	err := b.TryHandle("GET", "/b", testHandler("b"), Name("x"))
+	if want := `hmux: GET "/b" is named "x", like previously registered GET "/a"`; err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %q", err, want)
+	}
+
+	// Names registered by a failed atomic registration are released.
+	err = b.TryHandleMethods([]string{"POST", "GET"}, "/a", testHandler(""), Name("y"))
+	if err == nil {
+		t.Fatal("TryHandleMethods succeeded despite conflict")
+	}
+	if err := b.TryHandle("GET", "/c", testHandler("c"), Name("y")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overrides may reuse names.
+	overrides := NewBuilder()
+	overrides.Get("/a", testHandler("a2"), Name("x"))
+	mux := b.BuildWith(overrides)
+	testRequests(t, mux, []reqTest{{"GET", "/a", "a2"}})
+	if rt, ok := mux.RouteByName("x"); !ok || rt.Pattern != "/a" {
+		t.Errorf(`RouteByName("x") = %+v, %t`, rt, ok)
+	}
+}
+
+func TestNameSharedByCall(t *testing.T) {
+	b := NewBuilder()
+	b.ServeFile("/robots.txt", "testdata/robots.txt", Name("robots"))
+	b.HandleMethods([]string{"GET", "POST"}, "/form", testHandler("form"), Name("form"))
+	mux := b.Build()
+	var got []string
+	for _, rt := range mux.Routes() {
+		got = append(got, rt.Method+" "+rt.Name)
+	}
+	want := []string{"GET robots", "HEAD robots", "GET form", "POST form"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got routes %q; want %q", got, want)
+	}
+	for _, name := range []string{"robots", "form"} {
+		if _, ok := mux.RouteByName(name); !ok {
+			t.Errorf("RouteByName(%q) found no route", name)
+		}
+	}
+
+	// Separate calls still may not share a name.
+	if err := b.TryHandle("PUT", "/form", testHandler(""), Name("form")); err == nil {
+		t.Error("registering a second rule named form succeeded")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("ServeFile with a used name did not panic")
+		}
+	}()
+	b.ServeFile("/other.txt", "testdata/robots.txt", Name("robots"))
+}
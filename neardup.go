@@ -0,0 +1,95 @@
+package hmux
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ReportNearDuplicates registers a function that Build calls for each pair of
+// rules whose patterns are suspiciously similar, which often indicates a
+// copy-and-paste mistake. The pairs reported are:
+//
+//   - rules with equivalent patterns (for different methods) that use
+//     different parameter names, such as "/users/:id" and "/users/:userid";
+//   - rules with patterns that differ only in the types of their parameters,
+//     such as "/users/:id:int32" and "/users/:id:int64".
+//
+// Patterns that differ in both parameter names and types, such as
+// "/users/:id:int64" and "/users/:name", are assumed to be intentional.
+// The reason passed to report describes the similarity.
+func (b *Builder) ReportNearDuplicates(report func(rt1, rt2 Route, reason string)) {
+	b.checkNotGroup("ReportNearDuplicates")
+	if report == nil {
+		panic("hmux: ReportNearDuplicates called with nil function")
+	}
+	b.nearDuplicates = report
+}
+
+func reportNearDuplicates(matchers []*matcher, report func(rt1, rt2 Route, reason string)) {
+	// Each matcher's rules have equivalent patterns. Compare their
+	// parameter names with those of the first rule.
+	for _, ma := range matchers {
+		var first *rule
+		ma.forEachRule(func(rl *rule) {
+			if first == nil {
+				first = rl
+				return
+			}
+			if paramNames(first.p) != paramNames(rl.p) {
+				report(first.route(), rl.route(), "patterns differ only in parameter names")
+			}
+		})
+	}
+
+	// Group matchers by the shape of their patterns, ignoring parameter
+	// types, and report those whose parameter names also match.
+	byShape := make(map[string]*rule)
+	for _, ma := range matchers {
+		var rl0 *rule
+		ma.forEachRule(func(rl *rule) {
+			if rl0 == nil {
+				rl0 = rl
+			}
+		})
+		if rl0 == nil || !rl0.p.hasParams() {
+			continue
+		}
+		key := patternShape(rl0.p)
+		if prev, ok := byShape[key]; ok {
+			report(prev.route(), rl0.route(), "patterns differ only in parameter types")
+			continue
+		}
+		byShape[key] = rl0
+	}
+}
+
+func paramNames(p pattern) string {
+	var names []string
+	for _, seg := range p.segs {
+		if seg.isParam {
+			names = append(names, seg.s)
+		}
+	}
+	return strings.Join(names, "\x00")
+}
+
+// patternShape describes p ignoring parameter types (but not names).
+func patternShape(p pattern) string {
+	var sb strings.Builder
+	sb.WriteByte(byte('0' + p.opt))
+	for _, seg := range p.segs {
+		if seg.isParam {
+			sb.WriteString("/:")
+			sb.WriteString(strconv.Quote(seg.s))
+			sb.WriteString(strconv.Quote(seg.suffix))
+		} else {
+			sb.WriteByte('/')
+			sb.WriteString(strconv.Quote(seg.s))
+		}
+	}
+	for _, g := range p.glob {
+		sb.WriteString("/*")
+		sb.WriteString(strconv.Quote(g))
+	}
+	return sb.String()
+}
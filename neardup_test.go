@@ -0,0 +1,34 @@
+package hmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReportNearDuplicates(t *testing.T) {
+	b := NewBuilder()
+	var got []string
+	b.ReportNearDuplicates(func(rt1, rt2 Route, reason string) {
+		got = append(got, rt1.Method+" "+rt1.Pattern+" | "+rt2.Method+" "+rt2.Pattern+" | "+reason)
+	})
+	b.Get("/users/:id", testHandler(""))
+	b.Put("/users/:userid", testHandler(""))
+	b.Delete("/users/:id", testHandler(""))
+	b.Get("/orders/:id:int32", testHandler(""))
+	b.Get("/orders/:id:int64", testHandler(""))
+	b.Get("/items/:id:int64", testHandler(""))
+	b.Get("/items/:name", testHandler(""))
+	b.Get("/files/:name.json", testHandler(""))
+	b.Get("/files/:name.csv", testHandler(""))
+	b.Get("/a/b", testHandler(""))
+	b.Get("/a/%3ab", testHandler(""))
+	b.Build()
+
+	want := []string{
+		"DELETE /users/:id | PUT /users/:userid | patterns differ only in parameter names",
+		"GET /orders/:id:int32 | GET /orders/:id:int64 | patterns differ only in parameter types",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got reports\n%q\nwant\n%q", got, want)
+	}
+}
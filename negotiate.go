@@ -0,0 +1,126 @@
+package hmux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Produces returns a RuleOption which declares that a rule's handler produces
+// responses of the given media type (such as "application/json"). Several
+// rules for the same pattern and method may produce different media types;
+// the Mux chooses among them by negotiating with the request's Accept header:
+//
+//	b.Get("/report", serveReportHTML, hmux.Produces("text/html"))
+//	b.Get("/report", serveReportJSON, hmux.Produces("application/json"))
+//	b.Get("/report", serveReportCSV, hmux.Produces("text/csv"))
+//
+// The Mux routes the request to the rule whose media type has the highest
+// quality value in the Accept header, as given by the most specific media
+// range that includes the media type. Among rules with equal quality values,
+// the one registered first is chosen. (A request without an Accept header
+// accepts every media type equally.)
+//
+// If the Accept header excludes all of the rules' media types, the Mux
+// responds with a 406 ("Not Acceptable") unless another rule for the same
+// pattern and method (without a Produces option) matches.
+//
+// When a request is routed among rules with Produces options, the Mux adds
+// Accept to the Vary header of the response. It also sets the Content-Type
+// header of the response to the chosen media type before calling the
+// handler, which may change it.
+//
+// Produces panics if mediaType is not of the form type/subtype.
+func Produces(mediaType string) RuleOption {
+	mt := strings.ToLower(mediaType)
+	slash := strings.IndexByte(mt, '/')
+	if slash <= 0 || slash == len(mt)-1 || strings.ContainsAny(mt, " ;,*") {
+		panic("hmux: invalid media type " + mediaType)
+	}
+	return func(rl *rule) {
+		rl.produces = mt
+		rl.conds = append(rl.conds, producesCond(mt))
+	}
+}
+
+type producesCond string
+
+func (c producesCond) check(r *http.Request, _ *rule, _ *Params) int {
+	if acceptQuality(r.Header.Get("Accept"), string(c)) == 0 {
+		return http.StatusNotAcceptable
+	}
+	return condOK
+}
+
+func (c producesCond) key() string { return "produces:" + string(c) }
+
+func (c producesCond) respond(w http.ResponseWriter, r *http.Request, status int) {
+	w.Header().Add("Vary", "Accept")
+	http.Error(w, http.StatusText(status), status)
+}
+
+// bestQuality returns the highest quality value that the Accept header of r
+// gives to the media type of any of rules.
+func bestQuality(r *http.Request, rules ...[]*rule) float64 {
+	accept := r.Header.Get("Accept")
+	best := 0.0
+	for _, rs := range rules {
+		for _, rl := range rs {
+			if rl.produces == "" {
+				continue
+			}
+			if q := acceptQuality(accept, rl.produces); q > best {
+				best = q
+			}
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality value which the Accept header value
+// accept gives the media type mt (which is lowercase). The most specific
+// matching media range determines the quality. An empty header accepts
+// everything.
+func acceptQuality(accept, mt string) float64 {
+	if strings.TrimSpace(accept) == "" {
+		return 1
+	}
+	typ := mt[:strings.IndexByte(mt, '/')+1] // includes slash
+	q := 0.0
+	specificity := -1
+	for _, rng := range strings.Split(accept, ",") {
+		params := strings.Split(rng, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		var spec int
+		switch {
+		case name == mt:
+			spec = 2
+		case name == typ+"*":
+			spec = 1
+		case name == "*/*":
+			spec = 0
+		default:
+			continue
+		}
+		if spec < specificity {
+			continue
+		}
+		rq := 1.0
+		for _, param := range params[1:] {
+			eq := strings.IndexByte(param, '=')
+			if eq < 0 || strings.ToLower(strings.TrimSpace(param[:eq])) != "q" {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(param[eq+1:]), 64)
+			if err != nil || f < 0 || f > 1 {
+				f = 0
+			}
+			rq = f
+		}
+		if spec > specificity || rq > q {
+			q = rq
+		}
+		specificity = spec
+	}
+	return q
+}
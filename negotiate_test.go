@@ -0,0 +1,77 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProduces(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/report", testHandler("html"), Produces("text/html"))
+	b.Get("/report", testHandler("json"), Produces("application/json"))
+	b.Get("/report", testHandler("csv"), Produces("text/csv"))
+	b.Get("/doc", testHandler("doc json"), Produces("application/json"))
+	b.Get("/doc", testHandler("doc fallback"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		path     string
+		accept   string
+		wantCode int
+		want     string
+	}{
+		{"/report", "", 200, "html"},
+		{"/report", "application/json", 200, "json"},
+		{"/report", "text/*", 200, "html"},
+		{"/report", "text/*;q=0.5, text/csv", 200, "csv"},
+		{"/report", "text/html;q=0.2, application/json;q=0.8", 200, "json"},
+		{"/report", "*/*;q=0.1, text/csv;q=0.3, text/html;q=0.2", 200, "csv"},
+		{"/report", "text/*, text/html;q=0", 200, "csv"},
+		{"/report", "Application/JSON; Q=1", 200, "json"},
+		{"/report", "image/png", 406, ""},
+		{"/report", "*/*;q=0", 406, ""},
+		{"/doc", "application/json", 200, "doc json"},
+		{"/doc", "text/html", 200, "doc fallback"},
+	} {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tt.wantCode {
+			t.Errorf("GET %s with Accept %q: got code %d; want %d", tt.path, tt.accept, w.Code, tt.wantCode)
+			continue
+		}
+		if got := w.Header().Get("Vary"); got != "Accept" {
+			t.Errorf("GET %s with Accept %q: got Vary %q; want Accept", tt.path, tt.accept, got)
+		}
+		if tt.wantCode != 200 {
+			continue
+		}
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("GET %s with Accept %q: got %q; want %q", tt.path, tt.accept, got, tt.want)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/report", nil)
+	r.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if got := w.Header().Get("Content-Type"); got != "text/csv" {
+		t.Errorf("got Content-Type %q; want text/csv", got)
+	}
+}
+
+func TestProducesInvalid(t *testing.T) {
+	for _, mt := range []string{"json", "text/*", "text/plain;charset=utf-8", "/json"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Produces(%q) did not panic", mt)
+				}
+			}()
+			Produces(mt)
+		}()
+	}
+}
@@ -0,0 +1,69 @@
+package hmux
+
+// A RuleOption configures a rule as it is registered with a Builder.
+// RuleOptions may be passed to any of the Builder methods which register
+// rules, such as Get, Handle, and Prefix.
+type RuleOption func(*rule)
+
+// Meta returns a RuleOption which attaches a metadata key-value pair to a
+// rule. Metadata does not affect routing, but it is available to code which
+// inspects the rule using its Route (such as an OnMatch function) and to
+// features, such as ServeRobots, that consult it.
+func Meta(key, value string) RuleOption {
+	return func(rl *rule) {
+		if rl.meta == nil {
+			rl.meta = make(map[string]string)
+		}
+		rl.meta[key] = value
+	}
+}
+
+// Streaming returns a RuleOption which marks a rule's handler as streaming
+// its response, as with server-sent events or long polling. Features of this
+// package which buffer response bodies do not apply to streaming rules, so a
+// handler may write (and flush) an unbounded response.
+//
+// Middleware which buffers responses should likewise skip streaming rules.
+// An OnMatch function can check the Streaming field of the matched Route
+// before wrapping the handler.
+func Streaming() RuleOption {
+	return func(rl *rule) {
+		rl.streaming = true
+	}
+}
+
+// RangeRequests returns a RuleOption which marks a rule's handler as serving
+// HTTP range requests, as do http.FileServer and typical reverse proxies.
+// Features of this package which buffer or transform response bodies do not
+// apply to such rules, and header transformations (see RequestHeaders and
+// ResponseHeaders) leave the Range, If-Range, Accept-Ranges, and
+// Content-Range headers unchanged.
+//
+// As with Streaming, middleware can check the RangeRequests field of the
+// matched Route to skip such rules.
+func RangeRequests() RuleOption {
+	return func(rl *rule) {
+		rl.ranges = true
+	}
+}
+
+// Priority returns a RuleOption which overrides the specificity ordering of
+// patterns (see Routing) for a rule. A Mux considers rules with higher
+// priorities before rules with lower priorities, regardless of their
+// patterns; rules with the same priority are ordered by specificity as
+// usual. Rules have priority 0 by default.
+//
+// For example, during a migration, a parameterized rule may need to take
+// precedence over a literal rule for the same paths:
+//
+//	b.Get("/users/new", handleNewUserForm)
+//	b.Get("/users/:id", handleUser, hmux.Priority(1))
+//
+// Here, a request for /users/new is routed to handleUser. Since the priority
+// of a rule's pattern is part of its specificity, rules with equivalent
+// patterns but different priorities do not conflict.
+func Priority(n int) RuleOption {
+	return func(rl *rule) {
+		rl.p.priority = n
+	}
+}
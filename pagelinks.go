@@ -0,0 +1,91 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// AddPageLinks adds Link headers (RFC 8288) to h giving the URLs of the
+// first, previous, next, and last pages of a paginated listing served by the
+// rule of m with the given name (see Name). The URLs are built as by URLMap
+// from params, the parameters of the current page, so they always match the
+// rule:
+//
+//	b.Get("/users/:id/posts", listPosts, hmux.Name("posts"))
+//	...
+//	err := mux.AddPageLinks(w.Header(), "posts", map[string]interface{}{"id": id}, "page", page, last)
+//
+// adds, for page 2 of 5 of user 3's posts,
+//
+//	Link: </users/3/posts?page=1>; rel="first"
+//	Link: </users/3/posts?page=1>; rel="prev"
+//	Link: </users/3/posts?page=3>; rel="next"
+//	Link: </users/3/posts?page=5>; rel="last"
+//
+// Pages are numbered from 1 to last. If the rule has a parameter named
+// pageParam (as in "/posts/page/:page:int32"), the page number is given in
+// the path; otherwise, it is given in the query parameter pageParam. The
+// first and previous links are omitted on the first page and the next and
+// last links are omitted on the last. (For a page beyond the last, the
+// previous link is to the last page.)
+//
+// AddPageLinks returns an error, and adds no headers, if page or last is less
+// than 1 or if URLMap would return an error for the rule and params.
+func (m *Mux) AddPageLinks(h http.Header, name string, params map[string]interface{}, pageParam string, page, last int) error {
+	if page < 1 || last < 1 {
+		return fmt.Errorf("hmux: invalid page %d of %d", page, last)
+	}
+	rl, err := m.urlRule(name)
+	if err != nil {
+		return err
+	}
+	inPath := rl.p.hasParam(pageParam)
+	if _, ok := params[pageParam]; ok && inPath {
+		return fmt.Errorf("hmux: page parameter %q given in params", pageParam)
+	}
+	pageURL := func(n int) (string, error) {
+		if !inPath {
+			u, err := m.URLMap(name, params)
+			if err != nil {
+				return "", err
+			}
+			return u + "?" + url.Values{pageParam: {strconv.Itoa(n)}}.Encode(), nil
+		}
+		vals := make(map[string]interface{}, len(params)+1)
+		for k, v := range params {
+			vals[k] = v
+		}
+		vals[pageParam] = n
+		return m.URLMap(name, vals)
+	}
+
+	type link struct {
+		page int
+		rel  string
+	}
+	var links []link
+	if page > 1 {
+		prev := page - 1
+		if prev > last {
+			prev = last
+		}
+		links = append(links, link{1, "first"}, link{prev, "prev"})
+	}
+	if page < last {
+		links = append(links, link{page + 1, "next"}, link{last, "last"})
+	}
+	vals := make([]string, len(links))
+	for i, l := range links {
+		u, err := pageURL(l.page)
+		if err != nil {
+			return err
+		}
+		vals[i] = fmt.Sprintf("<%s>; rel=%q", u, l.rel)
+	}
+	for _, v := range vals {
+		h.Add("Link", v)
+	}
+	return nil
+}
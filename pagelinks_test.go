@@ -0,0 +1,78 @@
+package hmux
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddPageLinks(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/users/:id:int64/posts", testHandler("posts"), Name("posts"))
+	b.Get("/archive/:year/page/:page:int32", testHandler("archive"), Name("archive"))
+	mux := b.Build()
+
+	user := map[string]interface{}{"id": 3}
+	for _, tt := range []struct {
+		name   string
+		params map[string]interface{}
+		page   int
+		last   int
+		want   []string // or an error substring, prefixed with "error: "
+	}{
+		{"posts", user, 2, 5, []string{
+			`</users/3/posts?page=1>; rel="first"`,
+			`</users/3/posts?page=1>; rel="prev"`,
+			`</users/3/posts?page=3>; rel="next"`,
+			`</users/3/posts?page=5>; rel="last"`,
+		}},
+		{"posts", user, 1, 2, []string{
+			`</users/3/posts?page=2>; rel="next"`,
+			`</users/3/posts?page=2>; rel="last"`,
+		}},
+		{"posts", user, 2, 2, []string{
+			`</users/3/posts?page=1>; rel="first"`,
+			`</users/3/posts?page=1>; rel="prev"`,
+		}},
+		{"posts", user, 9, 2, []string{
+			`</users/3/posts?page=1>; rel="first"`,
+			`</users/3/posts?page=2>; rel="prev"`,
+		}},
+		{"posts", user, 1, 1, nil},
+		{"archive", map[string]interface{}{"year": "2020"}, 2, 3, []string{
+			`</archive/2020/page/1>; rel="first"`,
+			`</archive/2020/page/1>; rel="prev"`,
+			`</archive/2020/page/3>; rel="next"`,
+			`</archive/2020/page/3>; rel="last"`,
+		}},
+		{"posts", user, 0, 2, []string{"error: invalid page 0 of 2"}},
+		{"posts", map[string]interface{}{"id": "x"}, 1, 2, []string{"error: does not match"}},
+		{"posts", map[string]interface{}{"id": 3, "x": 1}, 1, 2, []string{`error: has no parameter "x"`}},
+		{"archive", map[string]interface{}{"year": "2020", "page": 1}, 1, 2, []string{`error: page parameter "page" given`}},
+		{"nope", nil, 1, 2, []string{`error: no rule named "nope"`}},
+	} {
+		h := make(http.Header)
+		err := mux.AddPageLinks(h, tt.name, tt.params, "page", tt.page, tt.last)
+		if len(tt.want) == 1 && strings.HasPrefix(tt.want[0], "error: ") {
+			want := strings.TrimPrefix(tt.want[0], "error: ")
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("AddPageLinks(%q, %v, %d, %d): got error %v; want error containing %q",
+					tt.name, tt.params, tt.page, tt.last, err, want)
+			}
+			if len(h) > 0 {
+				t.Errorf("AddPageLinks(%q, %v, %d, %d) failed but added headers %v",
+					tt.name, tt.params, tt.page, tt.last, h)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("AddPageLinks(%q, %v, %d, %d): %s", tt.name, tt.params, tt.page, tt.last, err)
+			continue
+		}
+		if got := h["Link"]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AddPageLinks(%q, %v, %d, %d): got links %q; want %q",
+				tt.name, tt.params, tt.page, tt.last, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxParamLen returns a RuleOption which limits the value of the named
+// parameter of a rule's pattern to n characters, so that absurdly long path
+// segments are rejected by the Mux rather than passed on to handlers (and,
+// say, used as database keys):
+//
+//	b.Get("/users/:name", serveUser, hmux.MaxParamLen("name", 64))
+//
+// If the value in a request is longer, the Mux ignores the rule and continues
+// looking for a matching rule; if there is none, it responds with a 414
+// ("URI Too Long"). (By contrast, a request for which the string(min,max)
+// parameter type is too long does not match the pattern at all and so
+// receives a 404.) The length is measured in runes, before any mappings
+// given by MapParam or TrimParam are applied.
+//
+// MaxParamLen panics if n is not positive, if the rule's pattern has no
+// parameter with the given name, or if the parameter's type is not string
+// (with or without length bounds).
+func MaxParamLen(name string, n int) RuleOption {
+	if n <= 0 {
+		panic(fmt.Sprintf("hmux: MaxParamLen called with invalid length %d", n))
+	}
+	return func(rl *rule) {
+		checkStringParam(rl, "MaxParamLen", name)
+		rl.conds = append(rl.conds, maxLenCond{name, n})
+	}
+}
+
+type maxLenCond struct {
+	name string
+	n    int
+}
+
+func (c maxLenCond) check(_ *http.Request, _ *rule, p *Params) int {
+	if utf8.RuneCountInString(paramValue(p, c.name)) > c.n {
+		return http.StatusRequestURITooLong
+	}
+	return condOK
+}
+
+func (c maxLenCond) key() string { return "maxlen:" + c.name + "=" + strconv.Itoa(c.n) }
+
+// TrimParam returns a RuleOption which removes leading and trailing white
+// space from the value of the named parameter of a rule's pattern before
+// the value is made available through Params, as if by
+//
+//	hmux.MapParam(name, strings.TrimSpace)
+//
+// except that a value consisting only of white space does not match: the Mux
+// ignores the rule for such a request and continues looking for a matching
+// rule.
+//
+// TrimParam panics if the rule's pattern has no parameter with the given name
+// or if the parameter's type is not string (with or without length bounds).
+func TrimParam(name string) RuleOption {
+	return func(rl *rule) {
+		checkStringParam(rl, "TrimParam", name)
+		rl.conds = append(rl.conds, trimCond(name))
+		rl.paramMaps = append(rl.paramMaps, paramMap{name, strings.TrimSpace})
+	}
+}
+
+type trimCond string
+
+func (c trimCond) check(_ *http.Request, _ *rule, p *Params) int {
+	if strings.TrimSpace(paramValue(p, string(c))) == "" {
+		return condSkip
+	}
+	return condOK
+}
+
+func (c trimCond) key() string { return "trim:" + string(c) }
+
+// paramValue returns the value of the named param of p, or "" if there is
+// none.
+func paramValue(p *Params, name string) string {
+	if p == nil {
+		return ""
+	}
+	for _, pp := range p.ps {
+		if pp.name == name {
+			return pp.val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package hmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxParamLen(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/users/:name", testHandler("user %s", "name"), MaxParamLen("name", 5))
+	b.Get("/keys/:k", testHandler("key %s", "k"), MaxParamLen("k", 3))
+	b.Get("/keys/:k", testHandler("long key %s", "k"), MaxParamLen("k", 10), Query("long", "1"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/users/alice", "user alice"},
+		{"GET", "/users/%C3%A9%C3%A9%C3%A9%C3%A9%C3%A9", "user ééééé"},
+		{"GET", "/users/alexander", "414"},
+		{"GET", "/keys/abc", "key abc"},
+		{"GET", "/keys/abcdef", "414"},
+		{"GET", "/keys/abcdef?long=1", "long key abcdef"},
+	})
+}
+
+func TestTrimParam(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/tags/:tag", testHandler("tag %s", "tag"), TrimParam("tag"))
+	b.Get("/slugs/:s", testHandler("slug %s", "s"), TrimParam("s"), MapParam("s", strings.ToUpper), MaxParamLen("s", 4))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/tags/%20go%20", "tag go"},
+		{"GET", "/tags/go", "tag go"},
+		{"GET", "/tags/%20%20", "404"},
+		{"GET", "/slugs/%20ab%20", "slug AB"},
+		{"GET", "/slugs/%20abc%20", "414"},
+	})
+
+	for _, opt := range []func() RuleOption{
+		func() RuleOption { return TrimParam("x") },
+		func() RuleOption { return MaxParamLen("x", 3) },
+		func() RuleOption { return MaxParamLen("id", 0) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			NewBuilder().Get("/a/:id:int32", testHandler(""), opt())
+		}()
+	}
+}
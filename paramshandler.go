@@ -0,0 +1,36 @@
+package hmux
+
+import "net/http"
+
+// A ParamsHandler is an http.Handler which can receive the Params of a
+// request directly.
+//
+// Ordinarily, a Mux passes Params to a handler by attaching them to the
+// request's context, which requires a copy of the request (see
+// http.Request.WithContext). When the handler for a matched rule with
+// parameters is a ParamsHandler, the Mux instead calls ServeHTTPParams with
+// the original request, avoiding the copy. Consequently, RequestParams does
+// not return the Params inside ServeHTTPParams; the handler must use p,
+// which is nil if the rule has no parameters.
+//
+// The Mux uses ServeHTTP as usual if the rule's handler is wrapped by other
+// functionality, such as a RuleOption which modifies requests or responses,
+// or if the Builder was configured with CanonicalBase.
+type ParamsHandler interface {
+	http.Handler
+	ServeHTTPParams(w http.ResponseWriter, r *http.Request, p *Params)
+}
+
+// ParamsHandlerFunc is an adapter to allow the use of ordinary functions as
+// ParamsHandlers.
+type ParamsHandlerFunc func(w http.ResponseWriter, r *http.Request, p *Params)
+
+// ServeHTTPParams calls f(w, r, p).
+func (f ParamsHandlerFunc) ServeHTTPParams(w http.ResponseWriter, r *http.Request, p *Params) {
+	f(w, r, p)
+}
+
+// ServeHTTP calls f(w, r, RequestParams(r)).
+func (f ParamsHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f(w, r, RequestParams(r))
+}
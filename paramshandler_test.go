@@ -0,0 +1,36 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParamsHandler(t *testing.T) {
+	h := ParamsHandlerFunc(func(w http.ResponseWriter, r *http.Request, p *Params) {
+		if p == nil {
+			fmt.Fprint(w, "no params")
+			return
+		}
+		fmt.Fprintf(w, "%s ctx=%t", p.Get("a"), RequestParams(r) != nil)
+	})
+	inner := NewBuilder()
+	inner.Handle("GET", "/x", h)
+	inner.Handle("GET", "/:b", ParamsHandlerFunc(func(w http.ResponseWriter, r *http.Request, p *Params) {
+		fmt.Fprintf(w, "%s %s", p.Get("a"), p.Get("b"))
+	}))
+	b := NewBuilder()
+	b.Handle("GET", "/a/:a", h)
+	b.Handle("GET", "/wrapped/:a", h, RequestHeaders(HeaderTransform{}))
+	b.Handle("GET", "/none", h)
+	b.Prefix("/nested/:a", inner.Build())
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/a/1", "1 ctx=false"},
+		{"GET", "/wrapped/1", "1 ctx=true"},
+		{"GET", "/none", "no params"},
+		{"GET", "/nested/1/x", "1 ctx=true"},
+		{"GET", "/nested/1/2", "1 2"},
+	})
+}
@@ -0,0 +1,65 @@
+package hmux
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+type customParamType struct {
+	name string
+	// order is the registration order among all types registered in the
+	// process, so that the types of different Builders (see Merge) are
+	// distinct and ordered.
+	order int64
+	parse func(string) (interface{}, error)
+}
+
+// paramTypeCount is the number of custom parameter types registered.
+var paramTypeCount int64
+
+// RegisterParamType defines a new parameter type which may be used in
+// patterns registered with b after this call. A pattern segment with the type
+// matches a request path segment if parse returns a nil error for the
+// (unescaped) segment; the value returned by parse is available to handlers
+// via Params.Value.
+//
+//	b.RegisterParamType("ulid", func(s string) (interface{}, error) {
+//		return ulid.Parse(s)
+//	})
+//	b.Get("/orders/:id:ulid", handleOrder)
+//
+// For the purpose of determining rule specificity, custom parameter types are
+// more specific than string parameters (with or without length bounds) and
+// less specific than the other built-in types. Among custom types, those
+// registered earlier are more specific.
+//
+// RegisterParamType panics if name is empty, contains a colon, period, or
+// parenthesis, or is already the name of a parameter type.
+func (b *Builder) RegisterParamType(name string, parse func(string) (interface{}, error)) {
+	if b.group != nil {
+		b.group.parent.RegisterParamType(name, parse)
+		return
+	}
+	if parse == nil {
+		panic("hmux: RegisterParamType called with nil parse function")
+	}
+	if name == "" || strings.ContainsAny(name, ":.()") {
+		panic(fmt.Sprintf("hmux: invalid parameter type name %q", name))
+	}
+	switch name {
+	case "string", "int32", "int64", "rfc3339", "hex", "enum":
+		panic(fmt.Sprintf("hmux: parameter type %q is built in", name))
+	}
+	if _, ok := b.paramTypes[name]; ok {
+		panic(fmt.Sprintf("hmux: parameter type %q is already registered", name))
+	}
+	if b.paramTypes == nil {
+		b.paramTypes = make(map[string]*customParamType)
+	}
+	b.paramTypes[name] = &customParamType{
+		name:  name,
+		order: atomic.AddInt64(&paramTypeCount, 1),
+		parse: parse,
+	}
+}
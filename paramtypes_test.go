@@ -0,0 +1,60 @@
+package hmux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCustomParamTypes(t *testing.T) {
+	b := NewBuilder()
+	b.RegisterParamType("upper", func(s string) (interface{}, error) {
+		if strings.ToUpper(s) != s {
+			return nil, errors.New("not uppercase")
+		}
+		return strings.ToLower(s), nil
+	})
+	b.RegisterParamType("even", func(s string) (interface{}, error) {
+		if len(s)%2 != 0 {
+			return nil, errors.New("odd length")
+		}
+		return len(s), nil
+	})
+	valueHandler := func(format string, name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, format, RequestParams(r).Value(name))
+		}
+	}
+	b.Get("/x/:p:even", valueHandler("even %d", "p"))
+	b.Get("/x/:p:upper", valueHandler("upper %s", "p"))
+	b.Get("/x/:p:int32", valueHandler("int32 %d", "p"))
+	b.Get("/x/:p", valueHandler("string %s", "p"))
+
+	testCases := []reqTest{
+		{"GET", "/x/123", "int32 123"},
+		{"GET", "/x/ABCD", "upper abcd"},
+		{"GET", "/x/ABC", "upper abc"},
+		{"GET", "/x/abcd", "even 4"},
+		{"GET", "/x/abc", "string abc"},
+		{"GET", "/x/%41%42", "upper ab"},
+	}
+	testRequests(t, b.Build(), testCases)
+}
+
+func TestRegisterParamTypeErrors(t *testing.T) {
+	parse := func(s string) (interface{}, error) { return s, nil }
+	for _, name := range []string{"", "a:b", "a(b)", "a.b", "int32", "hex", "dup"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterParamType(%q): got no panic", name)
+				}
+			}()
+			b := NewBuilder()
+			b.RegisterParamType("dup", parse)
+			b.RegisterParamType(name, parse)
+		}()
+	}
+}
@@ -0,0 +1,43 @@
+package hmux
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Port returns a RuleOption which restricts a rule to requests received by
+// the server on the given local port. This allows one Mux to back several
+// listeners while keeping some rules (such as administrative endpoints)
+// reachable on only one of them:
+//
+//	b.Get("/debug/vars", expvar.Handler().ServeHTTP, hmux.Port(9090))
+//
+// The port is determined from the local address that net/http records in the
+// request context (see http.LocalAddrContextKey), not from the Host header,
+// which is controlled by the client. If the local address is unavailable or
+// is not a TCP address, the rule does not match.
+//
+// If the port does not match, the Mux ignores the rule and continues looking
+// for a matching rule, as with Host.
+func Port(port int) RuleOption {
+	if port <= 0 || port > 65535 {
+		panic(fmt.Sprintf("hmux: invalid port %d", port))
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, portCond(port))
+	}
+}
+
+type portCond int
+
+func (c portCond) check(r *http.Request, _ *rule, _ *Params) int {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(*net.TCPAddr)
+	if !ok || addr.Port != int(c) {
+		return condSkip
+	}
+	return condOK
+}
+
+func (c portCond) key() string { return "port:" + strconv.Itoa(int(c)) }
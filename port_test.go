@@ -0,0 +1,44 @@
+package hmux
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPort(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/debug", testHandler("admin debug"), Port(9090))
+	b.Get("/:page", testHandler("page %s", "page"))
+	b.Get("/status", testHandler("status 9090"), Port(9090))
+	b.Get("/status", testHandler("status 8080"), Port(8080))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		addr net.Addr
+		path string
+		want string
+	}{
+		{&net.TCPAddr{Port: 9090}, "/debug", "admin debug"},
+		{&net.TCPAddr{Port: 8080}, "/debug", "page debug"},
+		{nil, "/debug", "page debug"},
+		{&net.UnixAddr{Name: "/tmp/sock", Net: "unix"}, "/debug", "page debug"},
+		{&net.TCPAddr{Port: 8080}, "/status", "status 8080"},
+		{&net.TCPAddr{Port: 9090}, "/status", "status 9090"},
+		{&net.TCPAddr{Port: 80}, "/status", "page status"},
+	} {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		if tt.addr != nil {
+			r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, tt.addr))
+		}
+		// The Host header is not consulted.
+		r.Host = "example.com:9090"
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("GET %s (local addr %v): got %q; want %q", tt.path, tt.addr, got, tt.want)
+		}
+	}
+}
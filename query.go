@@ -0,0 +1,53 @@
+package hmux
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Query returns a RuleOption which restricts a rule to requests whose URL
+// query includes the parameter name with the given value (among any other
+// values for the same name). If value is empty, the parameter need only be
+// present, with any value. This allows several handlers to serve one path
+// depending on the query:
+//
+//	b.Get("/search", serveSearchRSS, hmux.Query("format", "rss"))
+//	b.Get("/search", serveSearchJSON, hmux.Query("format", "json"))
+//	b.Get("/search", serveSearch)
+//
+// The query restriction is a condition (see Conditions): if the query does
+// not match, the Mux continues looking for a matching rule, such as the
+// unrestricted one above.
+func Query(name, value string) RuleOption {
+	if name == "" {
+		panic("hmux: Query called with empty name")
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, queryCond{name, value})
+	}
+}
+
+type queryCond struct {
+	name  string
+	value string
+}
+
+func (c queryCond) check(r *http.Request, _ *rule, _ *Params) int {
+	vals, ok := r.URL.Query()[c.name]
+	if !ok {
+		return condSkip
+	}
+	if c.value == "" {
+		return condOK
+	}
+	for _, v := range vals {
+		if v == c.value {
+			return condOK
+		}
+	}
+	return condSkip
+}
+
+func (c queryCond) key() string {
+	return "query:" + url.QueryEscape(c.name) + "=" + url.QueryEscape(c.value)
+}
@@ -0,0 +1,39 @@
+package hmux
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/search", testHandler("rss"), Query("format", "rss"))
+	b.Get("/search", testHandler("json"), Query("format", "json"))
+	b.Get("/search", testHandler("debug rss"), Query("format", "rss"), Query("debug", ""))
+	b.Get("/search", testHandler("plain"))
+	b.Get("/items/:id", testHandler("preview %s", "id"), Query("preview", ""))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/search", "plain"},
+		{"GET", "/search?format=rss", "rss"},
+		{"GET", "/search?format=json", "json"},
+		{"GET", "/search?format=xml", "plain"},
+		{"GET", "/search?format=xml&format=json", "json"},
+		{"GET", "/search?format=rss&debug", "debug rss"},
+		{"GET", "/search?debug=1", "plain"},
+		{"GET", "/items/3?preview", "preview 3"},
+		{"GET", "/items/3?preview=", "preview 3"},
+		{"GET", "/items/3", "404"},
+	})
+}
+
+func TestQueryConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler("a"), Query("a", "1"))
+	b.Get("/x", testHandler("b"), Query("a", "2"))
+	b.Get("/x", testHandler("c"), Query("a", ""))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule with the same query restriction did not panic")
+		}
+	}()
+	b.Get("/x", testHandler("d"), Query("a", "1"))
+}
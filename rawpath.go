@@ -0,0 +1,54 @@
+package hmux
+
+import "strings"
+
+// RawPathMatching sets whether Muxes built from b match patterns against the
+// escaped form of the request path (see url.URL.EscapedPath) rather than
+// unescaping each path segment first.
+//
+// By default, an escaped slash (%2F) in a request path is already treated as
+// part of a segment rather than as a separator, but parameter values and
+// wildcard remainders are unescaped, so the remainder matched by "/files/*"
+// is "/a/b" for both /files/a/b and /files/a%2Fb. With raw path matching
+// enabled, parameter values and wildcard remainders hold the escaped text
+// ("/a%2Fb" in the second case), so handlers can distinguish encoded slashes
+// from real ones and unescape the values themselves.
+//
+// Literal pattern segments and globs still match path segments which
+// unescape to the same text, so the pattern "/a%2fb" matches the paths
+// /a%2Fb and /a%2fb. Parameters, however, are matched against the escaped
+// text, including their types and literal suffixes.
+func (b *Builder) RawPathMatching(enable bool) {
+	b.checkNotGroup("RawPathMatching")
+	b.rawPath = enable
+}
+
+// rawUnescape returns the unescaped form of the path segment s if opts
+// indicates that path segments are escaped; otherwise, it returns s.
+func rawUnescape(s string, opts matchOpts) string {
+	if opts&optRaw == 0 || strings.IndexByte(s, '%') < 0 {
+		return s
+	}
+	return mustPathUnescape(s)
+}
+
+// rawUnescapeAll is like rawUnescape for several segments.
+func rawUnescapeAll(parts []string, opts matchOpts) []string {
+	if opts&optRaw == 0 {
+		return parts
+	}
+	var parts1 []string
+	for i, part := range parts {
+		part1 := rawUnescape(part, opts)
+		if part1 != part && parts1 == nil {
+			parts1 = append([]string(nil), parts...)
+		}
+		if parts1 != nil {
+			parts1[i] = part1
+		}
+	}
+	if parts1 == nil {
+		return parts
+	}
+	return parts1
+}
@@ -0,0 +1,56 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRawPathMatching(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/files/*", testHandler("files %s", "*"))
+	b.Get("/users/:name", testHandler("user %s", "name"))
+	b.Get("/docs/:name.json", testHandler("doc %s", "name"))
+	b.Get("/a%2fb", testHandler("a/b"))
+	b.Get("/assets/*.css", testHandler("css %s", "*"))
+	b.Get("/n/:n:int32", testHandler("n %s", "n"))
+	unescaped := b.Build()
+	b.RawPathMatching(true)
+	raw := b.Build()
+
+	testRequests(t, unescaped, []reqTest{
+		{"GET", "/files/a/b", "files /a/b"},
+		{"GET", "/files/a%2Fb", "files /a/b"},
+		{"GET", "/users/a%2Fb", "user a/b"},
+		{"GET", "/a%2Fb", "a/b"},
+	})
+	testRequests(t, raw, []reqTest{
+		{"GET", "/files/a/b", "files /a/b"},
+		{"GET", "/files/a%2Fb", "files /a%2Fb"},
+		{"GET", "/files/x%20y", "files /x%20y"},
+		{"GET", "/users/a%2Fb", "user a%2Fb"},
+		{"GET", "/users/bob", "user bob"},
+		{"GET", "/docs/a%2Fb.json", "doc a%2Fb"},
+		{"GET", "/docs/x%2Ejson", "404"},
+		{"GET", "/a%2Fb", "a/b"},
+		{"GET", "/a%2fb", "a/b"},
+		{"GET", "/%61%2Fb", "a/b"},
+		{"GET", "/assets/a%2Fb.css", "css /a%2Fb.css"},
+		{"GET", "/n/12", "n 12"},
+		{"GET", "/n/%312", "404"},
+	})
+}
+
+func TestRawPathCanonicalURL(t *testing.T) {
+	b := NewBuilder()
+	b.RawPathMatching(true)
+	b.CanonicalBase("https://example.com")
+	var got string
+	b.Get("/files/:name/*", func(w http.ResponseWriter, r *http.Request) {
+		got = CanonicalURL(r)
+	})
+	b.Build().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/files/a%2Fb/c%20d", nil))
+	if want := "https://example.com/files/a%2Fb/c%20d"; got != want {
+		t.Errorf("got canonical URL %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,259 @@
+package hmux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// LoadRedirects reads redirect rules from r and registers them with b.
+//
+// The format is a subset of the _redirects file format used by several static
+// site hosts. Each line contains a source pattern, a destination, and an
+// optional HTTP status code, separated by whitespace:
+//
+//	# Comments and blank lines are ignored.
+//	/home              /
+//	/blog/*            /news/:splat         302
+//	/users/:id         /people/:id          308
+//	/docs/*            https://docs.example.com/:splat
+//	/app/*             /index.html          200
+//
+// The source is an ordinary hmux pattern. The destination may be a path or an
+// absolute URL. Placeholders in the destination path of the form :name are
+// replaced by the value of the parameter of the same name, and the placeholder
+// :splat is replaced by the remainder of the path matched by a wildcard source
+// pattern. If the destination has no query string, the request's query string
+// is preserved.
+//
+// The status must be a redirect status (301, 302, 303, 307, or 308) or 200.
+// The default is 301. A status of 200 indicates a rewrite rather than a
+// redirect: the Mux serves the request as if it had been made for the
+// destination path, which must not be an absolute URL.
+//
+// Each rule is registered for all methods, so rules added with a specific
+// method for the same pattern take precedence over a redirect.
+//
+// Source patterns are parsed like those given to Handle, so a Builder
+// created by Group adds its prefix to them (but not to destination paths).
+//
+// LoadRedirects returns an error if r contains a malformed line or if a rule
+// conflicts with a previously registered rule. On error, no rules are added.
+func (b *Builder) LoadRedirects(r io.Reader) error {
+	type redirectRule struct {
+		line int
+		pat  string
+		p    pattern
+		h    http.Handler
+	}
+	var rules []redirectRule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		pat, p, h, err := b.parseRedirect(fields)
+		if err != nil {
+			return fmt.Errorf("hmux: redirects line %d: %s", line, err)
+		}
+		rules = append(rules, redirectRule{line, pat, p, h})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return b.atomically(func() error {
+		for _, rr := range rules {
+			if err := b.addHandler("", rr.pat, rr.p, rr.h, nil); err != nil {
+				return fmt.Errorf("hmux: redirects line %d: %s", rr.line, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (b *Builder) parseRedirect(fields []string) (string, pattern, http.Handler, error) {
+	if len(fields) < 2 {
+		return "", pattern{}, nil, errors.New("missing destination")
+	}
+	if len(fields) > 3 {
+		return "", pattern{}, nil, fmt.Errorf("unexpected field %q", fields[3])
+	}
+	pat, p, err := b.parsePattern(fields[0])
+	if err != nil {
+		return "", p, nil, err
+	}
+	code := http.StatusMovedPermanently
+	if len(fields) == 3 {
+		code, err = strconv.Atoi(fields[2])
+		if err != nil {
+			return "", p, nil, fmt.Errorf("bad status %q", fields[2])
+		}
+		switch code {
+		case http.StatusOK,
+			http.StatusMovedPermanently,
+			http.StatusFound,
+			http.StatusSeeOther,
+			http.StatusTemporaryRedirect,
+			http.StatusPermanentRedirect:
+		default:
+			return "", p, nil, fmt.Errorf("unsupported status %d", code)
+		}
+	}
+	to, err := url.Parse(fields[1])
+	if err != nil {
+		return "", p, nil, err
+	}
+	if code == http.StatusOK && (to.Scheme != "" || to.Host != "") {
+		return "", p, nil, errors.New("rewrite destination must be a path")
+	}
+	parts, err := parseRedirectTemplate(to.EscapedPath(), p)
+	if err != nil {
+		return "", p, nil, err
+	}
+	to.Path = ""
+	to.RawPath = ""
+	h := &redirectHandler{to: to, parts: parts}
+	if code == http.StatusOK {
+		return pat, p, rewriteHandler{h}, nil
+	}
+	h.code = code
+	return pat, p, h, nil
+}
+
+// A redirectPart is a piece of a redirect destination path: either a literal
+// (escaped) string or a placeholder.
+type redirectPart struct {
+	lit   string
+	param string
+	splat bool
+}
+
+func parseRedirectTemplate(s string, p pattern) ([]redirectPart, error) {
+	var parts []redirectPart
+	for s != "" {
+		i := strings.IndexByte(s, ':')
+		if i < 0 {
+			parts = append(parts, redirectPart{lit: s})
+			break
+		}
+		if i > 0 {
+			parts = append(parts, redirectPart{lit: s[:i]})
+		}
+		s = s[i+1:]
+		n := 0
+		for n < len(s) && isPlaceholderByte(s[n]) {
+			n++
+		}
+		if n == 0 {
+			parts = append(parts, redirectPart{lit: ":"})
+			continue
+		}
+		name := s[:n]
+		s = s[n:]
+		if name == "splat" {
+			if p.opt != patWildcard {
+				return nil, errors.New(":splat used with a non-wildcard pattern")
+			}
+			parts = append(parts, redirectPart{splat: true})
+			continue
+		}
+		if !p.hasParam(name) {
+			return nil, fmt.Errorf("pattern has no parameter named %q", name)
+		}
+		parts = append(parts, redirectPart{param: name})
+	}
+	return parts, nil
+}
+
+func isPlaceholderByte(c byte) bool {
+	return c == '_' ||
+		'a' <= c && c <= 'z' ||
+		'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9'
+}
+
+type redirectHandler struct {
+	to    *url.URL // without a path
+	parts []redirectPart
+	code  int
+}
+
+// target computes the destination URL for r.
+func (h *redirectHandler) target(r *http.Request) *url.URL {
+	p := RequestParams(r)
+	var sb strings.Builder
+	for _, part := range h.parts {
+		switch {
+		case part.splat:
+			segs := strings.Split(strings.TrimPrefix(p.Wildcard(), "/"), "/")
+			for i, seg := range segs {
+				if i > 0 {
+					sb.WriteByte('/')
+				}
+				sb.WriteString(url.PathEscape(seg))
+			}
+		case part.param != "":
+			sb.WriteString(url.PathEscape(p.Get(part.param)))
+		default:
+			sb.WriteString(part.lit)
+		}
+	}
+	u := *h.to
+	// The template was built from a valid escaped path and all
+	// substitutions are escaped, so this cannot fail.
+	pu, err := url.Parse(sb.String())
+	if err != nil {
+		panic(err)
+	}
+	u.Path = pu.Path
+	u.RawPath = pu.RawPath
+	if u.RawQuery == "" {
+		u.RawQuery = r.URL.RawQuery
+	}
+	return &u
+}
+
+func (h *redirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, h.target(r).String(), h.code)
+}
+
+// maxRewrites limits how many times a single request may be rewritten,
+// protecting against rewrite loops.
+const maxRewrites = 10
+
+// A rewriteHandler serves a request by routing it again using a rewritten
+// path. It must be bound to a Mux before use.
+type rewriteHandler struct {
+	*redirectHandler
+}
+
+func (h rewriteHandler) bindMux(m *Mux) http.Handler {
+	return boundRewriteHandler{h.redirectHandler, m}
+}
+
+type boundRewriteHandler struct {
+	*redirectHandler
+	mux *Mux
+}
+
+func (h boundRewriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	depth, _ := r.Context().Value(rewriteDepthKey).(int)
+	if depth >= maxRewrites {
+		http.Error(w, "hmux: too many rewrites", http.StatusInternalServerError)
+		return
+	}
+	ctx := context.WithValue(r.Context(), rewriteDepthKey, depth+1)
+	// Drop the params of the rewritten request so that they aren't merged
+	// into the params of the destination rule.
+	ctx = context.WithValue(ctx, paramKey, (*Params)(nil))
+	r1 := r.WithContext(ctx)
+	r1.URL = h.target(r)
+	h.mux.ServeHTTP(w, r1)
+}
@@ -0,0 +1,96 @@
+package hmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadRedirects(t *testing.T) {
+	const redirects = `
+# Comment.
+/home              /
+/blog/*            /news/:splat         302
+/users/:id         /people/:id/x        308
+/docs/*            https://docs.example.com/:splat?a=b
+/app/*             /index.html          200
+/loop              /loop                200
+/old/:name         /a/:name             200
+`
+	b := NewBuilder()
+	b.Get("/index.html", testHandler("index"))
+	b.Get("/a/:name", testHandler("a %s", "name"))
+	b.Get("/home", testHandler("home")) // shadows the redirect for GET
+	if err := b.LoadRedirects(strings.NewReader(redirects)); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []reqTest{
+		{"GET", "/home", "home"},
+		{"HEAD", "/home", "301 /"},
+		{"GET", "/blog/2020/01/hello", "302 /news/2020/01/hello"},
+		{"GET", "/blog/a%20b?x=y", "302 /news/a%20b?x=y"},
+		{"GET", "/users/a%20b", "308 /people/a%20b/x"},
+		{"GET", "/docs/a/b?c=d", "301 https://docs.example.com/a/b?a=b"},
+		{"GET", "/app/x/y", "index"},
+		{"GET", "/loop", "500"},
+		{"GET", "/old/b%2fc", "a b/c"},
+	}
+	testRequests(t, b.Build(), testCases)
+}
+
+func TestLoadRedirectsErrors(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		want string
+	}{
+		{"/a", "line 1: missing destination"},
+		{"/a /b 301 Country=us", `line 1: unexpected field "Country=us"`},
+		{"/a /b 301!", `line 1: bad status "301!"`},
+		{"/a /b 404", "line 1: unsupported status 404"},
+		{"a /b", "line 1: pattern does not begin with a /"},
+		{"/a https://example.com/ 200", "line 1: rewrite destination must be a path"},
+		{"/a/:x /b/:y", `line 1: pattern has no parameter named "y"`},
+		{"/a/:x /b/:splat", "line 1: :splat used with a non-wildcard pattern"},
+		{"/a /b\n/a /c", "line 2: " + ` "/a" conflicts`},
+	} {
+		err := NewBuilder().LoadRedirects(strings.NewReader(tt.line))
+		if err == nil {
+			t.Errorf("LoadRedirects(%q): got nil error", tt.line)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadRedirects(%q): got %q; want substring %q", tt.line, err, tt.want)
+		}
+	}
+}
+
+func TestLoadRedirectsGroup(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/new", testHandler("new"))
+	b.DefinePattern("post", "/posts/:slug:string(1,20)")
+	var err error
+	b.Group("/g", func(g *Builder) {
+		err = g.LoadRedirects(strings.NewReader("/old /new 301\n/blog/{post} /p/:slug 302"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/g/old", "301 /new"},
+		{"GET", "/old", "404"},
+		{"GET", "/g/blog/posts/hi", "302 /p/hi"},
+	})
+}
+
+func TestLoadRedirectsAtomic(t *testing.T) {
+	b := NewBuilder()
+	b.Any("/taken", testHandler("taken"))
+	err := b.LoadRedirects(strings.NewReader("/a /b\n/taken /c"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("got error %v; want conflict on line 2", err)
+	}
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/a", "404"},
+		{"GET", "/taken", "taken"},
+	})
+}
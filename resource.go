@@ -0,0 +1,219 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The following interfaces describe the conventional actions of a REST
+// resource. A controller passed to Builder.Resource implements one or more of
+// them.
+type (
+	// An Indexer lists a resource collection (GET /things).
+	Indexer interface {
+		Index(w http.ResponseWriter, r *http.Request)
+	}
+	// A Creator adds to a resource collection (POST /things).
+	Creator interface {
+		Create(w http.ResponseWriter, r *http.Request)
+	}
+	// A Shower shows a single resource (GET /things/:id).
+	Shower interface {
+		Show(w http.ResponseWriter, r *http.Request)
+	}
+	// An Updater modifies a single resource (PUT or PATCH /things/:id).
+	Updater interface {
+		Update(w http.ResponseWriter, r *http.Request)
+	}
+	// A Deleter removes a single resource (DELETE /things/:id).
+	Deleter interface {
+		Delete(w http.ResponseWriter, r *http.Request)
+	}
+)
+
+// Resource registers rules for the conventional REST actions implemented by
+// controller, which must implement at least one of Indexer, Creator, Shower,
+// Updater, and Deleter. For example, if controller implements all of them,
+//
+//	b.Resource("/users", controller)
+//
+// is equivalent to
+//
+//	b.Get("/users", controller.Index)
+//	b.Post("/users", controller.Create)
+//	b.Get("/users/:id", controller.Show)
+//	b.Put("/users/:id", controller.Update)
+//	b.Handle("PATCH", "/users/:id", http.HandlerFunc(controller.Update))
+//	b.Delete("/users/:id", controller.Delete)
+//
+// If the final segment of the pattern is a parameter, it gives the pattern of
+// a single item in place of ":id", so that the ID may be typed:
+//
+//	b.Resource("/users/:id:int64", controller)
+//
+// registers the same rules with the pattern "/users/:id:int64" for items.
+//
+// The pattern must not be a wildcard pattern or one of the special patterns
+// "" and "*"; a trailing slash is ignored. The options apply to every rule.
+// The rules are registered atomically: if any of them conflicts with a
+// previously registered rule, Resource panics without registering any.
+//
+// If the options name the rules (see Name), each rule's name is that name
+// followed by a period and the action: given hmux.Name("users"), the rules
+// above are named users.index, users.create, users.show, users.update (both
+// the PUT and the PATCH rule), and users.delete, so that
+//
+//	mux.URL("users.show", 3)
+//
+// returns "/users/3".
+//
+// The returned Resource may be used to register nested resources.
+func (b *Builder) Resource(pat string, controller interface{}, opts ...RuleOption) *Resource {
+	return b.resource(pat, controller, "", opts)
+}
+
+// A Resource is a collection of rules registered by Builder.Resource.
+type Resource struct {
+	b    *Builder
+	pat  string // the collection pattern
+	item string // the final segment of the item pattern, such as ":id"
+	name string // the name of the rules without the action, if any
+	opts []RuleOption
+}
+
+// Resource registers rules for a resource nested inside of r. The pattern is
+// relative to a single item of r, whose ID is captured by a parameter of the
+// same type as r's item parameter. If that parameter is named id, the name of
+// the nested parameter is derived from the final segment of r's pattern: that
+// segment, without a trailing "s", followed by "_id". For example,
+//
+//	b.Resource("/teams/:id:int64", teams).Resource("/members", members)
+//
+// registers rules for members using patterns such as
+//
+//	/teams/:team_id:int64/members
+//	/teams/:team_id:int64/members/:id
+//
+// The derivation does not handle other plurals ("/categories" gives
+// categorie_id) or singular words ending in s ("/status" gives statu_id).
+// To choose the name, give r's item parameter another name than id, which is
+// then used both by r's rules and by those of the nested resource:
+//
+//	b.Resource("/categories/:category_id", categories).Resource("/posts", posts)
+//
+// registers rules with patterns such as "/categories/:category_id" and
+// "/categories/:category_id/posts/:id".
+//
+// The options given when registering r also apply to the nested resource's
+// rules, followed by opts, except that a name given to r's rules does not
+// name the nested rules directly. If r's rules are named, the names of the
+// nested rules begin with r's name, a period, and the name given in opts
+// or, without one, the final segment of the nested collection's pattern:
+//
+//	b.Resource("/teams", teams, hmux.Name("teams")).Resource("/members", members)
+//
+// names the rule for a single member teams.members.show, so that
+//
+//	mux.URL("teams.members.show", "a", "b")
+//
+// returns "/teams/a/members/b".
+func (r *Resource) Resource(pat string, controller interface{}, opts ...RuleOption) *Resource {
+	param := r.item[1:]
+	name := param
+	if j := strings.IndexAny(param, ":."); j >= 0 {
+		name = param[:j]
+	}
+	if name == "id" {
+		i := strings.LastIndexByte(r.pat, '/')
+		last := r.pat[i+1:]
+		if strings.HasPrefix(last, ":") {
+			panic("hmux: cannot derive the parameter name of a resource nested under a pattern ending with a parameter")
+		}
+		param = strings.TrimSuffix(last, "s") + "_id" + param[len(name):]
+	}
+	prefix := r.pat + "/:" + param
+	allOpts := append([]RuleOption(nil), r.opts...)
+	allOpts = append(allOpts, func(rl *rule) { rl.name = "" })
+	allOpts = append(allOpts, opts...)
+	return r.b.resource(prefix+"/"+strings.TrimPrefix(pat, "/"), controller, r.name, allOpts)
+}
+
+// resource registers the rules of a resource. If parent is not empty, it is
+// the name of the resource which the new one is nested inside of.
+func (b *Builder) resource(pat string, controller interface{}, parent string, opts []RuleOption) *Resource {
+	pat = strings.TrimSuffix(pat, "/")
+	if pat == "" || pat == "*" || strings.HasSuffix(pat, "/*") {
+		panic("hmux: Resource called with a wildcard or special pattern")
+	}
+	itemSeg := ":id"
+	if i := strings.LastIndexByte(pat, '/'); strings.HasPrefix(pat[i+1:], ":") {
+		if i <= 0 {
+			panic("hmux: Resource called with a pattern without a collection")
+		}
+		pat, itemSeg = pat[:i], pat[i+1:]
+	}
+	item := pat + "/" + itemSeg
+	res := &Resource{b: b, pat: pat, item: itemSeg, opts: opts}
+	last := pat[strings.LastIndexByte(pat, '/')+1:]
+	// action returns opts followed by an option which names a rule after
+	// the action it performs.
+	action := func(action string) []RuleOption {
+		return append(opts[:len(opts):len(opts)], func(rl *rule) {
+			base := rl.name
+			if base == "" && parent != "" && !strings.HasPrefix(last, ":") {
+				base = last
+			}
+			if base == "" {
+				return
+			}
+			if parent != "" {
+				base = parent + "." + base
+			}
+			res.name = base
+			rl.name = base + "." + action
+		})
+	}
+	n := 0
+	err := b.atomically(func() error {
+		handle := func(method, pat, act string, h http.HandlerFunc) error {
+			n++
+			return b.handle(method, pat, h, action(act)...)
+		}
+		if c, ok := controller.(Indexer); ok {
+			if err := handle(http.MethodGet, pat, "index", c.Index); err != nil {
+				return err
+			}
+		}
+		if c, ok := controller.(Creator); ok {
+			if err := handle(http.MethodPost, pat, "create", c.Create); err != nil {
+				return err
+			}
+		}
+		if c, ok := controller.(Shower); ok {
+			if err := handle(http.MethodGet, item, "show", c.Show); err != nil {
+				return err
+			}
+		}
+		if c, ok := controller.(Updater); ok {
+			if err := handle(http.MethodPut, item, "update", c.Update); err != nil {
+				return err
+			}
+			if err := handle(http.MethodPatch, item, "update", c.Update); err != nil {
+				return err
+			}
+		}
+		if c, ok := controller.(Deleter); ok {
+			if err := handle(http.MethodDelete, item, "delete", c.Delete); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic("hmux: " + err.Error())
+	}
+	if n == 0 {
+		panic("hmux: Resource controller does not implement any resource actions")
+	}
+	return res
+}
@@ -0,0 +1,221 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type testUsers struct{}
+
+func (testUsers) Index(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, "index")
+}
+
+func (testUsers) Show(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "show %s", RequestParams(r).Get("id"))
+}
+
+func (testUsers) Update(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "update %s", RequestParams(r).Get("id"))
+}
+
+type testMembers struct{}
+
+func (testMembers) Index(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "members of %s", RequestParams(r).Get("team_id"))
+}
+
+func (testMembers) Show(w http.ResponseWriter, r *http.Request) {
+	p := RequestParams(r)
+	fmt.Fprintf(w, "member %s of %s", p.Get("id"), p.Get("team_id"))
+}
+
+type testRoles struct{}
+
+func (testRoles) Show(w http.ResponseWriter, r *http.Request) {
+	p := RequestParams(r)
+	fmt.Fprintf(w, "role %s of %s in %s", p.Get("id"), p.Get("member_id"), p.Get("team_id"))
+}
+
+func TestResource(t *testing.T) {
+	b := NewBuilder()
+	b.Resource("/users/", testUsers{})
+	b.Get("/users/new", testHandler("new"))
+
+	testCases := []reqTest{
+		{"GET", "/users", "index"},
+		{"POST", "/users", "405 GET"},
+		{"GET", "/users/alice", "show alice"},
+		{"PUT", "/users/alice", "update alice"},
+		{"PATCH", "/users/alice", "update alice"},
+		{"DELETE", "/users/alice", "405 GET, PATCH, PUT"},
+		{"GET", "/users/new", "new"},
+	}
+	testRequests(t, b.Build(), testCases)
+
+	b = NewBuilder()
+	teams := b.Resource("/teams", testUsers{}, Meta("area", "teams"))
+	teams.Resource("/members", testMembers{}).Resource("/roles/", testRoles{})
+	mux := b.Build()
+	testRequests(t, mux, []reqTest{
+		{"GET", "/teams/a", "show a"},
+		{"GET", "/teams/a/members", "members of a"},
+		{"GET", "/teams/a/members/b", "member b of a"},
+		{"GET", "/teams/a/members/b/roles/c", "role c of b in a"},
+	})
+	for _, rt := range mux.Routes() {
+		if rt.Meta["area"] != "teams" {
+			t.Errorf("route %s %s is missing inherited metadata", rt.Method, rt.Pattern)
+		}
+	}
+
+	for _, tt := range []struct {
+		pat        string
+		controller interface{}
+	}{
+		{"/users", struct{}{}},
+		{"/users/*", testUsers{}},
+		{"", testUsers{}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Resource(%q, %T): got no panic", tt.pat, tt.controller)
+				}
+			}()
+			NewBuilder().Resource(tt.pat, tt.controller)
+		}()
+	}
+}
+
+type testPosts struct{}
+
+func (testPosts) Show(w http.ResponseWriter, r *http.Request) {
+	p := RequestParams(r)
+	fmt.Fprintf(w, "post %s in %v", p.Get("id"), p.Value("category_id"))
+}
+
+func TestResourceParams(t *testing.T) {
+	b := NewBuilder()
+	b.Resource("/teams/:id:int64", testUsers{}).Resource("/members", testMembers{})
+	b.Resource("/categories/:category_id:int32", testCategories{}).Resource("/posts", testPosts{})
+	b.Resource("/status", testUsers{}).Resource("/checks", testUsers{})
+	mux := b.Build()
+	testRequests(t, mux, []reqTest{
+		{"GET", "/teams", "index"},
+		{"GET", "/teams/3", "show 3"},
+		{"GET", "/teams/x", "404"},
+		{"GET", "/teams/3/members/b", "member b of 3"},
+		{"GET", "/teams/x/members/b", "404"},
+		{"GET", "/categories/7", "category 7"},
+		{"GET", "/categories/7/posts/p", "post p in 7"},
+		{"GET", "/categories/x/posts/p", "404"},
+	})
+	var pats []string
+	for _, rt := range mux.Routes() {
+		if rt.Method == http.MethodGet {
+			pats = append(pats, rt.Pattern)
+		}
+	}
+	want := map[string]bool{
+		"/teams/:team_id:int64/members/:id":        true,
+		"/categories/:category_id:int32/posts/:id": true,
+		// The derived name is a naive singular.
+		"/status/:statu_id/checks/:id": true,
+	}
+	for _, pat := range pats {
+		delete(want, pat)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing patterns %v among %v", want, pats)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Resource with only an item pattern did not panic")
+		}
+	}()
+	NewBuilder().Resource("/:id", testUsers{})
+}
+
+type testCategories struct{}
+
+func (testCategories) Show(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "category %s", RequestParams(r).Get("category_id"))
+}
+
+func TestResourceNames(t *testing.T) {
+	b := NewBuilder()
+	teams := b.Resource("/teams/:id:int64", testUsers{}, Name("teams"))
+	teams.Resource("/members", testMembers{}).Resource("/roles", testRoles{})
+	teams.Resource("/projects", testUsers{}, Name("work"))
+	b.Resource("/users", testUsers{}).Resource("/posts", testPosts{}, Name("posts"))
+	b.Resource("/tags", testUsers{})
+	mux := b.Build()
+
+	var got []string
+	for _, rt := range mux.Routes() {
+		if rt.Name != "" {
+			got = append(got, rt.Method+" "+rt.Name)
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		"GET posts.show",
+		"GET teams.index",
+		"GET teams.members.index",
+		"GET teams.members.roles.show",
+		"GET teams.members.show",
+		"GET teams.show",
+		"GET teams.work.index",
+		"GET teams.work.show",
+		"PATCH teams.update",
+		"PATCH teams.work.update",
+		"PUT teams.update",
+		"PUT teams.work.update",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got names\n%q\nwant\n%q", got, want)
+	}
+
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"teams.index", nil, "/teams"},
+		{"teams.show", []interface{}{3}, "/teams/3"},
+		{"teams.members.show", []interface{}{3, "b"}, "/teams/3/members/b"},
+		{"teams.members.roles.show", []interface{}{3, "b", "c"}, "/teams/3/members/b/roles/c"},
+		{"teams.work.index", []interface{}{3}, "/teams/3/projects"},
+		{"posts.show", []interface{}{"u", "p"}, "/users/u/posts/p"},
+	} {
+		got, err := mux.URL(tt.name, tt.args...)
+		if err != nil || got != tt.want {
+			t.Errorf("URL(%q, %v): got (%q, %v); want %q", tt.name, tt.args, got, err, tt.want)
+		}
+	}
+	if _, err := mux.URL("teams.show", "x"); err == nil {
+		t.Error(`URL("teams.show", "x") succeeded`)
+	}
+
+	// The rules of a resource are registered atomically.
+	b = NewBuilder()
+	b.Get("/users/:id", testHandler("user"))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("conflicting Resource did not panic")
+			}
+		}()
+		b.Resource("/users", testUsers{}, Name("users"))
+	}()
+	testRequests(t, b.Build(), []reqTest{{"GET", "/users", "404"}})
+	b.Resource("/people", testUsers{}, Name("users"))
+	if _, ok := b.Build().RouteByName("users.index"); !ok {
+		t.Error("name of failed Resource registration not released")
+	}
+}
@@ -0,0 +1,161 @@
+package hmux
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// URL returns the escaped URL path of the rule of m with the given name (see
+// Name), substituting args for its parameters in the order in which they
+// appear in the pattern. For a wildcard pattern, one further argument, a
+// string, may give the path suffix matched by the wildcard; without it, the
+// URL ends with the slash preceding the wildcard. For example, given
+//
+//	b.Get("/users/:id:int64/posts/:slug", showPost, hmux.Name("post"))
+//	b.Get("/static/*", serveStatic, hmux.Name("static"))
+//
+// m.URL("post", 3, "hello world") returns "/users/3/posts/hello%20world" and
+// m.URL("static", "css/site.css") returns "/static/css/site.css".
+//
+// Each argument may be a string, an integer, a time.Time (for rfc3339
+// parameters), a []byte (for hex parameters), or a fmt.Stringer. URL returns
+// an error if there is no rule with the name, if the number of arguments is
+// wrong, or if an argument has an unsupported type or a value which its
+// parameter would not match. Rules with the special patterns "" and "*" have
+// no URL.
+func (m *Mux) URL(name string, args ...interface{}) (string, error) {
+	rl, err := m.urlRule(name)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, seg := range rl.p.segs {
+		if seg.isParam {
+			names = append(names, seg.s)
+		}
+	}
+	n := len(names)
+	if len(args) != n && (rl.p.opt != patWildcard || len(args) != n+1) {
+		return "", fmt.Errorf("hmux: rule %q (pattern %q) has %d parameters; got %d arguments", name, rl.pat, n, len(args))
+	}
+	vals := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		if i == n {
+			vals["*"] = arg
+		} else {
+			vals[names[i]] = arg
+		}
+	}
+	return m.buildURL(rl, vals)
+}
+
+// Args holds parameter values keyed by parameter name, as taken by URLMap.
+type Args map[string]interface{}
+
+// URLMap is like URL but takes the parameter values keyed by parameter name.
+// The wildcard value, if any, has the key "*". URLMap returns an error if
+// params includes a key which is not a parameter of the rule.
+func (m *Mux) URLMap(name string, params map[string]interface{}) (string, error) {
+	rl, err := m.urlRule(name)
+	if err != nil {
+		return "", err
+	}
+	for k := range params {
+		if k == "*" && rl.p.opt == patWildcard || rl.p.hasParam(k) {
+			continue
+		}
+		return "", fmt.Errorf("hmux: rule %q (pattern %q) has no parameter %q", name, rl.pat, k)
+	}
+	return m.buildURL(rl, params)
+}
+
+func (m *Mux) urlRule(name string) (*rule, error) {
+	rl, ok := m.names[name]
+	if !ok {
+		return nil, fmt.Errorf("hmux: no rule named %q", name)
+	}
+	if rl.p.opt == patEmpty || rl.p.opt == patStar {
+		return nil, fmt.Errorf("hmux: rule %q (pattern %q) has no URL", name, rl.pat)
+	}
+	return rl, nil
+}
+
+// buildURL constructs the URL path of rl from the parameter values in vals.
+func (m *Mux) buildURL(rl *rule, vals map[string]interface{}) (string, error) {
+	strs := make(map[string]string, len(vals))
+	for _, seg := range rl.p.segs {
+		if !seg.isParam {
+			continue
+		}
+		v, ok := vals[seg.s]
+		if !ok {
+			continue // reported by fill
+		}
+		s, err := formatParam(seg, v)
+		if err != nil {
+			return "", fmt.Errorf("hmux: rule %q: %s", rl.name, err)
+		}
+		strs[seg.s] = s
+	}
+	pth, err := rl.p.fill(strs)
+	if err != nil {
+		return "", fmt.Errorf("hmux: rule %q: %s", rl.name, err)
+	}
+	if rl.p.opt != patWildcard {
+		return pth, nil
+	}
+	var rest string
+	if v, ok := vals["*"]; ok {
+		if rest, ok = v.(string); !ok {
+			return "", fmt.Errorf("hmux: rule %q: wildcard value has type %T, not string", rl.name, v)
+		}
+		rest = strings.TrimPrefix(rest, "/")
+	}
+	var parts []string
+	if rest != "" {
+		parts = strings.Split(rest, "/")
+	}
+	if rl.p.glob != nil {
+		var opts matchOpts
+		if m.foldCase {
+			opts |= optFoldCase
+		}
+		if !matchGlob(rl.p.glob, parts, opts) {
+			return "", fmt.Errorf("hmux: rule %q: wildcard value %q does not match the pattern", rl.name, rest)
+		}
+	}
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = url.PathEscape(part)
+	}
+	return pth + strings.Join(escaped, "/"), nil
+}
+
+// formatParam converts v, a value for the parameter seg, to its string form.
+func formatParam(seg segment, v interface{}) (string, error) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s = fmt.Sprint(v)
+	case time.Time:
+		if seg.ptyp != paramRFC3339 {
+			return "", fmt.Errorf("time.Time value for parameter %q of type %s", seg.s, seg.typeName())
+		}
+		s = v.Format(time.RFC3339)
+	case []byte:
+		if seg.ptyp != paramHex {
+			return "", fmt.Errorf("[]byte value for parameter %q of type %s", seg.s, seg.typeName())
+		}
+		s = hex.EncodeToString(v)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return "", fmt.Errorf("unsupported value of type %T for parameter %q", v, seg.s)
+	}
+	return s, nil
+}
@@ -0,0 +1,98 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSlug string
+
+func (s testSlug) String() string { return strings.ToLower(string(s)) }
+
+func TestURL(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/users/:id:int64/posts/:slug", testHandler("post"), Name("post"))
+	b.Get("/static/*", testHandler("static"), Name("static"))
+	b.Get("/img/**/*.png", testHandler("png"), Name("png"))
+	b.Get("/files/:name.txt", testHandler("file"), Name("file"))
+	b.Get("/events/:t:rfc3339", testHandler("event"), Name("event"))
+	b.Get("/blobs/:sum:hex", testHandler("blob"), Name("blob"))
+	b.Get("/size/:s:enum(small|large)", testHandler("size"), Name("size"))
+	b.Get("*", testHandler("star"), Name("star"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+		want string // or error substring, prefixed with "error: "
+	}{
+		{"post", []interface{}{3, "hello world"}, "/users/3/posts/hello%20world"},
+		{"post", []interface{}{int64(3), testSlug("Hi")}, "/users/3/posts/hi"},
+		{"post", []interface{}{"x", "a"}, `error: value "x" does not match parameter "id" of type int64`},
+		{"post", []interface{}{3}, "error: has 2 parameters; got 1 arguments"},
+		{"post", []interface{}{3.5, "a"}, "error: unsupported value of type float64"},
+		{"post", []interface{}{3, "a/b"}, "/users/3/posts/a%2Fb"},
+		{"static", nil, "/static/"},
+		{"static", []interface{}{"css/site.css"}, "/static/css/site.css"},
+		{"static", []interface{}{"/a b/c"}, "/static/a%20b/c"},
+		{"static", []interface{}{1}, "error: wildcard value has type int"},
+		{"png", []interface{}{"a/b/logo.png"}, "/img/a/b/logo.png"},
+		{"png", []interface{}{"logo.jpg"}, `error: wildcard value "logo.jpg" does not match`},
+		{"file", []interface{}{"notes"}, "/files/notes.txt"},
+		{"event", []interface{}{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}, "/events/2020-01-02T03:04:05Z"},
+		{"event", []interface{}{[]byte{1}}, `error: []byte value for parameter "t"`},
+		{"blob", []interface{}{[]byte{0xab, 0x01}}, "/blobs/ab01"},
+		{"size", []interface{}{"large"}, "/size/large"},
+		{"size", []interface{}{"medium"}, "error: does not match"},
+		{"star", nil, "error: has no URL"},
+		{"nope", nil, `error: no rule named "nope"`},
+	} {
+		got, err := mux.URL(tt.name, tt.args...)
+		if want := strings.TrimPrefix(tt.want, "error: "); want != tt.want {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("URL(%q, %v): got (%q, %v); want error containing %q", tt.name, tt.args, got, err, want)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("URL(%q, %v): got (%q, %v); want %q", tt.name, tt.args, got, err, tt.want)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", got, nil))
+		if w.Body.String() != tt.name {
+			t.Errorf("GET %s: got %q; want %q", got, w.Body.String(), tt.name)
+		}
+	}
+}
+
+func TestURLMap(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/users/:id:int64/posts/:slug", testHandler("post"), Name("post"))
+	b.Get("/static/*", testHandler("static"), Name("static"))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		name   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"post", map[string]interface{}{"id": 3, "slug": "a"}, "/users/3/posts/a"},
+		{"post", map[string]interface{}{"id": 3}, `error: missing value for parameter "slug"`},
+		{"post", map[string]interface{}{"id": 3, "slug": "a", "page": 2}, `error: has no parameter "page"`},
+		{"post", map[string]interface{}{"id": 3, "slug": "a", "*": "x"}, `error: has no parameter "*"`},
+		{"static", map[string]interface{}{"*": "js/app.js"}, "/static/js/app.js"},
+	} {
+		got, err := mux.URLMap(tt.name, tt.params)
+		if want := strings.TrimPrefix(tt.want, "error: "); want != tt.want {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("URLMap(%q, %v): got (%q, %v); want error containing %q", tt.name, tt.params, got, err, want)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("URLMap(%q, %v): got (%q, %v); want %q", tt.name, tt.params, got, err, tt.want)
+		}
+	}
+}
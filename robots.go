@@ -0,0 +1,164 @@
+package hmux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ServeRobots registers GET and HEAD handlers for /robots.txt which serve a
+// robots.txt file disallowing, for all user agents, every rule that has the
+// "noindex" metadata key (with any value):
+//
+//	b.Get("/admin/:page", handleAdmin, hmux.Meta("noindex", ""))
+//	b.ServeRobots()
+//
+// The file is computed when the Mux is built, so it reflects rules registered
+// both before and after ServeRobots is called. Parameter segments are written
+// as * and patterns which match a single path are terminated with $, as
+// understood by the major search engine crawlers. A glob pattern containing
+// ** (which may match no segments) gives a rule for each way of matching or
+// skipping its ** segments with *.
+//
+// Since crawlers only look for robots.txt at the root of a site, and the file
+// covers all the rules of the Mux, ServeRobots panics if b was created by
+// Group.
+func (b *Builder) ServeRobots() {
+	b.checkNotGroup("ServeRobots")
+	var h robotsHandler
+	pat, p, err := b.parsePattern("/robots.txt")
+	if err != nil {
+		panic(err)
+	}
+	err = b.atomically(func() error {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			if err := b.addHandler(method, pat, p, h, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic("hmux: " + err.Error())
+	}
+}
+
+type robotsHandler struct{}
+
+func (robotsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	panic("robotsHandler used without binding to a Mux")
+}
+
+func (robotsHandler) bindMux(m *Mux) http.Handler {
+	var sb strings.Builder
+	sb.WriteString("User-agent: *\n")
+	seen := make(map[string]bool)
+	n := 0
+	for _, ma := range m.matchers {
+		ma.forEachRule(func(rl *rule) {
+			if _, ok := rl.meta["noindex"]; !ok || rl.p.opt == patStar {
+				return
+			}
+			for _, line := range robotsPaths(rl.p) {
+				if seen[line] {
+					continue
+				}
+				seen[line] = true
+				sb.WriteString("Disallow: " + line + "\n")
+				n++
+			}
+		})
+	}
+	if n == 0 {
+		// An empty Disallow allows everything.
+		sb.WriteString("Disallow:\n")
+	}
+	body := sb.String()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if r.Method != http.MethodHead {
+			w.Write([]byte(body))
+		}
+	})
+}
+
+// robotsPaths converts p (which is not "*") into robots.txt path rules.
+func robotsPaths(p pattern) []string {
+	pths := robotsGlobPaths(p)
+	if !p.anySlash {
+		return pths
+	}
+	var all []string
+	for _, pth := range pths {
+		all = append(all, pth, strings.TrimSuffix(pth, "$")+"/$")
+	}
+	return all
+}
+
+// robotsGlobPaths converts p (which is not "*") into robots.txt path rules,
+// ignoring p.anySlash. There is more than one rule only if p has a glob
+// containing **.
+func robotsGlobPaths(p pattern) []string {
+	if p.opt == patEmpty {
+		return []string{"/"}
+	}
+	var sb strings.Builder
+	for _, seg := range p.segs {
+		sb.WriteByte('/')
+		if seg.isParam {
+			sb.WriteByte('*')
+			sb.WriteString(url.PathEscape(seg.suffix))
+		} else {
+			sb.WriteString(url.PathEscape(seg.s))
+		}
+	}
+	switch p.opt {
+	case patTrailingSlash:
+		sb.WriteString("/$")
+	case patWildcard:
+		if p.glob == nil {
+			sb.WriteByte('/')
+		} else {
+			return robotsGlob(sb.String(), p.glob)
+		}
+	default:
+		sb.WriteByte('$')
+	}
+	return []string{sb.String()}
+}
+
+// robotsGlob converts the glob of a pattern, following the path prefix,
+// into robots.txt path rules. Robots.txt wildcards also match slashes, so a
+// * within a segment is approximate. A ** segment, which matches zero or
+// more segments, cannot be written as a single wildcard: "/a/**/b" gives
+// the rules "/a/b$" and "/a/*/b$".
+func robotsGlob(prefix string, glob []string) []string {
+	pths := []string{prefix}
+	for _, g := range glob {
+		if g == "**" {
+			n := len(pths)
+			for _, pth := range pths[:n] {
+				pths = append(pths, pth+"/*")
+			}
+			continue
+		}
+		var sb strings.Builder
+		sb.WriteByte('/')
+		for i, lit := range strings.Split(g, "*") {
+			if i > 0 {
+				sb.WriteByte('*')
+			}
+			sb.WriteString(url.PathEscape(lit))
+		}
+		for i := range pths {
+			pths[i] += sb.String()
+		}
+	}
+	for i, pth := range pths {
+		if pth == "" {
+			pth = "/" // "/**" matching "/"
+		}
+		pths[i] = pth + "$"
+	}
+	return pths
+}
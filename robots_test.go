@@ -0,0 +1,81 @@
+package hmux
+
+import "testing"
+
+func TestServeRobots(t *testing.T) {
+	b := NewBuilder()
+	h := testHandler("")
+	b.Get("/", h)
+	b.Get("/private", h, Meta("noindex", ""))
+	b.ServeRobots()
+	b.Get("/users/:name/settings/", h, Meta("noindex", "true"))
+	b.Prefix("/admin", h, Meta("noindex", ""), Meta("owner", "ops"))
+	b.ServeFile("/secret.txt", "secret.txt", Meta("noindex", ""))
+	b.Get("/drafts", h, Meta("noindex", ""), OptionalTrailingSlash())
+	b.Get("/tmp/**/*.bak", h, Meta("noindex", ""))
+	b.Get("/cache/**/v*/**", h, Meta("noindex", ""))
+	b.Get("/**/.git", h, Meta("noindex", ""))
+
+	want := `User-agent: *
+Disallow: /users/*/settings/$
+Disallow: /tmp/*.bak$
+Disallow: /tmp/*/*.bak$
+Disallow: /secret.txt$
+Disallow: /private$
+Disallow: /drafts$
+Disallow: /drafts/$
+Disallow: /cache/v*$
+Disallow: /cache/*/v*$
+Disallow: /cache/v*/*$
+Disallow: /cache/*/v*/*$
+Disallow: /admin/
+Disallow: /.git$
+Disallow: /*/.git$
+`
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/robots.txt", want},
+		{"HEAD", "/robots.txt", ""},
+	})
+
+	b = NewBuilder()
+	b.ServeRobots()
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/robots.txt", "User-agent: *\nDisallow:\n"},
+	})
+
+	b = NewBuilder()
+	b.Get("/**", h, Meta("noindex", ""))
+	b.ServeRobots()
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/robots.txt", "User-agent: *\nDisallow: /$\nDisallow: /*$\n"},
+	})
+}
+
+func TestServeRobotsGroup(t *testing.T) {
+	b := NewBuilder()
+	defer func() {
+		if recover() == nil {
+			t.Error("ServeRobots on a group Builder did not panic")
+		}
+		testRequests(t, b.Build(), []reqTest{
+			{"GET", "/robots.txt", "404"},
+			{"GET", "/g/robots.txt", "404"},
+		})
+	}()
+	b.Group("/g", func(g *Builder) {
+		g.ServeRobots()
+	})
+}
+
+func TestMeta(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler(""), Meta("a", "1"), Meta("b", "2"), Meta("a", "3"))
+	routes := b.Build().Routes()
+	if len(routes) != 1 {
+		t.Fatalf("got %d routes; want 1", len(routes))
+	}
+	meta := routes[0].Meta
+	if len(meta) != 2 || meta["a"] != "3" || meta["b"] != "2" {
+		t.Errorf("got meta %v; want map[a:3 b:2]", meta)
+	}
+}
@@ -0,0 +1,98 @@
+package hmux
+
+import "sort"
+
+// NormalizeSegments sets a function which Muxes built from b apply to each
+// (unescaped) request path segment and to the literal text of each pattern
+// (literal segments, literal parameter suffixes, enum values, and globs)
+// before matching. A nil function, the default, disables normalization.
+//
+// This allows visually identical internationalized paths to be routed
+// consistently. For example, to match paths in Unicode Normalization Form C
+// using the golang.org/x/text/unicode/norm package:
+//
+//	b.NormalizeSegments(norm.NFC.String)
+//
+// Then the pattern "/café" matches request paths spelling é either as a
+// single code point or as an e followed by a combining accent.
+//
+// Parameter values and wildcard remainders hold the normalized segments.
+// Normalization applies only to segments which the Mux unescapes before
+// matching; it has no effect when raw path matching is enabled (see
+// RawPathMatching). Avoid registering patterns which are equivalent after
+// normalization; which of them matches is unspecified.
+func (b *Builder) NormalizeSegments(f func(string) string) {
+	b.checkNotGroup("NormalizeSegments")
+	b.normSeg = f
+}
+
+// normalizeMatchers replaces the matchers of m whose patterns change under
+// m.normSeg and restores the precedence order.
+func (m *Mux) normalizeMatchers() {
+	for i, ma := range m.matchers {
+		if pat, ok := ma.pat.normalize(m.normSeg); ok {
+			ma = ma.clone()
+			ma.pat = pat
+			m.matchers[i] = ma
+		}
+	}
+	sort.SliceStable(m.matchers, func(i, j int) bool {
+		return m.matchers[i].pat.compare(m.matchers[j].pat) > 0
+	})
+}
+
+// normalize returns a copy of p with f applied to its literal text. It
+// returns false if the text is unchanged.
+func (p pattern) normalize(f func(string) string) (pattern, bool) {
+	changed := false
+	norm := func(s string) string {
+		s1 := f(s)
+		if s1 != s {
+			changed = true
+		}
+		return s1
+	}
+	segs := make([]segment, len(p.segs))
+	for i, seg := range p.segs {
+		if !seg.isParam {
+			seg.s = norm(seg.s)
+			segs[i] = seg
+			continue
+		}
+		seg.suffix = norm(seg.suffix)
+		if seg.enum != nil {
+			enum := make([]string, len(seg.enum))
+			for j, v := range seg.enum {
+				enum[j] = norm(v)
+			}
+			sort.Strings(enum)
+			seg.enum = enum
+		}
+		segs[i] = seg
+	}
+	var glob []string
+	for _, g := range p.glob {
+		if g != "**" {
+			g = norm(g)
+		}
+		glob = append(glob, g)
+	}
+	if !changed {
+		return p, false
+	}
+	p.segs = segs
+	p.glob = glob
+	return p, true
+}
+
+// splitPath is like the package-level splitPath, but also normalizes the
+// segments if m has a normalization function.
+func (m *Mux) splitPath(pth string, opts matchOpts) ([]string, matchOpts) {
+	parts, opts := splitPath(pth, opts)
+	if m.normSeg != nil && opts&optRaw == 0 {
+		for i, part := range parts {
+			parts[i] = m.normSeg(part)
+		}
+	}
+	return parts, opts
+}
@@ -0,0 +1,40 @@
+package hmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSegments(t *testing.T) {
+	// A stand-in for NFC normalization of the few strings used here.
+	nfc := strings.NewReplacer("é", "é", "Å", "Å").Replace
+
+	b := NewBuilder()
+	b.NormalizeSegments(nfc)
+	b.Get("/café", testHandler("cafe"))
+	b.Get("/b/été", testHandler("summer"))
+	b.Get("/b/:name", testHandler("name %s", "name"))
+	b.Get("/c/:x.é", testHandler("suffix %s", "x"))
+	b.Get("/d/:v:enum(Å|b)", testHandler("enum %s", "v"))
+	b.Get("/e/*", testHandler("wild %s", "*"), Except("/e/é"))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"GET", "/café", "cafe"},
+		{"GET", "/café", "cafe"},
+		{"GET", "/caf%C3%A9", "cafe"},
+		{"GET", "/cafe%CC%81", "cafe"},
+		{"GET", "/b/été", "summer"},
+		{"GET", "/b/éx", "name éx"},
+		{"GET", "/c/1.é", "suffix 1"},
+		{"GET", "/d/Å", "enum Å"},
+		{"GET", "/e/a/é", "wild /a/é"},
+		{"GET", "/e/é", "404"},
+	})
+
+	b.NormalizeSegments(nil)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/café", "cafe"},
+		{"GET", "/café", "404"},
+	})
+}
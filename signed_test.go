@@ -0,0 +1,53 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSigned(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { timeNow = time.Now }()
+	timeNow = func() time.Time { return now }
+
+	key := []byte("secret")
+	b := NewBuilder()
+	b.Get("/private/*", testHandler("private %s", "*"), Signed(key))
+	mux := b.Build()
+
+	sign := func(pth string, key []byte, expires time.Time) string {
+		return SignURL(&url.URL{Path: pth, RawQuery: "a=b"}, key, expires).String()
+	}
+	valid := sign("/private/x.txt", key, now.Add(time.Hour))
+	tampered, err := url.Parse(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered.Path = "/private/y.txt"
+
+	for _, tt := range []struct {
+		url      string
+		wantCode int
+	}{
+		{valid, 200},
+		{"/private/x.txt", 403},
+		{tampered.String(), 403},
+		{sign("/private/x.txt", key, now), 403},
+		{sign("/private/x.txt", []byte("other"), now.Add(time.Hour)), 403},
+		{sign("/private/a b", key, now.Add(time.Hour)), 200},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("GET %s: got code %d; want %d", tt.url, w.Code, tt.wantCode)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", valid, nil))
+	if got, want := w.Body.String(), "private /x.txt"; got != want {
+		t.Errorf("got body %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package hmux
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// WriteSitemap writes an XML sitemap (as described at sitemaps.org) listing
+// the URLs served by the GET rules of m. Each URL is formed by appending a
+// path to baseURL, which should include the scheme and host
+// ("https://example.com").
+//
+// Rules whose patterns consist only of literal segments are listed directly.
+// For rules with parameters, WriteSitemap calls expand (if it is non-nil) to
+// obtain the parameter values, keyed by parameter name, of each URL to list.
+// Rules with wildcard patterns and the special patterns "" and "*" are not
+// listed. The URLs are sorted.
+func (m *Mux) WriteSitemap(w io.Writer, baseURL string, expand func(Route) []map[string]string) error {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	var locs []string
+	seen := make(map[string]bool)
+	add := func(pth string) {
+		loc := baseURL + pth
+		if !seen[loc] {
+			seen[loc] = true
+			locs = append(locs, loc)
+		}
+	}
+	for _, ma := range m.matchers {
+		for _, rl := range ma.rules(http.MethodGet) {
+			switch rl.p.opt {
+			case patEmpty, patStar, patWildcard:
+				continue
+			}
+			if !rl.p.hasParams() {
+				pth, err := rl.p.fill(nil)
+				if err != nil {
+					return err
+				}
+				add(pth)
+				continue
+			}
+			if expand == nil {
+				continue
+			}
+			for _, vals := range expand(rl.route()) {
+				pth, err := rl.p.fill(vals)
+				if err != nil {
+					return fmt.Errorf("hmux: expanding %q: %s", rl.pat, err)
+				}
+				add(pth)
+			}
+		}
+	}
+
+	sort.Strings(locs)
+
+	type sitemapURL struct {
+		Loc string `xml:"loc"`
+	}
+	type urlset struct {
+		XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+		URLs    []sitemapURL `xml:"url"`
+	}
+	set := urlset{URLs: make([]sitemapURL, len(locs))}
+	for i, loc := range locs {
+		set.URLs[i].Loc = loc
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(set); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// fill constructs an escaped URL path from p by substituting parameter values
+// from vals. It returns an error if a parameter is missing from vals or has a
+// value which the parameter would not match. The wildcard, if any, is
+// omitted, leaving a trailing slash.
+func (p pattern) fill(vals map[string]string) (string, error) {
+	var sb strings.Builder
+	for _, seg := range p.segs {
+		sb.WriteByte('/')
+		if !seg.isParam {
+			sb.WriteString(url.PathEscape(seg.s))
+			continue
+		}
+		v, ok := vals[seg.s]
+		if !ok {
+			return "", fmt.Errorf("missing value for parameter %q", seg.s)
+		}
+		if v == "" {
+			return "", fmt.Errorf("empty value for parameter %q", seg.s)
+		}
+		if _, ok := matchParam(seg, v); !ok {
+			return "", fmt.Errorf("value %q does not match parameter %q of type %s", v, seg.s, seg.typeName())
+		}
+		sb.WriteString(url.PathEscape(v))
+		sb.WriteString(url.PathEscape(seg.suffix))
+	}
+	if p.opt == patTrailingSlash || p.opt == patWildcard {
+		sb.WriteByte('/')
+	}
+	return sb.String(), nil
+}
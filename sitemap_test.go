@@ -0,0 +1,69 @@
+package hmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteSitemap(t *testing.T) {
+	b := NewBuilder()
+	h := testHandler("")
+	b.Get("/", h)
+	b.Get("/about/", h)
+	b.Get("/a%20b", h)
+	b.Post("/contact", h)
+	b.Handle("", "/any", h)
+	b.Get("/static/*", h)
+	b.Get("/users/:name", h)
+	b.Get("/posts/:id:int64", h)
+	b.Get("/skipped/:x", h)
+
+	expand := func(r Route) []map[string]string {
+		switch r.Pattern {
+		case "/users/:name":
+			return []map[string]string{{"name": "alice"}, {"name": "b/c"}}
+		case "/posts/:id:int64":
+			return []map[string]string{{"id": "3"}}
+		}
+		return nil
+	}
+	var sb strings.Builder
+	if err := b.Build().WriteSitemap(&sb, "https://example.com/", expand); err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+  </url>
+  <url>
+    <loc>https://example.com/a%20b</loc>
+  </url>
+  <url>
+    <loc>https://example.com/about/</loc>
+  </url>
+  <url>
+    <loc>https://example.com/posts/3</loc>
+  </url>
+  <url>
+    <loc>https://example.com/users/alice</loc>
+  </url>
+  <url>
+    <loc>https://example.com/users/b%2Fc</loc>
+  </url>
+</urlset>
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got sitemap:\n%s\nwant:\n%s", got, want)
+	}
+
+	bad := func(Route) []map[string]string {
+		return []map[string]string{{"id": "x"}}
+	}
+	b = NewBuilder()
+	b.Get("/posts/:id:int64", h)
+	err := b.Build().WriteSitemap(&sb, "https://example.com", bad)
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("WriteSitemap with bad param: got err=%v", err)
+	}
+}
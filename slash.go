@@ -0,0 +1,90 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// OptionalTrailingSlash returns a RuleOption which makes a rule match request
+// paths with or without a trailing slash, so that a single registration
+// serves both /x and /x/:
+//
+//	b.Get("/about", handleAbout, hmux.OptionalTrailingSlash())
+//
+// The rule's pattern may be given in either form; "/about" and "/about/" are
+// equivalent for such a rule, so registering both (for the same method and
+// conditions) is a conflict. A rule with a pattern that matches a single form
+// of the path is more specific than one with this option, so the two kinds of
+// rules may be combined:
+//
+//	b.Get("/docs", handleDocs, hmux.OptionalTrailingSlash())
+//	b.Get("/docs/", handleDocsDir) // takes precedence for /docs/
+//
+// The option has no effect on the patterns "", "*", and "/" or on wildcard
+// patterns, which already match paths ending with a slash.
+func OptionalTrailingSlash() RuleOption {
+	return optionalTrailingSlash
+}
+
+func optionalTrailingSlash(rl *rule) {
+	if len(rl.p.segs) == 0 {
+		return // "", "*", or "/"
+	}
+	switch rl.p.opt {
+	case patTrailingSlash:
+		rl.p.opt = patOther
+	case patOther:
+	default:
+		return
+	}
+	rl.p.anySlash = true
+}
+
+// OptionalTrailingSlashes sets whether rules registered with b after the
+// call behave as if given OptionalTrailingSlash, matching request paths with
+// or without a trailing slash. It is disabled by default.
+func (b *Builder) OptionalTrailingSlashes(enable bool) {
+	b.checkNotGroup("OptionalTrailingSlashes")
+	b.anySlash = enable
+}
+
+// RedirectTrailingSlash sets whether Muxes built from b redirect requests
+// whose paths match no rule pattern, but would match with a trailing slash
+// added or removed, to that form of the path. For example, if only the
+// pattern "/x" is registered, a request for /x/ receives a 308 redirect to
+// /x, and if only "/x/" is registered, a request for /x is redirected to /x/.
+// The redirect preserves the query.
+//
+// When disabled (the default), such requests result in a 404 response.
+func (b *Builder) RedirectTrailingSlash(enable bool) {
+	b.checkNotGroup("RedirectTrailingSlash")
+	b.redirectSlash = enable
+}
+
+// slashRedirect returns the URL to which a request for pth, which doesn't
+// match any rule pattern, should be redirected if the path with its trailing
+// slash added or removed matches.
+func (m *Mux) slashRedirect(r *http.Request, pth string, opts matchOpts) (string, bool) {
+	if pth == "*" || pth == "/" || pth == "" {
+		return "", false
+	}
+	trailing := strings.HasSuffix(pth, "/")
+	pth1 := pth + "/"
+	if trailing {
+		pth1 = strings.TrimSuffix(pth, "/")
+	}
+	if !m.handler(r, pth1, opts).matchesPath() {
+		return "", false
+	}
+	u := *r.URL
+	if trailing {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	} else {
+		u.Path += "/"
+		if u.RawPath != "" {
+			u.RawPath += "/"
+		}
+	}
+	return u.String(), true
+}
@@ -0,0 +1,88 @@
+package hmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptionalTrailingSlash(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/about", testHandler("about"), OptionalTrailingSlash())
+	b.Get("/team/", testHandler("team"), OptionalTrailingSlash())
+	b.Get("/docs", testHandler("docs"), OptionalTrailingSlash())
+	b.Get("/docs/", testHandler("docs dir"))
+	b.Get("/users/:id", testHandler("user %s", "id"), OptionalTrailingSlash())
+	b.Get("/strict", testHandler("strict"))
+	b.Get("/", testHandler("root"), OptionalTrailingSlash())
+	b.Get("/files/*", testHandler("files %s", "*"), OptionalTrailingSlash())
+
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/about", "about"},
+		{"GET", "/about/", "about"},
+		{"GET", "/team", "team"},
+		{"GET", "/team/", "team"},
+		{"GET", "/docs", "docs"},
+		{"GET", "/docs/", "docs dir"},
+		{"GET", "/users/3", "user 3"},
+		{"GET", "/users/3/", "user 3"},
+		{"GET", "/strict", "strict"},
+		{"GET", "/strict/", "404"},
+		{"GET", "/", "root"},
+		{"GET", "/files", "404"},
+		{"GET", "/files/", "files /"},
+	})
+
+	b = NewBuilder()
+	b.OptionalTrailingSlashes(true)
+	b.Get("/a", testHandler("a"))
+	b.OptionalTrailingSlashes(false)
+	b.Get("/b", testHandler("b"))
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/a/", "a"},
+		{"GET", "/b/", "404"},
+	})
+}
+
+func TestOptionalTrailingSlashConflict(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler("x"), OptionalTrailingSlash())
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "conflicts") {
+			t.Errorf("got panic %v; want a conflict", r)
+		}
+	}()
+	b.Get("/x/", testHandler("x/"), OptionalTrailingSlash())
+}
+
+func TestRedirectTrailingSlash(t *testing.T) {
+	b := NewBuilder()
+	b.RedirectTrailingSlash(true)
+	b.Get("/x", testHandler("x"))
+	b.Get("/dir/", testHandler("dir"))
+	b.Get("/both", testHandler("both"))
+	b.Get("/both/", testHandler("both/"))
+	b.Post("/post", testHandler("post"))
+	b.Get("/files/*", testHandler("files"))
+	b.Get("/a%2Fb/", testHandler("a/b"))
+
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/x", "x"},
+		{"GET", "/x/", "308 /x"},
+		{"GET", "/x/?q=1", "308 /x?q=1"},
+		{"GET", "/dir", "308 /dir/"},
+		{"GET", "/dir/", "dir"},
+		{"GET", "/both", "both"},
+		{"GET", "/both/", "both/"},
+		{"GET", "/post/", "308 /post"},
+		{"GET", "/files", "308 /files/"},
+		{"GET", "/a%2Fb", "308 /a%2Fb/"},
+		{"GET", "/y/", "404"},
+		{"GET", "/", "404"},
+	})
+
+	b.RedirectTrailingSlash(false)
+	testRequests(t, b.Build(), []reqTest{
+		{"GET", "/x/", "404"},
+	})
+}
@@ -0,0 +1,76 @@
+package hmux
+
+import "unsafe"
+
+// Stats describes the routing table of a Mux.
+type Stats struct {
+	// Rules is the number of rules.
+	Rules int
+	// Patterns is the number of distinct patterns. Rules with equivalent
+	// patterns (such as the rules for different methods on the same
+	// path) share a pattern.
+	Patterns int
+	// LiteralPatterns, ParamPatterns, WildcardPatterns, and
+	// SpecialPatterns break down Patterns by kind: patterns containing
+	// only literal segments, patterns containing parameters (but no
+	// wildcard), wildcard patterns, and the special patterns "" and "*".
+	LiteralPatterns  int
+	ParamPatterns    int
+	WildcardPatterns int
+	SpecialPatterns  int
+	// Bytes is an approximation of the memory used by the routing table,
+	// excluding handlers. Muxes built from the same Builder share much of
+	// this memory, so the Bytes of several Muxes may not be summed.
+	Bytes int
+}
+
+// Stats returns statistics about the routing table of m.
+func (m *Mux) Stats() Stats {
+	var st Stats
+	st.Bytes = len(m.matchers) * int(unsafe.Sizeof((*matcher)(nil)))
+	for _, ma := range m.matchers {
+		st.Patterns++
+		switch {
+		case ma.pat.opt == patEmpty || ma.pat.opt == patStar:
+			st.SpecialPatterns++
+		case ma.pat.opt == patWildcard:
+			st.WildcardPatterns++
+		case ma.pat.hasParams():
+			st.ParamPatterns++
+		default:
+			st.LiteralPatterns++
+		}
+		st.Bytes += int(unsafe.Sizeof(*ma))
+		st.Bytes += len(ma.other) * int(unsafe.Sizeof(methodRules{}))
+		st.Bytes += len(ma.methodNames) * int(unsafe.Sizeof(""))
+		ma.forEachRule(func(rl *rule) {
+			st.Rules++
+			st.Bytes += int(unsafe.Sizeof((*rule)(nil))) + rl.size()
+		})
+	}
+	return st
+}
+
+// size approximates the memory used by p's segments.
+func (p pattern) size() int {
+	n := len(p.segs) * int(unsafe.Sizeof(segment{}))
+	for _, seg := range p.segs {
+		n += len(seg.s)
+		for _, v := range seg.enum {
+			n += int(unsafe.Sizeof(v)) + len(v)
+		}
+	}
+	return n
+}
+
+// size approximates the memory used by rl, excluding its handler.
+func (rl *rule) size() int {
+	n := int(unsafe.Sizeof(*rl)) + len(rl.method) + len(rl.pat) + rl.p.size()
+	for k, v := range rl.meta {
+		n += 2*int(unsafe.Sizeof("")) + len(k) + len(v)
+	}
+	n += len(rl.mws) * int(unsafe.Sizeof(rl.mws[0]))
+	n += len(rl.responses) * int(unsafe.Sizeof(responseSpec{}))
+	n += len(rl.conds) * int(unsafe.Sizeof(rl.conds[0]))
+	return n
+}
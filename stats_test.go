@@ -0,0 +1,45 @@
+package hmux
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/", testHandler(""))
+	b.Get("/a/b", testHandler(""))
+	b.Post("/a/b", testHandler(""))
+	b.Get("/a/:x", testHandler(""))
+	b.Get("/a/:y/", testHandler(""))
+	b.Prefix("/static", testHandler(""))
+	b.Handle("OPTIONS", "*", testHandler(""))
+	b.Handle("", "", testHandler(""))
+	st := b.Build().Stats()
+	want := Stats{
+		Rules:            8,
+		Patterns:         7,
+		LiteralPatterns:  2,
+		ParamPatterns:    2,
+		WildcardPatterns: 1,
+		SpecialPatterns:  2,
+		Bytes:            st.Bytes,
+	}
+	if st != want {
+		t.Errorf("got %+v; want %+v", st, want)
+	}
+	if st.Bytes <= 0 {
+		t.Errorf("got Bytes=%d; want > 0", st.Bytes)
+	}
+
+	for i := 0; i < 100; i++ {
+		b.Get(fmt.Sprintf("/gen/%d/:id", i), testHandler(""))
+	}
+	st1 := b.Build().Stats()
+	if st1.Rules != 108 {
+		t.Errorf("got %d rules; want 108", st1.Rules)
+	}
+	if st1.Bytes <= st.Bytes {
+		t.Errorf("Bytes did not grow with more rules: %d, then %d", st.Bytes, st1.Bytes)
+	}
+}
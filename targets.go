@@ -0,0 +1,112 @@
+package hmux
+
+import "net/http"
+
+// A TargetForm is a form of HTTP request target (the URL given in the
+// request line) which a Mux may handle differently from ordinary requests.
+// See Builder.SetTargetAction.
+type TargetForm int
+
+const (
+	// EmptyPath is a request target with an empty path, such as the
+	// absolute-form target "http://example.com" (but not an
+	// authority-form target).
+	EmptyPath TargetForm = iota
+	// AbsoluteForm is a request target which is an absolute URL, such as
+	// "http://example.com/x", as clients send to proxies. (Requests
+	// created with an absolute URL, as by httptest.NewRequest, also have
+	// this form.)
+	AbsoluteForm
+	// AuthorityForm is the request target of a CONNECT request consisting
+	// only of a host and port, such as "example.com:443".
+	AuthorityForm
+
+	numTargetForms
+)
+
+// A TargetAction specifies how a Mux handles requests having a particular
+// TargetForm.
+type TargetAction int
+
+const (
+	targetDefault TargetAction = iota
+
+	// TargetNormalize handles a request as an ordinary request for the
+	// URL path. Requests with empty paths are redirected to "/" (as with
+	// other non-canonical paths; see Routing) and absolute-form requests
+	// are routed by their path alone. It is the default action for
+	// EmptyPath and AbsoluteForm, and it is not valid for AuthorityForm.
+	TargetNormalize
+	// TargetReject responds to a request with a 400 ("Bad Request").
+	TargetReject
+	// TargetSpecial routes a request using only the rules registered with
+	// the special pattern "", ignoring its path. It is the default action
+	// for AuthorityForm.
+	TargetSpecial
+)
+
+// SetTargetAction sets how Muxes built from b handle requests whose request
+// targets have the given form. For example, a server which is not a proxy
+// might reject requests for absolute URLs and send CONNECT requests to a
+// dedicated handler:
+//
+//	b.SetTargetAction(hmux.AbsoluteForm, hmux.TargetReject)
+//	b.Connect("", handleTunnel)
+//
+// If a request has both the EmptyPath and AbsoluteForm forms, the action
+// listed later among TargetNormalize, TargetReject, and TargetSpecial applies.
+func (b *Builder) SetTargetAction(form TargetForm, action TargetAction) {
+	b.checkNotGroup("SetTargetAction")
+	if form < 0 || form >= numTargetForms {
+		panic("hmux: SetTargetAction called with invalid form")
+	}
+	switch action {
+	case TargetNormalize:
+		if form == AuthorityForm {
+			panic("hmux: TargetNormalize is not valid for AuthorityForm")
+		}
+	case TargetReject, TargetSpecial:
+	default:
+		panic("hmux: SetTargetAction called with invalid action")
+	}
+	b.targets[form] = action
+}
+
+// targetAction returns the action for the form of r's request target.
+func (m *Mux) targetAction(r *http.Request) TargetAction {
+	if r.Method == http.MethodConnect && r.URL.Path == "" {
+		return m.targetFormAction(AuthorityForm)
+	}
+	action := TargetNormalize
+	if r.URL.Path == "" {
+		action = m.targetFormAction(EmptyPath)
+	}
+	if r.URL.IsAbs() {
+		if a := m.targetFormAction(AbsoluteForm); a > action {
+			action = a
+		}
+	}
+	return action
+}
+
+func (m *Mux) targetFormAction(form TargetForm) TargetAction {
+	if action := m.targets[form]; action != targetDefault {
+		return action
+	}
+	if form == AuthorityForm {
+		return TargetSpecial
+	}
+	return TargetNormalize
+}
+
+// specialHandler matches r using only the rules for the pattern "".
+func (m *Mux) specialHandler(r *http.Request) matchResult {
+	for i, ma := range m.matchers {
+		if ma.pat.opt == patEmpty {
+			mr := ma.match(r, nil, 0)
+			mr.scanned = i + 1
+			return mr
+		}
+	}
+	return noMatch
+}
@@ -0,0 +1,88 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTargetForms(t *testing.T) {
+	newBuilder := func() *Builder {
+		b := NewBuilder()
+		b.Get("/", testHandler("index"))
+		b.Get("/x", testHandler("x"))
+		b.Handle(http.MethodConnect, "", testHandler("tunnel"))
+		b.Get("", testHandler("special"))
+		return b
+	}
+
+	for _, tt := range []struct {
+		name    string
+		actions map[TargetForm]TargetAction
+		method  string
+		target  string
+		want    string
+	}{
+		{"default", nil, "GET", "/x", "x"},
+		{"default", nil, "GET", "http://example.com/x", "x"},
+		{"default", nil, "GET", "http://example.com", "308 http://example.com/"},
+		{"default", nil, "CONNECT", "example.com:443", "tunnel"},
+
+		{"reject absolute", map[TargetForm]TargetAction{AbsoluteForm: TargetReject}, "GET", "/x", "x"},
+		{"reject absolute", map[TargetForm]TargetAction{AbsoluteForm: TargetReject}, "GET", "http://example.com/x", "400"},
+		{"reject absolute", map[TargetForm]TargetAction{AbsoluteForm: TargetReject}, "GET", "http://example.com", "400"},
+		{"special absolute", map[TargetForm]TargetAction{AbsoluteForm: TargetSpecial}, "GET", "http://example.com/x", "special"},
+		{"special empty", map[TargetForm]TargetAction{EmptyPath: TargetSpecial}, "GET", "http://example.com", "special"},
+		{"special empty", map[TargetForm]TargetAction{EmptyPath: TargetSpecial}, "GET", "http://example.com/x", "x"},
+		{
+			"special empty, reject absolute",
+			map[TargetForm]TargetAction{EmptyPath: TargetSpecial, AbsoluteForm: TargetReject},
+			"GET", "http://example.com", "special",
+		},
+		{"reject authority", map[TargetForm]TargetAction{AuthorityForm: TargetReject}, "CONNECT", "example.com:443", "400"},
+		{"reject authority", map[TargetForm]TargetAction{AuthorityForm: TargetReject}, "GET", "/x", "x"},
+	} {
+		b := newBuilder()
+		for form, action := range tt.actions {
+			b.SetTargetAction(form, action)
+		}
+		mux := b.Build()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		mux.ServeHTTP(w, r)
+		var got string
+		switch {
+		case w.Code == 200:
+			got = w.Body.String()
+		case w.Code/100 == 3:
+			got = w.Result().Status[:3] + " " + w.Header().Get("Location")
+		case w.Code == 405:
+			got = "405 " + w.Header().Get("Allow")
+		default:
+			got = w.Result().Status[:3]
+		}
+		if got != tt.want {
+			t.Errorf("%s: %s %s: got %q; want %q", tt.name, tt.method, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestSetTargetActionInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		form   TargetForm
+		action TargetAction
+	}{
+		{AuthorityForm, TargetNormalize},
+		{TargetForm(10), TargetReject},
+		{EmptyPath, TargetAction(0)},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("SetTargetAction(%d, %d) did not panic", tt.form, tt.action)
+				}
+			}()
+			NewBuilder().SetTargetAction(tt.form, tt.action)
+		}()
+	}
+}
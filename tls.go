@@ -0,0 +1,95 @@
+package hmux
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TLS returns a RuleOption which restricts a rule to requests received over
+// TLS (that is, requests for which r.TLS is non-nil). This keeps sensitive
+// endpoints from being reached through a plaintext listener that shares the
+// Mux:
+//
+//	b.Post("/login", handleLogin, hmux.TLS(http.StatusPermanentRedirect))
+//
+// The fallback status determines the response to a plaintext request
+// matching the rule when no other rule for the same pattern and method
+// matches. It must be http.StatusNotFound or a redirect status (301, 302,
+// 303, 307, or 308); in the latter case, the Mux redirects the client to the
+// same URL with the https scheme. The redirect drops any port given in the
+// request's Host, since a port serving one scheme does not serve the other;
+// the client uses the default port for the new scheme. Use 307 or 308 for
+// methods other than GET and HEAD so that clients preserve the method and
+// body.
+//
+// A TLS rule and a Plaintext rule may be registered for the same pattern and
+// method to serve the two kinds of requests differently.
+//
+// Note that a server behind a proxy which terminates TLS sees only plaintext
+// requests.
+func TLS(fallback int) RuleOption {
+	return schemeOption(true, fallback)
+}
+
+// Plaintext returns a RuleOption which restricts a rule to requests not
+// received over TLS. It is the counterpart of TLS; a redirect fallback sends
+// the client to the same URL with the http scheme.
+func Plaintext(fallback int) RuleOption {
+	return schemeOption(false, fallback)
+}
+
+func schemeOption(tls bool, fallback int) RuleOption {
+	switch fallback {
+	case http.StatusNotFound,
+		http.StatusMovedPermanently,
+		http.StatusFound,
+		http.StatusSeeOther,
+		http.StatusTemporaryRedirect,
+		http.StatusPermanentRedirect:
+	default:
+		panic(fmt.Sprintf("hmux: invalid scheme fallback status %d", fallback))
+	}
+	return func(rl *rule) {
+		rl.conds = append(rl.conds, schemeCond{tls, fallback})
+	}
+}
+
+type schemeCond struct {
+	tls      bool
+	fallback int
+}
+
+func (c schemeCond) check(r *http.Request, _ *rule, _ *Params) int {
+	if (r.TLS != nil) == c.tls {
+		return condOK
+	}
+	return c.fallback
+}
+
+func (c schemeCond) key() string {
+	if c.tls {
+		return "tls"
+	}
+	return "plaintext"
+}
+
+func (c schemeCond) respond(w http.ResponseWriter, r *http.Request, status int) {
+	if status == http.StatusNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	scheme := "http"
+	if c.tls {
+		scheme = "https"
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6 literal
+		}
+	}
+	http.Redirect(w, r, scheme+"://"+host+r.URL.RequestURI(), status)
+}
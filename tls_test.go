@@ -0,0 +1,69 @@
+package hmux
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTLS(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/login", testHandler("login"), TLS(http.StatusMovedPermanently))
+	b.Get("/admin", testHandler("admin"), TLS(http.StatusNotFound))
+	b.Get("/page", testHandler("secure page"), TLS(http.StatusMovedPermanently))
+	b.Get("/page", testHandler("plain page"), Plaintext(http.StatusMovedPermanently))
+	b.Get("/legacy", testHandler("legacy"), Plaintext(http.StatusFound))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		url      string
+		tls      bool
+		wantCode int
+		want     string // body or Location
+	}{
+		{"/login", true, 200, "login"},
+		{"/login?next=/x", false, 301, "https://example.com/login?next=/x"},
+		{"/admin", true, 200, "admin"},
+		{"/admin", false, 404, "404 page not found\n"},
+		{"/page", true, 200, "secure page"},
+		{"/page", false, 200, "plain page"},
+		{"/legacy", false, 200, "legacy"},
+		{"/legacy", true, 302, "http://example.com/legacy"},
+		// The port of the other scheme is dropped.
+		{"http://example.com:80/login", false, 301, "https://example.com/login"},
+		{"http://example.com:8080/login", false, 301, "https://example.com/login"},
+		{"https://example.com:443/legacy", true, 302, "http://example.com/legacy"},
+		{"http://[::1]:80/login", false, 301, "https://[::1]/login"},
+		{"http://[::1]/login", false, 301, "https://[::1]/login"},
+	} {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if tt.tls {
+			r.TLS = new(tls.ConnectionState)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tt.wantCode {
+			t.Errorf("GET %s (tls=%t): got code %d; want %d", tt.url, tt.tls, w.Code, tt.wantCode)
+			continue
+		}
+		got := w.Body.String()
+		if w.Code/100 == 3 {
+			got = w.Header().Get("Location")
+		}
+		if got != tt.want {
+			t.Errorf("GET %s (tls=%t): got %q; want %q", tt.url, tt.tls, got, tt.want)
+		}
+	}
+
+	for _, status := range []int{0, 200, 403} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TLS(%d) did not panic", status)
+				}
+			}()
+			TLS(status)
+		}()
+	}
+}
@@ -0,0 +1,37 @@
+package hmux
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// traceOmit lists the request header fields which EchoTrace leaves out of its
+// responses because they may carry credentials.
+var traceOmit = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+}
+
+// EchoTrace is a handler for TRACE requests (see Builder.Trace). It responds
+// with a copy of the request line and header in a message/http body, as
+// described by RFC 9110, leaving out the Authorization, Proxy-Authorization,
+// and Cookie fields so that a page which can send TRACE requests cannot use
+// the responses to read the user's credentials.
+func EchoTrace(w http.ResponseWriter, r *http.Request) {
+	target := r.RequestURI
+	if target == "" {
+		target = r.URL.RequestURI()
+	}
+	header := make(http.Header)
+	for k, v := range r.Header {
+		if !traceOmit[k] {
+			header[k] = v
+		}
+	}
+	w.Header().Set("Content-Type", "message/http")
+	fmt.Fprintf(w, "%s %s %s\r\nHost: %s\r\n", r.Method, target, r.Proto, r.Host)
+	header.Write(w)
+	io.WriteString(w, "\r\n")
+}
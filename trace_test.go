@@ -0,0 +1,49 @@
+package hmux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEchoTrace(t *testing.T) {
+	b := NewBuilder()
+	b.Trace("", EchoTrace)
+	b.Get("/x", testHandler("x"))
+	mux := b.Build()
+
+	r := httptest.NewRequest("TRACE", "/x?a=1", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Cookie", "session=secret")
+	r.Header.Set("X-Forwarded-For", "10.0.0.1")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if got, want := w.Header().Get("Content-Type"), "message/http"; got != want {
+		t.Errorf("got Content-Type %q; want %q", got, want)
+	}
+	want := "TRACE /x?a=1 HTTP/1.1\r\nHost: example.com\r\nX-Forwarded-For: 10.0.0.1\r\n\r\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("got body %q; want %q", got, want)
+	}
+
+	testRequests(t, mux, []reqTest{
+		{"TRACE", "/y", "TRACE /y HTTP/1.1\r\nHost: example.com\r\n\r\n"},
+	})
+}
+
+func TestTraceWithoutRule(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/x", testHandler("x"))
+	b.Any("/any", testHandler("any"))
+	b.Trace("/any/traced", EchoTrace)
+	b.Any("/any/*", testHandler("any wildcard"))
+	mux := b.Build()
+
+	testRequests(t, mux, []reqTest{
+		{"TRACE", "/x", "405 GET"},
+		{"TRACE", "/y", "404"},
+		// Rules for all methods answer TRACE requests.
+		{"TRACE", "/any", "any"},
+		{"TRACE", "/any/other", "any wildcard"},
+		{"TRACE", "/any/traced", "TRACE /any/traced HTTP/1.1\r\nHost: example.com\r\n\r\n"},
+	})
+}
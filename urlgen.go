@@ -0,0 +1,150 @@
+package hmux
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// WriteURLBuilders writes the source of a Go file in package pkg defining a
+// typed URL-builder function for each named rule of m (see Name). Given
+//
+//	b.Get("/users/:id:int64/posts/:slug", showPost, hmux.Name("user.post"))
+//
+// the file defines
+//
+//	func UserPostURL(m *hmux.Mux, id int64, slug string) (string, error) {
+//		return m.URL("user.post", id, slug)
+//	}
+//
+// so that, once the file is regenerated (typically by a program run with go
+// generate which builds the Mux), callers of a builder whose rule has been
+// renamed or has different parameters fail to compile rather than getting an
+// error from URL at run time.
+//
+// The name of each function is formed from the rule name by capitalizing its
+// alphanumeric runs and adding the suffix "URL". The arguments are named after
+// the parameters and have the types int32, int64, time.Time (for rfc3339
+// parameters), []byte (for hex parameters), or string (for all others). A
+// function for a wildcard pattern takes a final string argument, rest, giving
+// the path suffix. Rules with the special patterns "" and "*" are skipped.
+//
+// WriteURLBuilders returns an error if two names yield the same function name
+// or if a name or parameter name yields no valid Go identifier.
+func (m *Mux) WriteURLBuilders(w io.Writer, pkg string) error {
+	names := make([]string, 0, len(m.names))
+	for name, rl := range m.names {
+		if rl.p.opt != patEmpty && rl.p.opt != patStar {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	usesTime := false
+	funcs := make(map[string]string)
+	for _, name := range names {
+		rl := m.names[name]
+		fn := goIdent(name, true)
+		if fn == "" {
+			return fmt.Errorf("hmux: rule name %q has no Go identifier", name)
+		}
+		fn += "URL"
+		if other, ok := funcs[fn]; ok {
+			return fmt.Errorf("hmux: rule names %q and %q both yield %s", other, name, fn)
+		}
+		funcs[fn] = name
+
+		var params, args []string
+		argNames := map[string]bool{"m": true}
+		addArg := func(pname, typ string) error {
+			arg := goIdent(pname, false)
+			if arg == "" {
+				return fmt.Errorf("hmux: parameter %q of rule %q has no Go identifier", pname, name)
+			}
+			if token.IsKeyword(arg) || argNames[arg] {
+				arg += "Param"
+			}
+			argNames[arg] = true
+			params = append(params, arg+" "+typ)
+			args = append(args, arg)
+			return nil
+		}
+		for _, seg := range rl.p.segs {
+			if !seg.isParam {
+				continue
+			}
+			typ := "string"
+			switch seg.ptyp {
+			case paramInt32:
+				typ = "int32"
+			case paramInt64:
+				typ = "int64"
+			case paramRFC3339:
+				typ = "time.Time"
+				usesTime = true
+			case paramHex:
+				typ = "[]byte"
+			}
+			if err := addArg(seg.s, typ); err != nil {
+				return err
+			}
+		}
+		if rl.p.opt == patWildcard {
+			if err := addArg("rest", "string"); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(&buf, "\n// %s returns the URL path of the rule %q (pattern %q).\n", fn, name, rl.pat)
+		fmt.Fprintf(&buf, "func %s(%s) (string, error) {\n", fn, strings.Join(append([]string{"m *hmux.Mux"}, params...), ", "))
+		fmt.Fprintf(&buf, "\treturn m.URL(%s)\n}\n", strings.Join(append([]string{fmt.Sprintf("%q", name)}, args...), ", "))
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by hmux.WriteURLBuilders. DO NOT EDIT.\n\npackage %s\n", pkg)
+	if len(funcs) > 0 {
+		src.WriteString("\nimport (\n")
+		if usesTime {
+			src.WriteString("\t\"time\"\n\n")
+		}
+		src.WriteString("\t\"github.com/cespare/hmux\"\n)\n")
+	}
+	src.Write(buf.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("hmux: generating URL builders: %s", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// goIdent converts s to a Go identifier by joining its runs of letters and
+// digits, capitalizing each run (except the first, unless exported is set).
+// It returns "" if the result would not be a valid identifier (apart from
+// being a keyword).
+func goIdent(s string, exported bool) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, word := range words {
+		rs := []rune(word)
+		if i > 0 || exported {
+			rs[0] = unicode.ToUpper(rs[0])
+		}
+		b.WriteString(string(rs))
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		return ""
+	}
+	if exported && !token.IsExported(id) {
+		return ""
+	}
+	return id
+}
@@ -0,0 +1,92 @@
+package hmux
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteURLBuilders(t *testing.T) {
+	b := NewBuilder()
+	b.HandleMethods([]string{"GET", "PUT"}, "/users/:id:int64/posts/:slug", testHandler(""), Name("user.post"))
+	b.Get("/events/:t:rfc3339/:type", testHandler(""), Name("event"))
+	b.Get("/blobs/:sum:hex/:n:int32", testHandler(""), Name("blob"))
+	b.Get("/files/:rest/*", testHandler(""), Name("file-tree"))
+	b.Get("/about", testHandler(""), Name("about"))
+	b.Get("/", testHandler(""))
+	b.Get("*", testHandler(""), Name("star"))
+	mux := b.Build()
+
+	var buf bytes.Buffer
+	if err := mux.WriteURLBuilders(&buf, "routes"); err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by hmux.WriteURLBuilders. DO NOT EDIT.
+
+package routes
+
+import (
+	"time"
+
+	"github.com/cespare/hmux"
+)
+
+// AboutURL returns the URL path of the rule "about" (pattern "/about").
+func AboutURL(m *hmux.Mux) (string, error) {
+	return m.URL("about")
+}
+
+// BlobURL returns the URL path of the rule "blob" (pattern "/blobs/:sum:hex/:n:int32").
+func BlobURL(m *hmux.Mux, sum []byte, n int32) (string, error) {
+	return m.URL("blob", sum, n)
+}
+
+// EventURL returns the URL path of the rule "event" (pattern "/events/:t:rfc3339/:type").
+func EventURL(m *hmux.Mux, t time.Time, typeParam string) (string, error) {
+	return m.URL("event", t, typeParam)
+}
+
+// FileTreeURL returns the URL path of the rule "file-tree" (pattern "/files/:rest/*").
+func FileTreeURL(m *hmux.Mux, rest string, restParam string) (string, error) {
+	return m.URL("file-tree", rest, restParam)
+}
+
+// UserPostURL returns the URL path of the rule "user.post" (pattern "/users/:id:int64/posts/:slug").
+func UserPostURL(m *hmux.Mux, id int64, slug string) (string, error) {
+	return m.URL("user.post", id, slug)
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := NewBuilder().Build().WriteURLBuilders(&buf, "routes"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "// Code generated by hmux.WriteURLBuilders. DO NOT EDIT.\n\npackage routes\n"; buf.String() != want {
+		t.Errorf("with no named rules, got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteURLBuildersErrors(t *testing.T) {
+	for _, tt := range []struct {
+		pats  []string
+		names []string
+		want  string
+	}{
+		{[]string{"/a", "/b"}, []string{"user.show", "user-show"}, `rule names "user-show" and "user.show" both yield UserShowURL`},
+		{[]string{"/a"}, []string{"1st"}, `rule name "1st" has no Go identifier`},
+		{[]string{"/a/:-"}, []string{"a"}, `parameter "-" of rule "a" has no Go identifier`},
+	} {
+		b := NewBuilder()
+		for i, pat := range tt.pats {
+			b.Get(pat, testHandler(""), Name(tt.names[i]))
+		}
+		var buf bytes.Buffer
+		err := b.Build().WriteURLBuilders(&buf, "routes")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("names %q: got error %v; want error containing %q", tt.names, err, tt.want)
+		}
+	}
+}
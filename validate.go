@@ -0,0 +1,115 @@
+package hmux
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// A Validator checks the body of a request. See Validate.
+//
+// A Validator can be implemented using a JSON Schema library, for example.
+type Validator interface {
+	Validate(r *http.Request, body []byte) error
+}
+
+// ValidatorFunc is an adapter to allow the use of ordinary functions as
+// Validators.
+type ValidatorFunc func(r *http.Request, body []byte) error
+
+// Validate calls f(r, body).
+func (f ValidatorFunc) Validate(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+// A ValidationError describes a problem with a single part of a request body.
+// Validators may return a ValidationError, or several of them combined using
+// errors.Join, to give clients structured error details.
+type ValidationError struct {
+	// Field identifies the invalid part of the body, such as a JSON
+	// pointer. It may be empty.
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// MaxValidatedBodySize is the largest request body which is read for
+// validation by rules using Validate. Larger requests are rejected with an
+// HTTP 413 ("Request Entity Too Large") response.
+const MaxValidatedBodySize = 10 << 20
+
+// Validate returns a RuleOption which validates the body of each request
+// matched by the rule using v before calling the handler. The handler
+// receives the request with its body intact.
+//
+// If v returns an error, the Mux writes an HTTP 422 ("Unprocessable Entity")
+// response with a JSON body listing the validation errors:
+//
+//	{"errors": [{"field": "/name", "message": "required"}]}
+//
+// The error may be a *ValidationError or, to report multiple problems, an
+// error with an Unwrap() []error method (such as one created by errors.Join)
+// wrapping several errors. Other errors are reported with only a message.
+func Validate(v Validator) RuleOption {
+	return func(rl *rule) {
+		rl.mws = append(rl.mws, func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body []byte
+				if r.Body != nil {
+					var err error
+					body, err = io.ReadAll(io.LimitReader(r.Body, MaxValidatedBodySize+1))
+					if err != nil {
+						http.Error(w, "error reading request body", http.StatusBadRequest)
+						return
+					}
+					if len(body) > MaxValidatedBodySize {
+						http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+						return
+					}
+					r.Body.Close()
+				}
+				if err := v.Validate(r, body); err != nil {
+					writeValidationErrors(w, err)
+					return
+				}
+				r1 := new(http.Request)
+				*r1 = *r
+				r1.Body = io.NopCloser(bytes.NewReader(body))
+				h.ServeHTTP(w, r1)
+			})
+		})
+	}
+}
+
+func writeValidationErrors(w http.ResponseWriter, err error) {
+	type jsonError struct {
+		Field   string `json:"field,omitempty"`
+		Message string `json:"message"`
+	}
+	var resp struct {
+		Errors []jsonError `json:"errors"`
+	}
+	errs := []error{err}
+	if u, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = u.Unwrap()
+	}
+	for _, err := range errs {
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			resp.Errors = append(resp.Errors, jsonError{ve.Field, ve.Message})
+		} else {
+			resp.Errors = append(resp.Errors, jsonError{Message: err.Error()})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,49 @@
+package hmux
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	v := ValidatorFunc(func(r *http.Request, body []byte) error {
+		switch string(body) {
+		case "ok":
+			return nil
+		case "bad":
+			return errors.New("bad body")
+		default:
+			return errors.Join(
+				&ValidationError{Field: "/name", Message: "required"},
+				&ValidationError{Message: "too short"},
+			)
+		}
+	})
+	b := NewBuilder()
+	b.Post("/x", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}, Validate(v))
+	mux := b.Build()
+
+	for _, tt := range []struct {
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"ok", 200, "ok"},
+		{"bad", 422, `{"errors":[{"message":"bad body"}]}` + "\n"},
+		{"x", 422, `{"errors":[{"field":"/name","message":"required"},{"message":"too short"}]}` + "\n"},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/x", strings.NewReader(tt.body))
+		mux.ServeHTTP(w, r)
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+			t.Errorf("POST %q: got %d %q; want %d %q",
+				tt.body, w.Code, w.Body, tt.wantCode, tt.wantBody)
+		}
+	}
+}